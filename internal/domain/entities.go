@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // Tool represents a Claude custom tool definition
 type Tool struct {
@@ -20,10 +25,319 @@ type Config struct {
 	Verbose       bool
 	Interactive   bool
 	ThoughtPrompt string
+	ModelFallback []string
+	Analyzer      string // name of the analyzer producing the tool result ("default" when empty)
+
+	// RaceModels are sent the initial request at the same time as Model; the
+	// first successful response is used, with its model for the rest of the
+	// analysis, and the other requests are cancelled. A cancelled request may
+	// still be billed for the input tokens and any output produced so far.
+	RaceModels []string
+
+	// NoFollowup stops after the initial response, returning the tool_use request
+	NoFollowup bool
+
+	// AbortOnInvalidToolUse fails instead of asking Claude to retry an empty tool_use input
+	AbortOnInvalidToolUse bool
+
+	// ErrorOnEmptyToolResult fails when the analyzer returns nothing, instead of
+	// sending a tool_result without content
+	ErrorOnEmptyToolResult bool
+
+	// Sentiment scores the thought's tone locally and attaches it to the response
+	Sentiment bool
+
+	// IncludeToolTrace records each tool_use and the tool_result returned for it
+	IncludeToolTrace bool
+
+	// IncludeInput attaches the analyzed thought to the response
+	IncludeInput bool
+
+	// NoEnv ignores environment variables such as ANTHROPIC_API_KEY, so settings
+	// come only from flags and the config file
+	NoEnv bool
+
+	// ForceTool names the tool Claude must call in the initial request, sent as
+	// tool_choice (empty to let Claude decide)
+	ForceTool string
+
+	// ContentBlockTypes are the content block types that contribute to the
+	// analysis text, in response order (text blocks only when empty)
+	ContentBlockTypes []string
+
+	// ExplainNoTool asks Claude in a follow-up why it answered without the think tool
+	ExplainNoTool bool
+
+	// ModelMaxTokens is the most output tokens each model accepts (the built-in
+	// table when nil). A larger MaxTokens is an error, or lowered to the limit
+	// with a warning when ClampMaxTokens is set.
+	ModelMaxTokens map[string]int
+	ClampMaxTokens bool
+
+	// StructuredOutput is the JSON schema of the result in structured output
+	// mode: Claude is made to answer through a tool with this input schema
+	StructuredOutput map[string]interface{}
+
+	// MaxPauseTurns caps how often a request is re-sent to let Claude continue
+	// a paused turn (stop_reason pause_turn); 0 returns the paused response
+	MaxPauseTurns int
+
+	// StripToolResultPrefix is a regular expression whose match at the start of
+	// the analyzer's text is removed before the tool result is sent
+	StripToolResultPrefix string
+
+	// ToolResult, when set, is sent as the tool result instead of running the analyzer
+	ToolResult string
+
+	// Chunking of long thoughts (ChunkSize in characters, i.e. runes, 0 disables it)
+	ChunkSize    int
+	ChunkOverlap int
+
+	// PromptTemplate is a Go text/template for the user prompt (takes precedence over ThoughtPrompt)
+	PromptTemplate string
+
+	// FollowupPrompt is a Go text/template sent as a text block after the tool
+	// result, guiding the final answer; nothing is added when empty
+	FollowupPrompt string
+
+	// Focus restricts the analysis to these FocusCategories (all when empty)
+	Focus []string
+
+	// NormalizeNewlines converts CRLF and CR line endings in the thought to LF
+	// before anything else is done with it
+	NormalizeNewlines bool
+
+	// Redaction applied to the thought before it is sent: built-in PII patterns
+	// and custom regular expressions
+	RedactPII      bool
+	RedactPatterns []string
+
+	// RetryOnStatus overrides the API client's built-in retryable HTTP statuses when non-nil
+	RetryOnStatus []int
+
+	// RetryBudget caps the retries of all requests in a run combined (0 for unlimited)
+	RetryBudget int
+
+	// MaxResponseBytes caps how much of a response body is read (0 for the client's default)
+	MaxResponseBytes int64
+
+	// UserAgent is sent as the User-Agent header of API requests
+	UserAgent string
+
+	// Routing headers for gateways: OrgID is sent as anthropic-organization-id,
+	// Headers are sent verbatim (and override the built-in headers)
+	OrgID   string
+	Headers map[string]string
+
+	// Provider selects the API the requests are sent to (ProviderAnthropic when
+	// empty); Vertex AI additionally needs a project and region
+	Provider      string
+	VertexProject string
+	VertexRegion  string
+
+	// Simulated is set when canned responses replace the API (CTT_FAKE_API=1)
+	Simulated bool
+
+	// AttemptTimeout bounds each attempt of a request, within Timeout, so a slow
+	// attempt leaves time for retries (0 for no per-attempt limit)
+	AttemptTimeout time.Duration
+
+	// ConnectTimeout bounds dialing and the TLS handshake of a connection, within
+	// the overall Timeout (0 for the transport's defaults)
+	ConnectTimeout time.Duration
+
+	// TLS settings for the API connection
+	CACertFile         string
+	InsecureSkipVerify bool
+
+	// UserID is sent as metadata.user_id so Anthropic can attribute abuse to an end user
+	UserID string
+
+	// SessionID is sent as the request container so server-side tool state persists across turns
+	SessionID string
+
+	// RequestExtra holds extra top-level request fields for API parameters
+	// without a flag; fields the tool sets itself are never overridden
+	RequestExtra map[string]interface{}
+
+	// TimeFormat is the resolved layout (or "unix"/"unixmilli") for emitted timestamps
+	TimeFormat string
+}
+
+// API providers selectable with -provider
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderVertex    = "vertex"
+	ProviderOllama    = "ollama"
+)
+
+// DefaultVertexRegion is the Vertex AI region used unless configured otherwise
+const DefaultVertexRegion = "us-east5"
+
+// Providers lists the supported API providers
+var Providers = []string{ProviderAnthropic, ProviderVertex, ProviderOllama}
+
+// ContentBlockTypes lists the content block types that can contribute to the
+// analysis text
+var ContentBlockTypes = []string{"text", "thinking", "redacted_thinking", "tool_use", "server_tool_use"}
+
+// ParseContentBlockTypes parses a comma-separated list of content block types
+func ParseContentBlockTypes(value string) ([]string, error) {
+	var types []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(ContentBlockTypes, name) {
+			return nil, fmt.Errorf("unknown content block type %q (expected one of %v)", name, ContentBlockTypes)
+		}
+		types = append(types, name)
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no content block types given")
+	}
+	return types, nil
+}
+
+// RequiresAPIKey reports whether the configured provider needs an API key
+// (a local Ollama server and simulated responses do not)
+func (c Config) RequiresAPIKey() bool {
+	return c.Provider != ProviderOllama && !c.Simulated
+}
+
+// RiskLevel is the machine-readable risk classification of an analyzed thought
+type RiskLevel string
+
+// Supported risk levels, ordered from least to most severe
+const (
+	RiskUnknown RiskLevel = ""
+	RiskLow     RiskLevel = "LOW"
+	RiskMedium  RiskLevel = "MEDIUM"
+	RiskHigh    RiskLevel = "HIGH"
+)
+
+// ParseRiskLevel converts a case-insensitive label into a RiskLevel
+func ParseRiskLevel(s string) (RiskLevel, error) {
+	switch level := RiskLevel(strings.ToUpper(strings.TrimSpace(s))); level {
+	case RiskLow, RiskMedium, RiskHigh:
+		return level, nil
+	default:
+		return RiskUnknown, fmt.Errorf("invalid risk level %q (expected low, medium or high)", s)
+	}
+}
+
+// Severity returns the ordinal severity of the level (0 when unknown)
+func (r RiskLevel) Severity() int {
+	switch r {
+	case RiskLow:
+		return 1
+	case RiskMedium:
+		return 2
+	case RiskHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether the level is known and as severe as threshold
+func (r RiskLevel) AtLeast(threshold RiskLevel) bool {
+	return r.Severity() > 0 && r.Severity() >= threshold.Severity()
+}
+
+// Fallacy is a logical fallacy pattern detected in a thought
+type Fallacy struct {
+	Name     string `json:"name"`
+	Evidence string `json:"evidence"`
+}
+
+// AnalysisResult is the output of an Analyzer. Text alone is sent back as a
+// plain string tool_result; Blocks and Data turn it into structured content.
+type AnalysisResult struct {
+	Text      string                   // prose result sent back to Claude
+	Blocks    []map[string]interface{} // extra content blocks (e.g. text or image blocks)
+	Data      map[string]interface{}   // machine-readable data, sent as a JSON text block
+	Fallacies []Fallacy                // structured findings, if the analyzer produces any
+}
+
+// ChunkResult is the analysis of one chunk of a long thought
+type ChunkResult struct {
+	Index   int    `json:"index"`
+	Thought string `json:"thought"`
+	Content string `json:"content"`
+}
+
+// ToolUse is a tool invocation requested by Claude
+type ToolUse struct {
+	ID    string                 `json:"id"`
+	Name  string                 `json:"name"`
+	Input map[string]interface{} `json:"input"`
+}
+
+// ToolTraceEntry records a tool invocation of an analysis together with the
+// tool_result content sent back for it
+type ToolTraceEntry struct {
+	ToolUse
+	Result  interface{} `json:"result,omitempty"` // absent when no tool_result was sent
+	IsError bool        `json:"is_error,omitempty"`
+}
+
+// Usage holds the token counts reported by the API
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+	}
+}
+
+// Sentiment is a quick local estimate of a thought's tone
+type Sentiment struct {
+	Label string  `json:"label"` // positive, neutral or negative
+	Score float64 `json:"score"` // from -1 (negative) to 1 (positive)
 }
 
 // ThinkResponse represents the structured response from a thought analysis
 type ThinkResponse struct {
-	Raw     map[string]interface{}
-	Content string
-}
\ No newline at end of file
+	Raw        map[string]interface{}
+	Content    string
+	RiskLevel  RiskLevel
+	Model      string
+	Refused    bool
+	Usage      Usage // summed over every API call of the analysis
+	Fallacies  []Fallacy
+	Chunks     []ChunkResult // per-chunk analyses when the thought was chunked
+	StopReason string        // stop_reason of the response the content was taken from
+	ToolUse    *ToolUse      // the think tool invocation, if Claude used the tool
+	ToolInput  string        // the thought as restated by Claude in the tool input
+	Timings    Timings       // durations of the analysis stages, in the order they ran
+	RequestID  string        // server-assigned request ID of the final API response
+	Warnings   []string      // non-fatal problems found while parsing the response
+
+	// Retries made by the API client during the analysis and the time spent waiting before them
+	RetryCount     int
+	TotalRetryWait time.Duration
+
+	// Sentiment of the thought, scored locally when Config.Sentiment is set
+	Sentiment *Sentiment
+
+	// Input is the analyzed thought, after any redaction, when Config.IncludeInput is set
+	Input string
+
+	// ToolTrace lists every tool invocation and its result when Config.IncludeToolTrace is set
+	ToolTrace []ToolTraceEntry
+
+	// ToolDecisionRationale is Claude's explanation for not using the think tool,
+	// asked for when Config.ExplainNoTool is set
+	ToolDecisionRationale string
+
+	// Structured is the validated result of Config.StructuredOutput mode;
+	// Content holds the same object as indented JSON
+	Structured map[string]interface{}
+}
@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -165,4 +166,76 @@ func TestThinkResponse(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestParseRiskLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        domain.RiskLevel
+		expectError bool
+	}{
+		{name: "low", input: "low", want: domain.RiskLow},
+		{name: "medium mixed case", input: "Medium", want: domain.RiskMedium},
+		{name: "high with spaces", input: " HIGH ", want: domain.RiskHigh},
+		{name: "invalid", input: "severe", want: domain.RiskUnknown, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseRiskLevel(tt.input)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("ParseRiskLevel(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRiskLevel(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentBlockTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        []string
+		expectError bool
+	}{
+		{name: "single", input: "text", want: []string{"text"}},
+		{name: "several with spaces", input: "thinking, text", want: []string{"thinking", "text"}},
+		{name: "unknown type", input: "text,citations", expectError: true},
+		{name: "empty", input: " , ", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseContentBlockTypes(tt.input)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("ParseContentBlockTypes(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseContentBlockTypes(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRiskLevel_AtLeast(t *testing.T) {
+	tests := []struct {
+		level     domain.RiskLevel
+		threshold domain.RiskLevel
+		want      bool
+	}{
+		{domain.RiskHigh, domain.RiskHigh, true},
+		{domain.RiskHigh, domain.RiskLow, true},
+		{domain.RiskMedium, domain.RiskHigh, false},
+		{domain.RiskLow, domain.RiskMedium, false},
+		{domain.RiskUnknown, domain.RiskLow, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.level.AtLeast(tt.threshold); got != tt.want {
+			t.Errorf("%q.AtLeast(%q) = %v, want %v", tt.level, tt.threshold, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FocusCategories lists the categories an analysis can be restricted to
+var FocusCategories = []string{"clarity", "correctness", "cost", "feasibility", "maintainability", "performance", "security"}
+
+// ParseFocusCategories parses a comma-separated list of focus categories
+// (case-insensitive), dropping duplicates
+func ParseFocusCategories(value string) ([]string, error) {
+	var categories []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(FocusCategories, name) {
+			return nil, fmt.Errorf("unknown focus category %q (expected one of %v)", name, FocusCategories)
+		}
+		if !slices.Contains(categories, name) {
+			categories = append(categories, name)
+		}
+	}
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("no focus categories given")
+	}
+	return categories, nil
+}
+
+// FocusInstruction asks Claude to restrict its observations to categories,
+// tagging each Strengths and Concerns item with its category
+func FocusInstruction(categories []string) string {
+	return fmt.Sprintf("Restrict your observations to %s; leave out anything outside these categories. Start each Strengths and Concerns item with its category in square brackets, such as \"[%s]\".",
+		strings.Join(categories, ", "), categories[0])
+}
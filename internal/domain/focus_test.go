@@ -0,0 +1,44 @@
+package domain_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestParseFocusCategories(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		want        []string
+		expectError bool
+	}{
+		{name: "single", value: "security", want: []string{"security"}},
+		{name: "list with spaces, case and duplicates", value: " Security, clarity,security ", want: []string{"security", "clarity"}},
+		{name: "unknown category", value: "security,vibes", expectError: true},
+		{name: "empty", value: " , ", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseFocusCategories(tt.value)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("ParseFocusCategories(%q) error = %v, expectError %v", tt.value, err, tt.expectError)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFocusCategories(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFocusInstruction(t *testing.T) {
+	instruction := domain.FocusInstruction([]string{"security", "clarity"})
+	for _, want := range []string{"security, clarity", "[security]"} {
+		if !strings.Contains(instruction, want) {
+			t.Errorf("FocusInstruction() = %q, want it to contain %q", instruction, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultModelAliases maps short model names to concrete model ids
+var DefaultModelAliases = map[string]string{
+	"sonnet": "claude-3-7-sonnet-20250219",
+	"opus":   "claude-3-opus-20240229",
+	"haiku":  "claude-3-5-haiku-20241022",
+}
+
+// DefaultModelMaxOutputTokens maps model ids to the most output tokens they
+// accept as max_tokens; models not listed aren't checked
+var DefaultModelMaxOutputTokens = map[string]int{
+	"claude-opus-4-20250514":     32000,
+	"claude-sonnet-4-20250514":   64000,
+	"claude-3-7-sonnet-20250219": 64000,
+	"claude-3-5-sonnet-20241022": 8192,
+	"claude-3-5-haiku-20241022":  8192,
+	"claude-3-opus-20240229":     4096,
+	"claude-3-haiku-20240307":    4096,
+}
+
+// ParseModelAliases parses "alias=model-id,..." overrides into a map
+func ParseModelAliases(value string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		alias, model, ok := strings.Cut(pair, "=")
+		alias, model = strings.TrimSpace(alias), strings.TrimSpace(model)
+		if !ok || alias == "" || model == "" {
+			return nil, fmt.Errorf("invalid model alias %q (expected alias=model-id)", pair)
+		}
+		aliases[strings.ToLower(alias)] = model
+	}
+	return aliases, nil
+}
+
+// MergeModelAliases returns the built-in aliases overridden by overrides
+func MergeModelAliases(overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(DefaultModelAliases)+len(overrides))
+	for alias, model := range DefaultModelAliases {
+		merged[alias] = model
+	}
+	for alias, model := range overrides {
+		merged[alias] = model
+	}
+	return merged
+}
+
+// ParseModelMaxTokens parses "model-id=tokens,..." output limit overrides into a map
+func ParseModelMaxTokens(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		model, tokens, ok := strings.Cut(pair, "=")
+		model = strings.TrimSpace(model)
+		limit, err := strconv.Atoi(strings.TrimSpace(tokens))
+		if !ok || model == "" || err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid model output limit %q (expected model-id=positive-tokens)", pair)
+		}
+		limits[model] = limit
+	}
+	return limits, nil
+}
+
+// MergeModelMaxTokens returns the built-in output limits overridden by overrides
+func MergeModelMaxTokens(overrides map[string]int) map[string]int {
+	merged := make(map[string]int, len(DefaultModelMaxOutputTokens)+len(overrides))
+	for model, limit := range DefaultModelMaxOutputTokens {
+		merged[model] = limit
+	}
+	for model, limit := range overrides {
+		merged[model] = limit
+	}
+	return merged
+}
+
+// ResolveModel maps an alias to its model id. Exact ids (anything containing a
+// hyphen) pass through unchanged; unknown aliases pass through too unless strict.
+func ResolveModel(name string, aliases map[string]string, strict bool) (string, error) {
+	if model, ok := aliases[strings.ToLower(name)]; ok {
+		return model, nil
+	}
+	if strict && !strings.Contains(name, "-") {
+		known := make([]string, 0, len(aliases))
+		for alias := range aliases {
+			known = append(known, alias)
+		}
+		sort.Strings(known)
+		return "", fmt.Errorf("unknown model alias %q (known aliases: %s)", name, strings.Join(known, ", "))
+	}
+	return name, nil
+}
@@ -0,0 +1,73 @@
+package domain_test
+
+import (
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestResolveModel(t *testing.T) {
+	overrides, err := domain.ParseModelAliases("fast=claude-3-5-haiku-latest, sonnet=claude-sonnet-custom")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	aliases := domain.MergeModelAliases(overrides)
+
+	tests := []struct {
+		name        string
+		model       string
+		strict      bool
+		want        string
+		expectError bool
+	}{
+		{name: "built-in alias", model: "opus", want: domain.DefaultModelAliases["opus"]},
+		{name: "alias is case-insensitive", model: "Haiku", want: domain.DefaultModelAliases["haiku"]},
+		{name: "custom alias", model: "fast", want: "claude-3-5-haiku-latest"},
+		{name: "override of built-in", model: "sonnet", want: "claude-sonnet-custom"},
+		{name: "exact id passes through", model: "claude-3-7-sonnet-20250219", want: "claude-3-7-sonnet-20250219"},
+		{name: "exact id passes through when strict", model: "claude-3-7-sonnet-20250219", strict: true, want: "claude-3-7-sonnet-20250219"},
+		{name: "unknown alias passes through", model: "llama", want: "llama"},
+		{name: "unknown alias errors when strict", model: "llama", strict: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ResolveModel(tt.model, aliases, tt.strict)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("ResolveModel(%q) error = %v, expectError %v", tt.model, err, tt.expectError)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveModel(%q) = %q, want %q", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseModelAliases_Invalid(t *testing.T) {
+	for _, value := range []string{"sonnet", "=claude-x", "fast="} {
+		if _, err := domain.ParseModelAliases(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestParseModelMaxTokens(t *testing.T) {
+	overrides, err := domain.ParseModelMaxTokens("claude-custom=2000, claude-3-opus-20240229 = 8192")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	limits := domain.MergeModelMaxTokens(overrides)
+
+	if limits["claude-custom"] != 2000 || limits["claude-3-opus-20240229"] != 8192 {
+		t.Errorf("Expected the overrides to apply, got %v", limits)
+	}
+	if limits["claude-3-5-haiku-20241022"] != domain.DefaultModelMaxOutputTokens["claude-3-5-haiku-20241022"] {
+		t.Errorf("Expected the built-in limits to be kept, got %v", limits)
+	}
+
+	for _, value := range []string{"claude-x", "=100", "claude-x=", "claude-x=many", "claude-x=0"} {
+		if _, err := domain.ParseModelMaxTokens(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
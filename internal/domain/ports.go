@@ -1,6 +1,10 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // ThinkService defines the interface for the core thinking analysis service
 type ThinkService interface {
@@ -12,8 +16,86 @@ type APIClient interface {
 	SendRequest(ctx context.Context, requestMap map[string]interface{}) ([]byte, error)
 }
 
+// Analyzer produces the result returned to Claude when it invokes the think tool
+type Analyzer interface {
+	Analyze(thought string) (*AnalysisResult, error)
+}
+
+// Configurable is implemented by components that adjust themselves to the
+// runtime configuration once it is known (e.g. after flag parsing)
+type Configurable interface {
+	Configure(config Config) error
+}
+
+// Preflighter is implemented by components that can check that the API is
+// reachable and accepts the credentials without running an analysis
+type Preflighter interface {
+	Preflight(ctx context.Context) error
+}
+
+// ErrPreflightUnsupported is returned by a Preflighter whose provider has no
+// preflight check
+var ErrPreflightUnsupported = errors.New("no preflight check for this provider")
+
+// RequestIDReporter is implemented by API clients that can report the
+// server-assigned request ID of the last response they received
+type RequestIDReporter interface {
+	LastRequestID() string
+}
+
 // FileStorage defines the interface for file operations
 type FileStorage interface {
 	ReadFromFile(filePath string) (string, error)
 	WriteToFile(filePath string, content string) error
-}
\ No newline at end of file
+	AppendToFile(filePath string, content string) error
+}
+
+// Clipboard defines the interface for the system clipboard
+type Clipboard interface {
+	ReadClipboard() (string, error)
+	WriteClipboard(text string) error
+}
+
+// ErrClipboardUnavailable is returned by a Clipboard on systems without one
+// (e.g. headless servers or missing clipboard tools)
+var ErrClipboardUnavailable = errors.New("clipboard unavailable")
+
+// DiffSource defines the interface for reading uncommitted code changes
+type DiffSource interface {
+	// ReadDiff returns the diff selected by args (e.g. --staged), empty when
+	// there are no changes
+	ReadDiff(args []string) (string, error)
+}
+
+// ErrPayloadTooLarge is returned by an APIClient when the request was rejected
+// for its size (HTTP 413) before reaching the model. Unlike a context-length
+// error, which the API reports as an invalid request, it says nothing about
+// the model's limits, only about the gateway's.
+var ErrPayloadTooLarge = errors.New("request payload too large")
+
+// DefaultMaxResponseBytes caps API response bodies unless configured otherwise
+const DefaultMaxResponseBytes = 8 << 20
+
+// ErrResponseTooLarge is returned by an APIClient when a response body exceeds
+// the configured size limit
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ErrIncompleteResponse is returned by an APIClient when a 200 response body
+// was cut short or isn't a complete JSON document, e.g. after a dropped
+// connection. Like a retryable status, it is worth another attempt.
+var ErrIncompleteResponse = errors.New("incomplete response body")
+
+// APIError is returned by an APIClient when the API answers with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+	RequestID  string // server-assigned request ID, to quote when contacting support
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("received non-200 response: %d (request-id: %s), body: %s", e.StatusCode, e.RequestID, e.Body)
+	}
+	return fmt.Sprintf("received non-200 response: %d, body: %s", e.StatusCode, e.Body)
+}
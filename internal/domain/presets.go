@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromptPreset is a named, curated ThoughtPrompt for a common style of analysis
+type PromptPreset struct {
+	Name        string
+	Description string
+	Prompt      string
+}
+
+// PromptPresets are the built-in presets, in the order they are listed
+var PromptPresets = []PromptPreset{
+	{
+		Name:        "skeptic",
+		Description: "Question the assumptions and look for what could go wrong",
+		Prompt:      "Take a skeptical view of the following thought: question its assumptions, look for missing evidence and point out what could go wrong.",
+	},
+	{
+		Name:        "supportive",
+		Description: "Build on the strengths and suggest constructive improvements",
+		Prompt:      "Take a supportive view of the following thought: build on its strengths and suggest constructive ways to address its weaknesses.",
+	},
+	{
+		Name:        "devils-advocate",
+		Description: "Argue the strongest case against the thought",
+		Prompt:      "Play devil's advocate against the following thought: argue the strongest case against it, even where you would otherwise agree.",
+	},
+	{
+		Name:        "executive-summary",
+		Description: "Summarize the key point, risks and a recommendation briefly",
+		Prompt:      "Summarize the following thought for an executive audience in a few sentences: the key point, the main risks and a recommendation.",
+	},
+}
+
+// FindPromptPreset returns the preset called name (case-insensitive)
+func FindPromptPreset(name string) (PromptPreset, error) {
+	known := make([]string, 0, len(PromptPresets))
+	for _, preset := range PromptPresets {
+		if strings.EqualFold(preset.Name, strings.TrimSpace(name)) {
+			return preset, nil
+		}
+		known = append(known, preset.Name)
+	}
+	return PromptPreset{}, fmt.Errorf("unknown preset %q (known presets: %s)", name, strings.Join(known, ", "))
+}
@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestFindPromptPreset(t *testing.T) {
+	tests := []struct {
+		name        string
+		preset      string
+		wantPrefix  string
+		expectError bool
+	}{
+		{name: "skeptic", preset: "skeptic", wantPrefix: "Take a skeptical view"},
+		{name: "case-insensitive", preset: "Executive-Summary", wantPrefix: "Summarize the following thought"},
+		{name: "devil's advocate", preset: "devils-advocate", wantPrefix: "Play devil's advocate"},
+		{name: "unknown", preset: "cheerleader", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preset, err := domain.FindPromptPreset(tt.preset)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("FindPromptPreset(%q) error = %v, expectError %v", tt.preset, err, tt.expectError)
+			}
+			if !strings.HasPrefix(preset.Prompt, tt.wantPrefix) {
+				t.Errorf("FindPromptPreset(%q).Prompt = %q, want prefix %q", tt.preset, preset.Prompt, tt.wantPrefix)
+			}
+		})
+	}
+}
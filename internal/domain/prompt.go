@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// PromptData is the data available to prompt templates
+type PromptData struct {
+	Thought string
+}
+
+// RenderPromptTemplate parses and executes a Go text/template prompt
+func RenderPromptTemplate(tmpl string, data PromptData) (string, error) {
+	t, err := template.New("prompt").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return b.String(), nil
+}
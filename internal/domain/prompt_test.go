@@ -0,0 +1,40 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestRenderPromptTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		want        string
+		expectError string
+	}{
+		{name: "thought placeholder", template: "Critique this: {{.Thought}}", want: "Critique this: sample"},
+		{name: "template functions", template: `{{printf "%q" .Thought}}`, want: `"sample"`},
+		{name: "parse error", template: "Critique {{.Thought", expectError: "failed to parse"},
+		{name: "unknown field", template: "{{.Idea}}", expectError: "failed to render"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.RenderPromptTemplate(tt.template, domain.PromptData{Thought: "sample"})
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderPromptTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// redactionRule replaces every match of pattern with placeholder
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}
+
+// piiRules are the built-in PII patterns. Card numbers come before phone
+// numbers so long digit runs are not half-matched as phones.
+var piiRules = []redactionRule{
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), "[CARD]"},
+	{regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]?\d{3,4}[ .-]?\d{3,4}\b`), "[PHONE]"},
+}
+
+// Redactor removes PII and other sensitive patterns from text
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor creates a Redactor using the built-in PII patterns (if pii is
+// set) followed by the given custom regular expressions
+func NewRedactor(pii bool, customPatterns []string) (*Redactor, error) {
+	var rules []redactionRule
+	if pii {
+		rules = append(rules, piiRules...)
+	}
+	for _, pattern := range customPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		rules = append(rules, redactionRule{pattern: re, placeholder: "[REDACTED]"})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// Redact returns text with every match replaced by its placeholder, and the
+// number of replacements made
+func (r *Redactor) Redact(text string) (string, int) {
+	count := 0
+	for _, rule := range r.rules {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return rule.placeholder
+		})
+	}
+	return text, count
+}
@@ -0,0 +1,83 @@
+package domain_test
+
+import (
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	tests := []struct {
+		name      string
+		pii       bool
+		custom    []string
+		text      string
+		want      string
+		wantCount int
+	}{
+		{
+			name:      "email",
+			pii:       true,
+			text:      "Ask jane.doe+ops@example.co.uk about it",
+			want:      "Ask [EMAIL] about it",
+			wantCount: 1,
+		},
+		{
+			name:      "phone numbers",
+			pii:       true,
+			text:      "Call +1 415-555-0132 or (03) 1234 5678",
+			want:      "Call [PHONE] or [PHONE]",
+			wantCount: 2,
+		},
+		{
+			name:      "card number",
+			pii:       true,
+			text:      "Charged 4111 1111 1111 1111 yesterday",
+			want:      "Charged [CARD] yesterday",
+			wantCount: 1,
+		},
+		{
+			name: "ordinary numbers are kept",
+			pii:  true,
+			text: "Engagement up 23% on 2024-01-15, 15% faster",
+			want: "Engagement up 23% on 2024-01-15, 15% faster",
+		},
+		{
+			name:      "custom pattern",
+			pii:       true,
+			custom:    []string{`ACME-\d+`},
+			text:      "Ticket ACME-4521 from bob@example.com",
+			want:      "Ticket [REDACTED] from [EMAIL]",
+			wantCount: 2,
+		},
+		{
+			name:      "custom pattern only",
+			custom:    []string{`(?i)project \w+`},
+			text:      "Project Falcon, contact bob@example.com",
+			want:      "[REDACTED], contact bob@example.com",
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redactor, err := domain.NewRedactor(tt.pii, tt.custom)
+			if err != nil {
+				t.Fatalf("NewRedactor returned error: %v", err)
+			}
+			got, count := redactor.Redact(tt.text)
+			if got != tt.want {
+				t.Errorf("Redact() = %q, want %q", got, tt.want)
+			}
+			if count != tt.wantCount {
+				t.Errorf("count = %d, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestNewRedactor_InvalidPattern(t *testing.T) {
+	if _, err := domain.NewRedactor(false, []string{"ACME-("}); err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProtectedRequestFields are the request fields the tool builds itself, which
+// extra request fields may not override
+var ProtectedRequestFields = []string{"max_tokens", "messages", "model", "stream", "system", "tools"}
+
+// ParseRequestExtra parses a JSON object of extra top-level request fields,
+// rejecting anything but an object and any protected field
+func ParseRequestExtra(value string) (map[string]interface{}, error) {
+	var extra map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &extra); err != nil || extra == nil {
+		return nil, fmt.Errorf("expected a JSON object, e.g. {\"temperature\": 0.2}")
+	}
+
+	var protected []string
+	for _, field := range ProtectedRequestFields {
+		if _, ok := extra[field]; ok {
+			protected = append(protected, field)
+		}
+	}
+	if len(protected) > 0 {
+		return nil, fmt.Errorf("cannot override protected request fields: %s", strings.Join(protected, ", "))
+	}
+	return extra, nil
+}
@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestParseRequestExtra(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      map[string]interface{}
+		wantError string
+	}{
+		{name: "object", value: `{"temperature": 0.2, "top_k": 5}`, want: map[string]interface{}{"temperature": 0.2, "top_k": float64(5)}},
+		{name: "empty object", value: `{}`, want: map[string]interface{}{}},
+		{name: "array", value: `[1]`, wantError: "expected a JSON object"},
+		{name: "null", value: `null`, wantError: "expected a JSON object"},
+		{name: "malformed", value: `{"temperature":`, wantError: "expected a JSON object"},
+		{name: "protected fields", value: `{"model": "x", "messages": [], "top_k": 5}`, wantError: "cannot override protected request fields: messages, model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseRequestExtra(tt.value)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("ParseRequestExtra(%q) error = %v, want %q", tt.value, err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRequestExtra(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"sync"
+)
+
+// RequestID records the server-assigned request ID of the latest response an
+// API client received on behalf of one analysis. It travels in the request
+// context, so requests sent at once, such as a race's, don't overwrite each
+// other's IDs.
+type RequestID struct {
+	mu sync.Mutex
+	id string
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a new, empty RequestID
+func WithRequestID(ctx context.Context) (context.Context, *RequestID) {
+	requestID := &RequestID{}
+	return context.WithValue(ctx, requestIDKey{}, requestID), requestID
+}
+
+// RequestIDFrom returns the RequestID carried by ctx, or nil
+func RequestIDFrom(ctx context.Context) *RequestID {
+	requestID, _ := ctx.Value(requestIDKey{}).(*RequestID)
+	return requestID
+}
+
+// Record stores the request ID of a response
+func (r *RequestID) Record(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.id = id
+}
+
+// Value returns the latest recorded request ID
+func (r *RequestID) Value() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.id
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RetryStats collects the retries an API client makes on behalf of one
+// analysis. It travels in the request context, so concurrent analyses keep
+// separate counts.
+type RetryStats struct {
+	mu    sync.Mutex
+	count int
+	wait  time.Duration
+}
+
+type retryStatsKey struct{}
+
+// WithRetryStats returns a context carrying a new, empty RetryStats
+func WithRetryStats(ctx context.Context) (context.Context, *RetryStats) {
+	stats := &RetryStats{}
+	return context.WithValue(ctx, retryStatsKey{}, stats), stats
+}
+
+// RetryStatsFrom returns the RetryStats carried by ctx, or nil
+func RetryStatsFrom(ctx context.Context) *RetryStats {
+	stats, _ := ctx.Value(retryStatsKey{}).(*RetryStats)
+	return stats
+}
+
+// Record counts one retry made after waiting for wait
+func (s *RetryStats) Record(wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	s.wait += wait
+}
+
+// Totals returns the number of retries and the total time spent waiting before them
+func (s *RetryStats) Totals() (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count, s.wait
+}
@@ -0,0 +1,89 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["risk", "concerns"],
+	"additionalProperties": false,
+	"properties": {
+		"risk": {"type": "string", "enum": ["low", "medium", "high"]},
+		"score": {"type": "integer"},
+		"concerns": {"type": "array", "items": {"type": "string"}},
+		"notes": {"type": ["string", "null"]}
+	}
+}`
+
+func TestParseOutputSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantError string
+	}{
+		{name: "object schema", value: testSchema},
+		{name: "not an object schema", value: `{"type": "array"}`, wantError: "must describe an object"},
+		{name: "not JSON", value: `{"type":`, wantError: "expected a JSON schema object"},
+		{name: "not an object", value: `[]`, wantError: "expected a JSON schema object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.ParseOutputSchema(tt.value)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema, err := domain.ParseOutputSchema(testSchema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		value     string
+		wantError string
+	}{
+		{name: "valid", value: `{"risk": "high", "score": 7, "concerns": ["untested"], "notes": null}`},
+		{name: "missing required property", value: `{"risk": "high"}`, wantError: `$: missing required property "concerns"`},
+		{name: "wrong type", value: `{"risk": "high", "concerns": "untested"}`, wantError: "$.concerns: expected array, got string"},
+		{name: "wrong item type", value: `{"risk": "high", "concerns": ["ok", 3]}`, wantError: "$.concerns[1]: expected string, got number"},
+		{name: "not in enum", value: `{"risk": "severe", "concerns": []}`, wantError: "$.risk: severe is not one of"},
+		{name: "not an integer", value: `{"risk": "low", "score": 7.5, "concerns": []}`, wantError: "$.score: expected integer, got number"},
+		{name: "unexpected property", value: `{"risk": "low", "concerns": [], "extra": true}`, wantError: `$: unexpected property "extra"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("Invalid test value: %v", err)
+			}
+			err := domain.ValidateSchema(value, schema)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AnalysisSections are the items of the Strengths, Concerns and
+// Recommendation sections of an analysis
+type AnalysisSections struct {
+	Strengths      []string
+	Concerns       []string
+	Recommendation []string
+
+	// Warnings describe the sections that are missing or have no items
+	Warnings []string
+}
+
+// sectionHeadingPattern matches a section heading, with optional markdown
+// heading marks or emphasis, and captures any text after it on the same line.
+// Focused analyses may qualify the heading with a focus category, as in
+// "Security concerns".
+var sectionHeadingPattern = regexp.MustCompile(`(?i)^(?:#+\s*)?\**\s*(?:(?:` + strings.Join(FocusCategories, "|") + `)\s+)?(strengths|concerns|recommendations?)\s*\**\s*:?\s*\**\s*(.*)$`)
+
+// otherHeadingPattern matches headings and labels that end a section: a
+// markdown heading, a line ending in ":" or the risk level label
+var otherHeadingPattern = regexp.MustCompile(`(?i)^(?:#+\s|\**[^-*•\d].{0,60}:\**$|\W*risk\s*level\b)`)
+
+// listMarkerPattern matches a bullet or number at the start of a list item
+var listMarkerPattern = regexp.MustCompile(`^(?:[-*•+]|\d+[.)])\s+`)
+
+// ParseSections extracts the Strengths, Concerns and Recommendation items of
+// an analysis. Each list item, or other non-empty line, of a section is an
+// item; text after a heading on the same line is an item too.
+func ParseSections(content string) AnalysisSections {
+	var sections AnalysisSections
+	var current *[]string
+	found := make(map[*[]string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if match := sectionHeadingPattern.FindStringSubmatch(line); match != nil {
+			switch strings.ToLower(match[1]) {
+			case "strengths":
+				current = &sections.Strengths
+			case "concerns":
+				current = &sections.Concerns
+			default:
+				current = &sections.Recommendation
+			}
+			found[current] = true
+			if rest := strings.TrimSpace(match[2]); rest != "" {
+				*current = append(*current, rest)
+			}
+			continue
+		}
+		if otherHeadingPattern.MatchString(line) {
+			current = nil
+			continue
+		}
+
+		if current != nil {
+			*current = append(*current, listMarkerPattern.ReplaceAllString(line, ""))
+		}
+	}
+
+	for _, section := range []struct {
+		name  string
+		items *[]string
+	}{
+		{"strengths", &sections.Strengths},
+		{"concerns", &sections.Concerns},
+		{"recommendation", &sections.Recommendation},
+	} {
+		switch {
+		case !found[section.items]:
+			sections.Warnings = append(sections.Warnings, "no "+section.name+" section found")
+		case len(*section.items) == 0:
+			sections.Warnings = append(sections.Warnings, "the "+section.name+" section has no items")
+		}
+	}
+	return sections
+}
@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"reflect"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestParseSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    domain.AnalysisSections
+	}{
+		{
+			name:    "plain headings with bullets",
+			content: "I've analyzed the thought.\n\nStrengths:\n- Clear\n- Short\n\nConcerns:\n- No evidence\n\nRecommendation:\n- Add data\n\nRisk level: MEDIUM",
+			want: domain.AnalysisSections{
+				Strengths:      []string{"Clear", "Short"},
+				Concerns:       []string{"No evidence"},
+				Recommendation: []string{"Add data"},
+			},
+		},
+		{
+			name:    "markdown headings, numbered items and inline text",
+			content: "## Strengths\n* Ambitious\n\n**Concerns:**\n1. Security testing is incomplete\n2) Rollout plan is vague\n\n**Recommendations:** Finish security testing first\n\n**Risk level:** HIGH",
+			want: domain.AnalysisSections{
+				Strengths:      []string{"Ambitious"},
+				Concerns:       []string{"Security testing is incomplete", "Rollout plan is vague"},
+				Recommendation: []string{"Finish security testing first"},
+			},
+		},
+		{
+			name:    "other headings end a section",
+			content: "Concerns:\n- Cost\n\nSummary:\nA fine plan overall.",
+			want: domain.AnalysisSections{
+				Concerns: []string{"Cost"},
+				Warnings: []string{"no strengths section found", "no recommendation section found"},
+			},
+		},
+		{
+			name:    "focused output with category headings and tags",
+			content: "### Security concerns\n- [security] Tokens are logged\n\n**Security Strengths:**\n- [security] Inputs are validated\n\nRisk level: HIGH",
+			want: domain.AnalysisSections{
+				Strengths: []string{"[security] Inputs are validated"},
+				Concerns:  []string{"[security] Tokens are logged"},
+				Warnings:  []string{"no recommendation section found"},
+			},
+		},
+		{
+			name:    "empty section",
+			content: "Strengths:\n- Clear\n\nConcerns:\n\nRecommendation:\n- Ship it",
+			want: domain.AnalysisSections{
+				Strengths:      []string{"Clear"},
+				Recommendation: []string{"Ship it"},
+				Warnings:       []string{"the concerns section has no items"},
+			},
+		},
+		{
+			name:    "no sections",
+			content: "The plan looks fine.",
+			want: domain.AnalysisSections{
+				Warnings: []string{"no strengths section found", "no concerns section found", "no recommendation section found"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.ParseSections(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSections() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
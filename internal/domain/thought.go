@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxThoughtLength is the default character limit checked by ValidateThought
+const DefaultMaxThoughtLength = 100000
+
+// maxControlRatio is the share of control characters above which a thought
+// is treated as binary data rather than text
+const maxControlRatio = 0.1
+
+// ValidateThought runs local sanity checks on a thought before it is sent to
+// the API and returns the reasons it fails them (none when it is usable)
+func ValidateThought(thought string, maxLength int) []string {
+	var problems []string
+
+	if strings.TrimSpace(thought) == "" {
+		return append(problems, "thought is empty")
+	}
+
+	if !utf8.ValidString(thought) {
+		problems = append(problems, "thought is not valid UTF-8")
+	}
+
+	if length := utf8.RuneCountInString(thought); maxLength > 0 && length > maxLength {
+		problems = append(problems, fmt.Sprintf("thought is %d characters long (limit %d)", length, maxLength))
+	}
+
+	if strings.ContainsRune(thought, 0) {
+		problems = append(problems, "thought contains NUL bytes (binary data?)")
+	} else if ratio := controlRatio(thought); ratio > maxControlRatio {
+		problems = append(problems, fmt.Sprintf("%.0f%% of the thought are control characters (binary data?)", ratio*100))
+	}
+
+	return problems
+}
+
+// NormalizeNewlines converts CRLF and lone CR line endings to LF
+func NormalizeNewlines(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+// controlRatio returns the share of control characters other than common whitespace
+func controlRatio(s string) float64 {
+	var total, control int
+	for _, r := range s {
+		total++
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			control++
+		}
+	}
+	return float64(control) / float64(total)
+}
@@ -0,0 +1,65 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestValidateThought(t *testing.T) {
+	tests := []struct {
+		name        string
+		thought     string
+		maxLength   int
+		wantProblem string
+	}{
+		{name: "valid thought", thought: "We should launch next week.\n\tMaybe.", maxLength: 100},
+		{name: "multi-byte characters count once", thought: "日本はかっこいい", maxLength: 8},
+		{name: "empty", thought: "", maxLength: 100, wantProblem: "thought is empty"},
+		{name: "whitespace only", thought: " \n\t ", maxLength: 100, wantProblem: "thought is empty"},
+		{name: "invalid UTF-8", thought: "caf\xe9 au lait", maxLength: 100, wantProblem: "not valid UTF-8"},
+		{name: "over length", thought: strings.Repeat("a", 11), maxLength: 10, wantProblem: "11 characters long (limit 10)"},
+		{name: "no limit", thought: strings.Repeat("a", 11), maxLength: 0},
+		{name: "NUL bytes", thought: "text\x00more", maxLength: 100, wantProblem: "NUL bytes"},
+		{name: "control characters", thought: "ab\x01\x02\x03cd", maxLength: 100, wantProblem: "control characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := domain.ValidateThought(tt.thought, tt.maxLength)
+
+			if tt.wantProblem == "" {
+				if len(problems) > 0 {
+					t.Errorf("Expected no problems, got %v", problems)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(problems, "; "), tt.wantProblem) {
+				t.Errorf("Expected a problem containing %q, got %v", tt.wantProblem, problems)
+			}
+		})
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "LF unchanged", input: "one\ntwo\n", want: "one\ntwo\n"},
+		{name: "CRLF", input: "one\r\ntwo\r\n", want: "one\ntwo\n"},
+		{name: "lone CR", input: "one\rtwo\r", want: "one\ntwo\n"},
+		{name: "mixed", input: "one\r\ntwo\nthree\rfour\r\n\r\nfive", want: "one\ntwo\nthree\nfour\n\nfive"},
+		{name: "CR before CRLF", input: "one\r\r\ntwo", want: "one\n\ntwo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.NormalizeNewlines(tt.input); got != tt.want {
+				t.Errorf("NormalizeNewlines(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// Timing is the wall-clock duration of one named stage of a run
+type Timing struct {
+	Stage    string
+	Duration time.Duration
+}
+
+// Timings collects stage durations in the order the stages ran
+type Timings []Timing
+
+// Track starts timing stage and returns a function that records it when called
+func (t *Timings) Track(stage string) func() {
+	start := time.Now()
+	return func() {
+		*t = append(*t, Timing{Stage: stage, Duration: time.Since(start)})
+	}
+}
+
+// Merge adds other's durations into t, summing stages present in both
+func (t *Timings) Merge(other Timings) {
+	for _, timing := range other {
+		merged := false
+		for i := range *t {
+			if (*t)[i].Stage == timing.Stage {
+				(*t)[i].Duration += timing.Duration
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			*t = append(*t, timing)
+		}
+	}
+}
+
+// Total returns the sum of all stage durations
+func (t Timings) Total() time.Duration {
+	var total time.Duration
+	for _, timing := range t {
+		total += timing.Duration
+	}
+	return total
+}
@@ -0,0 +1,53 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"claude-think-tool/internal/domain"
+)
+
+func TestTimingsTrack(t *testing.T) {
+	var timings domain.Timings
+	stop := timings.Track("initial_request")
+	time.Sleep(time.Millisecond)
+	stop()
+
+	if len(timings) != 1 {
+		t.Fatalf("Expected 1 timing, got %d", len(timings))
+	}
+	if timings[0].Stage != "initial_request" {
+		t.Errorf("Stage = %q, want initial_request", timings[0].Stage)
+	}
+	if timings[0].Duration < time.Millisecond {
+		t.Errorf("Duration = %v, want at least 1ms", timings[0].Duration)
+	}
+}
+
+func TestTimingsMerge(t *testing.T) {
+	timings := domain.Timings{
+		{Stage: "prompt_build", Duration: 1 * time.Millisecond},
+		{Stage: "initial_request", Duration: 10 * time.Millisecond},
+	}
+	timings.Merge(domain.Timings{
+		{Stage: "initial_request", Duration: 5 * time.Millisecond},
+		{Stage: "synthesis_request", Duration: 20 * time.Millisecond},
+	})
+
+	want := domain.Timings{
+		{Stage: "prompt_build", Duration: 1 * time.Millisecond},
+		{Stage: "initial_request", Duration: 15 * time.Millisecond},
+		{Stage: "synthesis_request", Duration: 20 * time.Millisecond},
+	}
+	if len(timings) != len(want) {
+		t.Fatalf("Merge() = %v, want %v", timings, want)
+	}
+	for i := range want {
+		if timings[i] != want[i] {
+			t.Errorf("timings[%d] = %v, want %v", i, timings[i], want[i])
+		}
+	}
+	if total := timings.Total(); total != 36*time.Millisecond {
+		t.Errorf("Total() = %v, want 36ms", total)
+	}
+}
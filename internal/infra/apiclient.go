@@ -4,49 +4,240 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"claude-think-tool/internal/domain"
 )
 
 // Constants for Claude API
 const (
 	AnthropicAPIURL     = "https://api.anthropic.com/v1/messages"
 	AnthropicAPIVersion = "2023-06-01"
+	OrganizationHeader  = "anthropic-organization-id"
+)
+
+// RequestIDHeaders carry the server-assigned request ID, in order of preference
+var RequestIDHeaders = []string{"anthropic-request-id", "request-id"}
+
+// Retry defaults used by SendRequest
+const (
+	DefaultMaxRetries   = 2
+	DefaultRetryBackoff = time.Second
 )
 
+// DefaultRetryStatuses are the HTTP statuses retried unless overridden with -retry-on-status
+var DefaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	529, // Anthropic "overloaded"
+}
+
 // ClaudeAPIClient implements the domain.APIClient interface
 type ClaudeAPIClient struct {
 	Client  *http.Client
 	APIKey  string
 	BaseURL string // Can be overridden for testing
+
+	MaxRetries    int           // retries after the first attempt
+	RetryStatuses map[int]bool  // statuses worth retrying
+	RetryBackoff  time.Duration // wait before the first retry, doubled for each further one
+	RetryBudget   *RetryBudget  // retries shared by every request of the run (nil for unlimited)
+
+	// AttemptTimeout bounds each attempt within the request context's deadline,
+	// so a slow attempt leaves time for retries (0 for no per-attempt limit)
+	AttemptTimeout time.Duration
+
+	// Headers are extra headers sent with every request (org routing, -header)
+	Headers http.Header
+
+	// UserAgent identifies the tool to the API (Go's default when empty)
+	UserAgent string
+
+	// MaxResponseBytes is the most of a response body that is read before giving up
+	MaxResponseBytes int64
+
+	lastRequestID atomic.Value // string
 }
 
 // NewClaudeAPIClient creates a new API client for Claude
 func NewClaudeAPIClient(client *http.Client, apiKey string) *ClaudeAPIClient {
 	return &ClaudeAPIClient{
-		Client:  client,
-		APIKey:  apiKey,
-		BaseURL: AnthropicAPIURL,
+		Client:        client,
+		APIKey:        apiKey,
+		BaseURL:       AnthropicAPIURL,
+		MaxRetries:    DefaultMaxRetries,
+		RetryStatuses: statusSet(DefaultRetryStatuses),
+		RetryBackoff:  DefaultRetryBackoff,
+
+		MaxResponseBytes: domain.DefaultMaxResponseBytes,
+	}
+}
+
+// Configure rebuilds the underlying HTTP client from the runtime configuration
+// and applies the retry policy
+func (c *ClaudeAPIClient) Configure(config domain.Config) error {
+	client, err := NewHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	c.Client = client
+	c.UserAgent = config.UserAgent
+	// A key from the configuration replaces the one the client was created
+	// with, which -no-env must not use at all
+	if config.APIKey != "" || config.NoEnv {
+		c.APIKey = config.APIKey
+	}
+	if config.MaxResponseBytes > 0 {
+		c.MaxResponseBytes = config.MaxResponseBytes
 	}
+
+	c.Headers = make(http.Header)
+	if config.OrgID != "" {
+		c.Headers.Set(OrganizationHeader, config.OrgID)
+	}
+	for name, value := range config.Headers {
+		c.Headers.Set(name, value)
+	}
+
+	if config.RetryOnStatus != nil {
+		c.RetryStatuses = statusSet(config.RetryOnStatus)
+	}
+	if config.RetryBudget > 0 {
+		c.RetryBudget = NewRetryBudget(config.RetryBudget)
+	}
+	c.AttemptTimeout = config.AttemptTimeout
+	return nil
 }
 
-// SendRequest sends a JSON request to the Claude API
+// SendRequest sends a JSON request to the Claude API, retrying retryable
+// statuses with exponential backoff
 func (c *ClaudeAPIClient) SendRequest(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
 	requestJSON, err := json.Marshal(requestMap)
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize request: %w", err)
 	}
+	return c.post(ctx, c.BaseURL, requestJSON, c.setAuthHeaders)
+}
+
+// Preflight checks that the API is reachable and accepts the key by listing
+// the models, which costs no tokens
+func (c *ClaudeAPIClient) Preflight(ctx context.Context) error {
+	_, err := c.send(ctx, strings.TrimSuffix(c.BaseURL, "/messages")+"/models", nil, c.setAuthHeaders)
+	return err
+}
+
+// setAuthHeaders authenticates a request to the Anthropic API
+func (c *ClaudeAPIClient) setAuthHeaders(header http.Header) {
+	header.Set("x-api-key", c.APIKey)
+	header.Set("anthropic-version", AnthropicAPIVersion)
+}
+
+// post sends requestJSON to url, authenticated by setAuth, retrying retryable
+// statuses and incomplete responses with exponential backoff. Other providers
+// reuse it so they share the client's retry policy, headers and limits.
+func (c *ClaudeAPIClient) post(ctx context.Context, url string, requestJSON []byte, setAuth func(http.Header)) ([]byte, error) {
+	backoff := c.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := c.attemptContext(ctx)
+		responseData, err := c.send(attemptCtx, url, requestJSON, setAuth)
+		cancel()
+		// An attempt that ran out of its own time is worth retrying while the
+		// request's deadline hasn't passed
+		timedOut := errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil
+		if err == nil || attempt >= c.MaxRetries || !(c.retryable(err) || timedOut || errors.Is(err, domain.ErrIncompleteResponse)) {
+			return responseData, err
+		}
+		if c.RetryBudget != nil && !c.RetryBudget.Take() {
+			return nil, fmt.Errorf("retry budget exhausted: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		if stats := domain.RetryStatsFrom(ctx); stats != nil {
+			stats.Record(backoff)
+		}
+		backoff *= 2
+	}
+}
+
+// attemptContext derives the context of one attempt: ctx limited to
+// AttemptTimeout, or to ctx's own deadline when that comes first
+func (c *ClaudeAPIClient) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.AttemptTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.AttemptTimeout)
+}
+
+// LastRequestID returns the request ID of the most recent response, if the
+// server sent one
+func (c *ClaudeAPIClient) LastRequestID() string {
+	requestID, _ := c.lastRequestID.Load().(string)
+	return requestID
+}
+
+// RetryBudget is a number of retries shared by concurrent requests
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+// NewRetryBudget creates a budget allowing n retries in total
+func NewRetryBudget(n int) *RetryBudget {
+	budget := &RetryBudget{}
+	budget.remaining.Store(int64(n))
+	return budget
+}
+
+// Take consumes one retry, reporting false once the budget is exhausted
+func (b *RetryBudget) Take() bool {
+	return b.remaining.Add(-1) >= 0
+}
+
+// Remaining returns the number of retries left
+func (b *RetryBudget) Remaining() int {
+	if remaining := b.remaining.Load(); remaining > 0 {
+		return int(remaining)
+	}
+	return 0
+}
+
+// retryable reports whether err is an API error with a status in the retry set
+func (c *ClaudeAPIClient) retryable(err error) bool {
+	var apiErr *domain.APIError
+	return errors.As(err, &apiErr) && c.RetryStatuses[apiErr.StatusCode]
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL, bytes.NewBuffer(requestJSON))
+// send performs a single request attempt
+func (c *ClaudeAPIClient) send(ctx context.Context, url string, requestJSON []byte, setAuth func(http.Header)) ([]byte, error) {
+	method, body := "POST", io.Reader(bytes.NewReader(requestJSON))
+	if requestJSON == nil {
+		method, body = "GET", nil
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("x-api-key", c.APIKey)
-	req.Header.Set("anthropic-version", AnthropicAPIVersion)
+	setAuth(req.Header)
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	for name, values := range c.Headers {
+		req.Header[name] = values
+	}
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -54,18 +245,78 @@ func (c *ClaudeAPIClient) SendRequest(ctx context.Context, requestMap map[string
 	}
 	defer resp.Body.Close()
 
+	requestID := responseRequestID(resp.Header)
+	c.lastRequestID.Store(requestID)
+	if recorder := domain.RequestIDFrom(ctx); recorder != nil {
+		recorder.Record(requestID)
+	}
+
+	if resp.StatusCode == http.StatusRequestEntityTooLarge {
+		return nil, fmt.Errorf("%w (HTTP 413%s): shorten the thought or analyze it in chunks with -chunk-size", domain.ErrPayloadTooLarge, requestIDSuffix(requestID))
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, readErr := io.ReadAll(resp.Body)
+		bodyBytes, readErr := c.readBody(resp.Body)
 		if readErr != nil {
-			return nil, fmt.Errorf("received non-200 response: %d, failed to read body: %w", resp.StatusCode, readErr)
+			return nil, fmt.Errorf("received non-200 response: %d%s, failed to read body: %w", resp.StatusCode, requestIDSuffix(requestID), readErr)
 		}
-		return nil, fmt.Errorf("received non-200 response: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &domain.APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes), RequestID: requestID}
 	}
 
-	responseData, err := io.ReadAll(resp.Body)
-	if err != nil {
+	responseData, err := c.readBody(resp.Body)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// The connection ended before the length the server announced
+		return nil, fmt.Errorf("%w%s: %v", domain.ErrIncompleteResponse, requestIDSuffix(requestID), err)
+	case err != nil:
 		return nil, fmt.Errorf("failed to read response body: %w", err)
+	case !json.Valid(responseData):
+		// A chunked body cut short ends cleanly but holds truncated JSON
+		return nil, fmt.Errorf("%w%s: got %d bytes that aren't a complete JSON document", domain.ErrIncompleteResponse, requestIDSuffix(requestID), len(responseData))
 	}
 
 	return responseData, nil
-}
\ No newline at end of file
+}
+
+// readBody reads a response body, failing once it exceeds MaxResponseBytes
+// instead of buffering whatever a misbehaving server sends
+func (c *ClaudeAPIClient) readBody(body io.Reader) ([]byte, error) {
+	if c.MaxResponseBytes <= 0 {
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, c.MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.MaxResponseBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes (raise -max-response-bytes if this is expected)", domain.ErrResponseTooLarge, c.MaxResponseBytes)
+	}
+	return data, nil
+}
+
+// responseRequestID returns the first request ID header present in header
+func responseRequestID(header http.Header) string {
+	for _, name := range RequestIDHeaders {
+		if requestID := header.Get(name); requestID != "" {
+			return requestID
+		}
+	}
+	return ""
+}
+
+// requestIDSuffix formats a request ID for inclusion in an error message
+func requestIDSuffix(requestID string) string {
+	if requestID == "" {
+		return ""
+	}
+	return ", request-id: " + requestID
+}
+
+// statusSet converts a list of HTTP statuses into a lookup set
+func statusSet(statuses []int) map[int]bool {
+	set := make(map[int]bool, len(statuses))
+	for _, status := range statuses {
+		set[status] = true
+	}
+	return set
+}
@@ -3,11 +3,16 @@ package infra_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"claude-think-tool/internal/domain"
 	"claude-think-tool/internal/infra"
 )
 
@@ -95,6 +100,11 @@ func TestClaudeAPIClient_SendRequest(t *testing.T) {
 			if tt.expectError {
 				if err == nil {
 					t.Errorf("Expected error, got nil")
+					return
+				}
+				var apiErr *domain.APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.serverStatus {
+					t.Errorf("Expected APIError with status %d, got %v", tt.serverStatus, err)
 				}
 				return
 			}
@@ -117,4 +127,566 @@ func TestClaudeAPIClient_SendRequest(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestClaudeAPIClient_RetryOnStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryOnStatus []int
+		failStatus    int
+		expectRetry   bool
+	}{
+		{name: "default set retries overloaded", failStatus: 529, expectRetry: true},
+		{name: "default set does not retry bad request", failStatus: http.StatusBadRequest, expectRetry: false},
+		{name: "custom status triggers a retry", retryOnStatus: []int{http.StatusTeapot}, failStatus: http.StatusTeapot, expectRetry: true},
+		{name: "removed default status is not retried", retryOnStatus: []int{http.StatusTeapot}, failStatus: 529, expectRetry: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"id": "msg_123"})
+			}))
+			defer server.Close()
+
+			apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+			apiClient.BaseURL = server.URL
+			apiClient.RetryBackoff = time.Millisecond
+			if err := apiClient.Configure(domain.Config{Timeout: 10 * time.Second, RetryOnStatus: tt.retryOnStatus}); err != nil {
+				t.Fatalf("Configure returned error: %v", err)
+			}
+
+			_, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+
+			if tt.expectRetry {
+				if err != nil {
+					t.Errorf("Expected success after retry, got %v", err)
+				}
+				if attempts != 2 {
+					t.Errorf("Expected 2 attempts, got %d", attempts)
+				}
+				return
+			}
+			var apiErr *domain.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.failStatus {
+				t.Errorf("Expected APIError with status %d, got %v", tt.failStatus, err)
+			}
+			if attempts != 1 {
+				t.Errorf("Expected a single attempt, got %d", attempts)
+			}
+		})
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestClaudeAPIClient_AttemptTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		outer          time.Duration
+		attemptTimeout time.Duration
+		wantAttempts   int
+		check          func(t *testing.T, remaining []time.Duration)
+	}{
+		{
+			name:           "attempt timeout leaves time for retries",
+			outer:          5 * time.Second,
+			attemptTimeout: 50 * time.Millisecond,
+			wantAttempts:   3,
+			check: func(t *testing.T, remaining []time.Duration) {
+				for i, r := range remaining {
+					if r > 50*time.Millisecond {
+						t.Errorf("attempt %d had %s left, want at most the 50ms attempt timeout", i+1, r)
+					}
+				}
+			},
+		},
+		{
+			name:           "attempts shrink as the outer deadline approaches",
+			outer:          180 * time.Millisecond,
+			attemptTimeout: 100 * time.Millisecond,
+			wantAttempts:   2,
+			check: func(t *testing.T, remaining []time.Duration) {
+				for i := 1; i < len(remaining); i++ {
+					if remaining[i] >= remaining[i-1] {
+						t.Errorf("attempt %d had %s left, want less than the %s of attempt %d", i+1, remaining[i], remaining[i-1], i)
+					}
+				}
+				if last := remaining[len(remaining)-1]; last > 80*time.Millisecond {
+					t.Errorf("last attempt had %s left, want only what remained of the outer deadline", last)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every attempt hangs until its context ends, recording the time it was given
+			var remaining []time.Duration
+			transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				deadline, ok := r.Context().Deadline()
+				if !ok {
+					t.Fatal("attempt has no deadline")
+				}
+				remaining = append(remaining, time.Until(deadline))
+				<-r.Context().Done()
+				return nil, r.Context().Err()
+			})
+
+			apiClient := infra.NewClaudeAPIClient(&http.Client{Transport: transport}, "test-api-key")
+			apiClient.RetryBackoff = time.Millisecond
+			apiClient.AttemptTimeout = tt.attemptTimeout
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.outer)
+			defer cancel()
+			_, err := apiClient.SendRequest(ctx, map[string]interface{}{"model": "test"})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected a deadline error, got %v", err)
+			}
+			if len(remaining) != tt.wantAttempts {
+				t.Fatalf("got %d attempts, want %d", len(remaining), tt.wantAttempts)
+			}
+			tt.check(t, remaining)
+		})
+	}
+}
+
+func TestClaudeAPIClient_IncompleteResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		truncate func(w http.ResponseWriter)
+	}{
+		{
+			name: "shorter than Content-Length",
+			truncate: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Length", "100")
+				w.Write([]byte(`{"id":"msg_`))
+			},
+		},
+		{
+			name: "truncated JSON",
+			truncate: func(w http.ResponseWriter) {
+				w.Write([]byte(`{"id":"msg_123","content":[{"type":"te`))
+			},
+		},
+		{
+			name:     "empty body",
+			truncate: func(w http.ResponseWriter) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, failures := 0, 1
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts <= failures {
+					tt.truncate(w)
+					return
+				}
+				w.Write([]byte(`{"id":"msg_123"}`))
+			}))
+			defer server.Close()
+
+			apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+			apiClient.BaseURL = server.URL
+			apiClient.RetryBackoff = time.Millisecond
+
+			// A truncated body is retried like a retryable status
+			if _, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"}); err != nil {
+				t.Fatalf("Expected success after retry, got %v", err)
+			}
+			if attempts != 2 {
+				t.Errorf("Expected 2 attempts, got %d", attempts)
+			}
+
+			// Once retries run out, the error says what went wrong
+			attempts, failures = 0, infra.DefaultMaxRetries+1
+			_, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+			if !errors.Is(err, domain.ErrIncompleteResponse) {
+				t.Fatalf("Expected ErrIncompleteResponse, got %v", err)
+			}
+			if want := infra.DefaultMaxRetries + 1; attempts != want {
+				t.Errorf("Expected %d attempts, got %d", want, attempts)
+			}
+		})
+	}
+}
+
+func TestClaudeAPIClient_RetriesAreBounded(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	apiClient.RetryBackoff = time.Millisecond
+
+	if _, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"}); err == nil {
+		t.Fatalf("Expected error after exhausting retries")
+	}
+	if want := infra.DefaultMaxRetries + 1; attempts != want {
+		t.Errorf("Expected %d attempts, got %d", want, attempts)
+	}
+}
+
+func TestClaudeAPIClient_RetryStats(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"content":[]}`))
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	apiClient.RetryBackoff = time.Millisecond
+
+	ctx, stats := domain.WithRetryStats(context.Background())
+	if _, err := apiClient.SendRequest(ctx, map[string]interface{}{"model": "test"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	count, wait := stats.Totals()
+	if count != 2 {
+		t.Errorf("Expected 2 retries, got %d", count)
+	}
+	if want := 3 * time.Millisecond; wait != want { // 1ms, then 2ms
+		t.Errorf("Expected a total retry wait of %s, got %s", want, wait)
+	}
+}
+
+func TestClaudeAPIClient_Headers(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "msg_123"})
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	config := domain.Config{
+		Timeout: 10 * time.Second,
+		OrgID:   "org_123",
+		Headers: map[string]string{"X-Tenant": "acme", "anthropic-version": "2099-01-01"},
+	}
+	if err := apiClient.Configure(config); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	if _, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		infra.OrganizationHeader: "org_123",
+		"X-Tenant":               "acme",
+		"anthropic-version":      "2099-01-01",
+		"x-api-key":              "test-api-key",
+	}
+	for name, value := range want {
+		if got := received.Get(name); got != value {
+			t.Errorf("Header %s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestClaudeAPIClient_RetryBudget(t *testing.T) {
+	var attempts atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	apiClient.RetryBackoff = time.Millisecond
+	if err := apiClient.Configure(domain.Config{Timeout: 10 * time.Second, RetryBudget: 3}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+
+	// Five concurrent requests would retry twice each without a budget
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	if got := attempts.Load(); got != 5+3 {
+		t.Errorf("Expected 8 attempts (5 requests + 3 budgeted retries), got %d", got)
+	}
+	if remaining := apiClient.RetryBudget.Remaining(); remaining != 0 {
+		t.Errorf("Remaining budget = %d, want 0", remaining)
+	}
+
+	exhausted := 0
+	for err := range errs {
+		var apiErr *domain.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected the last APIError to be returned, got %v", err)
+		}
+		if strings.Contains(err.Error(), "retry budget exhausted") {
+			exhausted++
+		}
+	}
+	if exhausted == 0 {
+		t.Errorf("Expected at least one request to report the exhausted budget")
+	}
+}
+
+func TestClaudeAPIClient_UserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "msg_123"})
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	if err := apiClient.Configure(domain.Config{Timeout: 10 * time.Second, UserAgent: "claude-think-tool/0.1.0 (go1.21.5)"}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	if _, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if userAgent != "claude-think-tool/0.1.0 (go1.21.5)" {
+		t.Errorf("User-Agent = %q, want the configured value", userAgent)
+	}
+}
+
+func TestClaudeAPIClient_ConfiguredAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		config domain.Config
+		want   string
+	}{
+		{name: "client key kept", config: domain.Config{}, want: "client-key"},
+		{name: "configured key", config: domain.Config{APIKey: "flag-key"}, want: "flag-key"},
+		{name: "no-env drops the client key", config: domain.Config{NoEnv: true}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiKey string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiKey = r.Header.Get("x-api-key")
+				json.NewEncoder(w).Encode(map[string]interface{}{"id": "msg_123"})
+			}))
+			defer server.Close()
+
+			apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "client-key")
+			apiClient.BaseURL = server.URL
+			tt.config.Timeout = 10 * time.Second
+			if err := apiClient.Configure(tt.config); err != nil {
+				t.Fatalf("Configure returned error: %v", err)
+			}
+			if _, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if apiKey != tt.want {
+				t.Errorf("x-api-key = %q, want %q", apiKey, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaudeAPIClient_Preflight(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "key accepted", status: http.StatusOK},
+		{name: "key rejected", status: http.StatusUnauthorized, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path, apiKey string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path, apiKey = r.Method, r.URL.Path, r.Header.Get("x-api-key")
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{}})
+			}))
+			defer server.Close()
+
+			apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+			apiClient.BaseURL = server.URL + "/v1/messages"
+			apiClient.MaxRetries = 0
+			err := apiClient.Preflight(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Preflight error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if method != "GET" || path != "/v1/models" || apiKey != "test-api-key" {
+				t.Errorf("Request = %s %s (x-api-key %q), want GET /v1/models with the key", method, path, apiKey)
+			}
+		})
+	}
+}
+
+func TestClaudeAPIClient_PayloadTooLarge(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		w.Write([]byte("<html><body>413 Request Entity Too Large</body></html>"))
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	_, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+
+	if !errors.Is(err, domain.ErrPayloadTooLarge) {
+		t.Fatalf("Expected ErrPayloadTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "-chunk-size") {
+		t.Errorf("Expected a suggestion to use chunking, got %q", err.Error())
+	}
+	if strings.Contains(err.Error(), "<html>") {
+		t.Errorf("Expected the raw gateway body to be dropped, got %q", err.Error())
+	}
+	var apiErr *domain.APIError
+	if errors.As(err, &apiErr) {
+		t.Errorf("Expected a payload error rather than an APIError, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retries, got %d attempts", attempts)
+	}
+}
+
+func TestClaudeAPIClient_ContextLengthIsNotPayloadTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`))
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	_, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+
+	if errors.Is(err, domain.ErrPayloadTooLarge) {
+		t.Errorf("Expected a context-length error to stay an APIError, got %v", err)
+	}
+	var apiErr *domain.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected APIError with status 400, got %v", err)
+	}
+}
+
+func TestClaudeAPIClient_MaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"msg_123","padding":"` + strings.Repeat("x", 4096) + `"}`))
+	}))
+	defer server.Close()
+
+	apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+	apiClient.BaseURL = server.URL
+	if err := apiClient.Configure(domain.Config{Timeout: 10 * time.Second, MaxResponseBytes: 1024}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	_, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+	if !errors.Is(err, domain.ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "1024 bytes") {
+		t.Errorf("Expected the limit in the error, got %q", err.Error())
+	}
+
+	// A body within the limit is returned whole
+	apiClient.MaxResponseBytes = 8192
+	responseData, err := apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !json.Valid(responseData) {
+		t.Errorf("Expected the complete response body, got %d bytes", len(responseData))
+	}
+}
+
+func TestClaudeAPIClient_RequestID(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		status        int
+		wantRequestID string
+	}{
+		{name: "error carries request-id", header: "request-id", status: http.StatusBadRequest, wantRequestID: "req_011"},
+		{name: "error carries anthropic-request-id", header: "anthropic-request-id", status: http.StatusBadRequest, wantRequestID: "req_011"},
+		{name: "success records request-id", header: "request-id", status: http.StatusOK, wantRequestID: "req_011"},
+		{name: "missing header", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set(tt.header, "req_011")
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"id":"msg_123"}`))
+			}))
+			defer server.Close()
+
+			apiClient := infra.NewClaudeAPIClient(http.DefaultClient, "test-api-key")
+			apiClient.BaseURL = server.URL
+			ctx, requestID := domain.WithRequestID(context.Background())
+			_, err := apiClient.SendRequest(ctx, map[string]interface{}{"model": "test"})
+
+			if apiClient.LastRequestID() != tt.wantRequestID {
+				t.Errorf("LastRequestID() = %q, want %q", apiClient.LastRequestID(), tt.wantRequestID)
+			}
+			if requestID.Value() != tt.wantRequestID {
+				t.Errorf("Context request ID = %q, want %q", requestID.Value(), tt.wantRequestID)
+			}
+			if tt.status == http.StatusOK {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var apiErr *domain.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected APIError, got %v", err)
+			}
+			if apiErr.RequestID != tt.wantRequestID {
+				t.Errorf("APIError.RequestID = %q, want %q", apiErr.RequestID, tt.wantRequestID)
+			}
+			if tt.wantRequestID != "" && !strings.Contains(err.Error(), "request-id: "+tt.wantRequestID) {
+				t.Errorf("Expected the request ID in the error, got %q", err.Error())
+			}
+		})
+	}
+}
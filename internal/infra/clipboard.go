@@ -0,0 +1,104 @@
+package infra
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// clipboardTool is a pair of platform commands reading and writing the clipboard
+type clipboardTool struct {
+	read  []string
+	write []string
+}
+
+// SystemClipboard implements the domain.Clipboard interface using the
+// platform's clipboard commands (pbpaste/pbcopy, PowerShell, wl-clipboard,
+// xclip or xsel)
+type SystemClipboard struct{}
+
+// NewSystemClipboard creates a new system clipboard implementation
+func NewSystemClipboard() *SystemClipboard {
+	return &SystemClipboard{}
+}
+
+// ReadClipboard returns the current clipboard text
+func (c *SystemClipboard) ReadClipboard() (string, error) {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(tool.read[0], tool.read[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read clipboard with %s: %w: %s", tool.read[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// WriteClipboard replaces the clipboard contents with text
+func (c *SystemClipboard) WriteClipboard(text string) error {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(tool.write[0], tool.write[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to write clipboard with %s: %w: %s", tool.write[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// findClipboardTool returns the first clipboard tool of the platform that is installed
+func findClipboardTool() (clipboardTool, error) {
+	candidates := clipboardTools(runtime.GOOS, os.Getenv)
+	if len(candidates) == 0 {
+		return clipboardTool{}, fmt.Errorf("%w: no graphical session (DISPLAY/WAYLAND_DISPLAY not set)", domain.ErrClipboardUnavailable)
+	}
+
+	var names []string
+	for _, tool := range candidates {
+		if _, err := exec.LookPath(tool.read[0]); err == nil {
+			return tool, nil
+		}
+		names = append(names, tool.read[0])
+	}
+	return clipboardTool{}, fmt.Errorf("%w: none of %s is installed", domain.ErrClipboardUnavailable, strings.Join(names, ", "))
+}
+
+// clipboardTools lists the clipboard commands to try on goos, in order of preference
+func clipboardTools(goos string, getenv func(string) string) []clipboardTool {
+	switch goos {
+	case "darwin":
+		return []clipboardTool{{read: []string{"pbpaste"}, write: []string{"pbcopy"}}}
+	case "windows":
+		return []clipboardTool{{
+			read:  []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"},
+			write: []string{"powershell", "-NoProfile", "-Command", "$input | Set-Clipboard"},
+		}}
+	}
+
+	var tools []clipboardTool
+	if getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{read: []string{"wl-paste", "--no-newline"}, write: []string{"wl-copy"}})
+	}
+	if getenv("DISPLAY") != "" {
+		tools = append(tools,
+			clipboardTool{read: []string{"xclip", "-selection", "clipboard", "-o"}, write: []string{"xclip", "-selection", "clipboard", "-i"}},
+			clipboardTool{read: []string{"xsel", "--clipboard", "--output"}, write: []string{"xsel", "--clipboard", "--input"}},
+		)
+	}
+	return tools
+}
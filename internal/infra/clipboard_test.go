@@ -0,0 +1,66 @@
+package infra_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+)
+
+func TestSystemClipboard(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fake clipboard tools are only set up for Linux")
+	}
+
+	t.Run("headless system", func(t *testing.T) {
+		t.Setenv("DISPLAY", "")
+		t.Setenv("WAYLAND_DISPLAY", "")
+
+		clipboard := infra.NewSystemClipboard()
+		if _, err := clipboard.ReadClipboard(); !errors.Is(err, domain.ErrClipboardUnavailable) {
+			t.Errorf("Expected ErrClipboardUnavailable, got %v", err)
+		}
+		if err := clipboard.WriteClipboard("text"); !errors.Is(err, domain.ErrClipboardUnavailable) {
+			t.Errorf("Expected ErrClipboardUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("no clipboard tool installed", func(t *testing.T) {
+		t.Setenv("DISPLAY", ":0")
+		t.Setenv("WAYLAND_DISPLAY", "")
+		t.Setenv("PATH", t.TempDir())
+
+		if _, err := infra.NewSystemClipboard().ReadClipboard(); !errors.Is(err, domain.ErrClipboardUnavailable) {
+			t.Errorf("Expected ErrClipboardUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("round trip through xclip", func(t *testing.T) {
+		binDir := t.TempDir()
+		store := filepath.Join(t.TempDir(), "clipboard")
+		script := "#!/bin/sh\ncase \"$*\" in\n*-o*) cat \"$CLIPBOARD_STORE\" ;;\n*) cat > \"$CLIPBOARD_STORE\" ;;\nesac\n"
+		if err := os.WriteFile(filepath.Join(binDir, "xclip"), []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to create fake xclip: %v", err)
+		}
+		t.Setenv("DISPLAY", ":0")
+		t.Setenv("WAYLAND_DISPLAY", "")
+		t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		t.Setenv("CLIPBOARD_STORE", store)
+
+		clipboard := infra.NewSystemClipboard()
+		if err := clipboard.WriteClipboard("日本はかっこいい"); err != nil {
+			t.Fatalf("WriteClipboard returned error: %v", err)
+		}
+		text, err := clipboard.ReadClipboard()
+		if err != nil {
+			t.Fatalf("ReadClipboard returned error: %v", err)
+		}
+		if text != "日本はかっこいい" {
+			t.Errorf("ReadClipboard() = %q, want %q", text, "日本はかっこいい")
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FakeAPIEnv enables the simulated API client when set to "1"
+const FakeAPIEnv = "CTT_FAKE_API"
+
+// SimulatedNotice starts every simulated analysis so it can't be mistaken for Claude's
+const SimulatedNotice = "[SIMULATED] This analysis was generated offline (CTT_FAKE_API=1); no API call was made."
+
+// FakeAPIClient answers Messages API requests with canned, deterministic
+// responses so the tool runs end to end without network access or an API key:
+// the first request gets a think tool call, the follow-up a fixed analysis
+type FakeAPIClient struct{}
+
+// NewFakeAPIClient creates a simulated API client
+func NewFakeAPIClient() *FakeAPIClient {
+	return &FakeAPIClient{}
+}
+
+// SendRequest implements domain.APIClient
+func (f *FakeAPIClient) SendRequest(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeJSON(requestMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+	request := normalized.(map[string]interface{})
+	messages, _ := request["messages"].([]interface{})
+
+	prompt := ""
+	if len(messages) > 0 {
+		first, _ := messages[0].(map[string]interface{})
+		prompt, _ = first["content"].(string)
+	}
+	usage := map[string]interface{}{
+		"input_tokens":  len(prompt) / 4,
+		"output_tokens": 0,
+	}
+
+	response := map[string]interface{}{
+		"id":    "msg_simulated",
+		"type":  "message",
+		"role":  "assistant",
+		"model": "simulated",
+		"usage": usage,
+	}
+
+	_, hasTools := request["tools"]
+	if hasTools && len(messages) == 1 {
+		response["stop_reason"] = "tool_use"
+		response["content"] = []interface{}{
+			map[string]interface{}{
+				"type":  "tool_use",
+				"id":    "toolu_simulated",
+				"name":  "think",
+				"input": map[string]interface{}{"thought": simulatedThought(prompt)},
+			},
+		}
+		usage["output_tokens"] = 20
+		return json.Marshal(response)
+	}
+
+	text := SimulatedNotice + `
+
+Strengths:
+- The thought states a clear position
+
+Concerns:
+- This is placeholder feedback; run without CTT_FAKE_API for a real analysis
+
+Recommendation:
+- Use simulated mode only for demos and offline tests
+
+Risk level: LOW`
+	response["stop_reason"] = "end_turn"
+	response["content"] = []interface{}{map[string]interface{}{"type": "text", "text": text}}
+	usage["output_tokens"] = len(text) / 4
+	return json.Marshal(response)
+}
+
+// Preflight implements domain.Preflighter; the simulated API is always reachable
+func (f *FakeAPIClient) Preflight(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// simulatedThought recovers the thought from the first line of the user prompt
+func simulatedThought(prompt string) string {
+	thought, _, _ := strings.Cut(prompt, "\n")
+	thought = strings.TrimPrefix(thought, "Please analyze the following thought: ")
+	if thought = strings.TrimSpace(thought); thought == "" {
+		return "(empty thought)"
+	}
+	return thought
+}
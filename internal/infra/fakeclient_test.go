@@ -0,0 +1,42 @@
+package infra_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+	"claude-think-tool/internal/usecase"
+)
+
+func TestFakeAPIClient_FullAnalysis(t *testing.T) {
+	service := usecase.NewThinkService(infra.NewFakeAPIClient())
+	config := domain.Config{Model: "claude-3-7-sonnet-20250219", MaxTokens: 1024, Simulated: true}
+
+	first, err := service.AnalyzeThought(context.Background(), "We should ship on Friday", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(first.Content, infra.SimulatedNotice) {
+		t.Errorf("Expected the content to be marked as simulated, got %q", first.Content)
+	}
+	if first.ToolInput != "We should ship on Friday" {
+		t.Errorf("ToolInput = %q, want the thought", first.ToolInput)
+	}
+	if first.RiskLevel != domain.RiskLow {
+		t.Errorf("RiskLevel = %q, want LOW", first.RiskLevel)
+	}
+
+	// Simulated responses are deterministic
+	second, err := service.AnalyzeThought(context.Background(), "We should ship on Friday", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	firstJSON, _ := json.Marshal(first.Raw)
+	secondJSON, _ := json.Marshal(second.Raw)
+	if string(firstJSON) != string(secondJSON) {
+		t.Errorf("Expected identical responses, got\n%s\n%s", firstJSON, secondJSON)
+	}
+}
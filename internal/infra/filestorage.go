@@ -1,10 +1,14 @@
 package infra
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 )
 
+// utf8BOM is the byte order mark some Windows editors put at the start of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
 // FileStorage implements the domain.FileStorage interface
 type FileStorage struct{}
 
@@ -13,13 +17,13 @@ func NewFileStorage() *FileStorage {
 	return &FileStorage{}
 }
 
-// ReadFromFile reads content from a file
+// ReadFromFile reads content from a file, dropping a leading UTF-8 BOM
 func (fs *FileStorage) ReadFromFile(filePath string) (string, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
-	return string(data), nil
+	return string(bytes.TrimPrefix(data, utf8BOM)), nil
 }
 
 // WriteToFile writes content to a file
@@ -29,4 +33,18 @@ func (fs *FileStorage) WriteToFile(filePath string, content string) error {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// AppendToFile appends content to a file, creating it if necessary
+func (fs *FileStorage) AppendToFile(filePath string, content string) error {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file for append: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		return fmt.Errorf("failed to append to file: %w", err)
+	}
+	return nil
+}
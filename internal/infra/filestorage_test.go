@@ -41,6 +41,21 @@ func TestFileStorage(t *testing.T) {
 		}
 	})
 
+	t.Run("read strips a UTF-8 BOM", func(t *testing.T) {
+		filePath := filepath.Join(tempDir, "test_bom.txt")
+		if err := os.WriteFile(filePath, []byte("\xEF\xBB\xBFthought with a BOM"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		readContent, err := storage.ReadFromFile(filePath)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if readContent != "thought with a BOM" {
+			t.Errorf("Expected the BOM to be removed, got %q", readContent)
+		}
+	})
+
 	t.Run("read nonexistent file", func(t *testing.T) {
 		filePath := filepath.Join(tempDir, "nonexistent.txt")
 		
@@ -65,4 +80,22 @@ func TestFileStorage(t *testing.T) {
 			t.Errorf("Expected error writing to directory path, got nil")
 		}
 	})
-}
\ No newline at end of file
+
+	t.Run("append creates and extends file", func(t *testing.T) {
+		filePath := filepath.Join(tempDir, "test_append.txt")
+
+		for _, line := range []string{"first\n", "second\n"} {
+			if err := storage.AppendToFile(filePath, line); err != nil {
+				t.Fatalf("Failed to append to file: %v", err)
+			}
+		}
+
+		readContent, err := storage.ReadFromFile(filePath)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if readContent != "first\nsecond\n" {
+			t.Errorf("Expected appended content, got %q", readContent)
+		}
+	})
+}
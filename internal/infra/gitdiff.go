@@ -0,0 +1,29 @@
+package infra
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitDiff implements the domain.DiffSource interface by running git diff in
+// the current directory
+type GitDiff struct{}
+
+// NewGitDiff creates a new git diff source
+func NewGitDiff() *GitDiff {
+	return &GitDiff{}
+}
+
+// ReadDiff returns the output of git diff with the given arguments
+func (g *GitDiff) ReadDiff(args []string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"diff"}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
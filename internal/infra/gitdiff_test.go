@@ -0,0 +1,62 @@
+package infra_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/infra"
+)
+
+func TestGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	git("add", "main.go")
+	git("commit", "-q", "-m", "initial")
+
+	diffSource := infra.NewGitDiff()
+	diff, err := diffSource.ReadDiff(nil)
+	if err != nil || diff != "" {
+		t.Fatalf("Expected an empty diff without changes, got %q, %v", diff, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	diff, err = diffSource.ReadDiff(nil)
+	if err != nil || !strings.Contains(diff, "+func main() {}") {
+		t.Errorf("Expected the change in the diff, got %q, %v", diff, err)
+	}
+	diff, err = diffSource.ReadDiff([]string{"--staged"})
+	if err != nil || diff != "" {
+		t.Errorf("Expected an empty staged diff, got %q, %v", diff, err)
+	}
+
+	if _, err := diffSource.ReadDiff([]string{"--no-such-option"}); err == nil {
+		t.Errorf("Expected an error for an invalid git diff option")
+	}
+}
@@ -0,0 +1,66 @@
+package infra
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"claude-think-tool/internal/domain"
+)
+
+// NewHTTPClient builds the HTTP client used for API calls from the runtime configuration
+func NewHTTPClient(config domain.Config) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	// Bound connection establishment separately, so an unreachable endpoint
+	// fails fast while a slow generation keeps the whole Timeout
+	if config.ConnectTimeout > 0 {
+		dialer := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = config.ConnectTimeout
+	}
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+// newTLSConfig loads the optional custom CA bundle and verification settings
+func newTLSConfig(config domain.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CACertFile == "" {
+		return tlsConfig, nil
+	}
+
+	pemData, err := os.ReadFile(config.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate file: %w", err)
+	}
+
+	// Extend the system roots so public endpoints keep working alongside the custom CA
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no valid PEM certificates found in %s", config.CACertFile)
+	}
+	tlsConfig.RootCAs = pool
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,129 @@
+package infra_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+)
+
+func TestNewHTTPClient_CustomCA(t *testing.T) {
+	// TLS test server whose self-signed certificate plays the role of a private CA
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "msg_tls"}`))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	caFile := filepath.Join(tempDir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+	invalidFile := filepath.Join(tempDir, "invalid.pem")
+	if err := os.WriteFile(invalidFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("Failed to write invalid CA file: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		config           domain.Config
+		expectBuildError bool
+		expectSendError  bool
+	}{
+		{
+			name:            "system roots reject the private CA",
+			config:          domain.Config{Timeout: 5 * time.Second},
+			expectSendError: true,
+		},
+		{
+			name:   "custom CA bundle is trusted",
+			config: domain.Config{Timeout: 5 * time.Second, CACertFile: caFile},
+		},
+		{
+			name:   "verification can be skipped",
+			config: domain.Config{Timeout: 5 * time.Second, InsecureSkipVerify: true},
+		},
+		{
+			name:             "missing CA file",
+			config:           domain.Config{CACertFile: filepath.Join(tempDir, "missing.pem")},
+			expectBuildError: true,
+		},
+		{
+			name:             "CA file without certificates",
+			config:           domain.Config{CACertFile: invalidFile},
+			expectBuildError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiClient := infra.NewClaudeAPIClient(nil, "test-api-key")
+			apiClient.BaseURL = server.URL
+
+			err := apiClient.Configure(tt.config)
+			if tt.expectBuildError {
+				if err == nil {
+					t.Errorf("Expected configuration error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected configuration error: %v", err)
+			}
+
+			_, err = apiClient.SendRequest(context.Background(), map[string]interface{}{"model": "test"})
+			if tt.expectSendError && err == nil {
+				t.Errorf("Expected TLS error, got nil")
+			}
+			if !tt.expectSendError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient_ConnectTimeout(t *testing.T) {
+	// A listener that accepts connections but never answers the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client, err := infra.NewHTTPClient(domain.Config{Timeout: 5 * time.Second, ConnectTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected build error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Get("https://" + listener.Addr().String())
+	if err == nil {
+		t.Fatalf("Expected the stalled handshake to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the connect timeout to end the request early, took %s", elapsed)
+	}
+	if !strings.Contains(err.Error(), "TLS handshake timeout") {
+		t.Errorf("Expected a TLS handshake timeout, got %v", err)
+	}
+}
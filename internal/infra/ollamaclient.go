@@ -0,0 +1,294 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"claude-think-tool/internal/domain"
+)
+
+// DefaultOllamaURL is the chat endpoint of a local Ollama server
+const DefaultOllamaURL = "http://localhost:11434/api/chat"
+
+// ollamaSupportedFields are the Messages API request fields OllamaAPIClient
+// translates; any other field is dropped with a warning
+var ollamaSupportedFields = map[string]bool{
+	"model":       true,
+	"max_tokens":  true,
+	"messages":    true,
+	"system":      true,
+	"tools":       true,
+	"temperature": true,
+}
+
+// OllamaAPIClient runs Messages API requests against a local Ollama model by
+// translating them into Ollama chat requests and the replies back into
+// Messages API responses. Features Ollama lacks are dropped with a warning.
+type OllamaAPIClient struct {
+	*ClaudeAPIClient
+
+	BaseURL string // Can be overridden for testing
+
+	warnMu sync.Mutex
+	warned map[string]bool
+}
+
+// NewOllamaAPIClient creates an Ollama client on top of an Anthropic client's transport
+func NewOllamaAPIClient(transport *ClaudeAPIClient) *OllamaAPIClient {
+	return &OllamaAPIClient{
+		ClaudeAPIClient: transport,
+		BaseURL:         DefaultOllamaURL,
+		warned:          make(map[string]bool),
+	}
+}
+
+// SendRequest sends a request to Ollama and returns the reply in the
+// Messages API response format
+func (o *OllamaAPIClient) SendRequest(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+	chatRequest, err := o.TranslateRequest(requestMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate request for Ollama: %w", err)
+	}
+	requestJSON, err := json.Marshal(chatRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	responseData, err := o.post(ctx, o.BaseURL, requestJSON, func(http.Header) {})
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResponse map[string]interface{}
+	if err := json.Unmarshal(responseData, &chatResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
+	return json.Marshal(TranslateOllamaResponse(chatResponse))
+}
+
+// Preflight implements domain.Preflighter. The promoted Anthropic check would
+// call api.anthropic.com instead of the local server, so there is no check.
+func (o *OllamaAPIClient) Preflight(ctx context.Context) error {
+	return domain.ErrPreflightUnsupported
+}
+
+// TranslateRequest converts a Messages API request into an Ollama chat request
+func (o *OllamaAPIClient) TranslateRequest(requestMap map[string]interface{}) (map[string]interface{}, error) {
+	// Round-trip through JSON so typed values (structs, typed slices) become plain maps
+	normalized, err := normalizeJSON(requestMap)
+	if err != nil {
+		return nil, err
+	}
+	request := normalized.(map[string]interface{})
+
+	for field := range request {
+		if !ollamaSupportedFields[field] {
+			o.warn(fmt.Sprintf("Ollama does not support %q; ignoring it", field))
+		}
+	}
+
+	chatRequest := map[string]interface{}{
+		"model":  request["model"],
+		"stream": false,
+	}
+
+	options := map[string]interface{}{}
+	if maxTokens, ok := request["max_tokens"]; ok {
+		options["num_predict"] = maxTokens
+	}
+	if temperature, ok := request["temperature"]; ok {
+		options["temperature"] = temperature
+	}
+	if len(options) > 0 {
+		chatRequest["options"] = options
+	}
+
+	var messages []interface{}
+	if system, ok := request["system"].(string); ok && system != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": system})
+	}
+	toolNames := map[string]string{} // tool_use id -> tool name, for the tool results
+	requestMessages, _ := request["messages"].([]interface{})
+	for _, item := range requestMessages {
+		message, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		messages = append(messages, o.translateMessage(message, toolNames)...)
+	}
+	chatRequest["messages"] = messages
+
+	if tools, ok := request["tools"].([]interface{}); ok {
+		var chatTools []interface{}
+		for _, item := range tools {
+			tool, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if toolType, _ := tool["type"].(string); toolType != "" && toolType != "custom" {
+				o.warn(fmt.Sprintf("Ollama does not support %s tools; ignoring %v", toolType, tool["name"]))
+				continue
+			}
+			chatTools = append(chatTools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        tool["name"],
+					"description": tool["description"],
+					"parameters":  tool["input_schema"],
+				},
+			})
+		}
+		if len(chatTools) > 0 {
+			chatRequest["tools"] = chatTools
+		}
+	}
+
+	return chatRequest, nil
+}
+
+// translateMessage converts one Messages API message into Ollama messages.
+// Tool results become separate "tool" messages, so one message may turn into several.
+func (o *OllamaAPIClient) translateMessage(message map[string]interface{}, toolNames map[string]string) []interface{} {
+	role, _ := message["role"].(string)
+	if content, ok := message["content"].(string); ok {
+		return []interface{}{map[string]interface{}{"role": role, "content": content}}
+	}
+
+	var texts []string
+	var toolCalls []interface{}
+	var toolMessages []interface{}
+	blocks, _ := message["content"].([]interface{})
+	for _, item := range blocks {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch blockType, _ := block["type"].(string); blockType {
+		case "text":
+			if text, ok := block["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		case "tool_use":
+			id, _ := block["id"].(string)
+			name, _ := block["name"].(string)
+			toolNames[id] = name
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"function": map[string]interface{}{"name": name, "arguments": block["input"]},
+			})
+		case "tool_result":
+			id, _ := block["tool_use_id"].(string)
+			toolMessages = append(toolMessages, map[string]interface{}{
+				"role":      "tool",
+				"tool_name": toolNames[id],
+				"content":   o.toolResultText(block["content"]),
+			})
+		default:
+			o.warn(fmt.Sprintf("Ollama does not support %s content blocks; ignoring them", blockType))
+		}
+	}
+
+	messages := toolMessages
+	if len(texts) > 0 || len(toolCalls) > 0 {
+		chatMessage := map[string]interface{}{"role": role, "content": strings.Join(texts, "\n")}
+		if len(toolCalls) > 0 {
+			chatMessage["tool_calls"] = toolCalls
+		}
+		messages = append(messages, chatMessage)
+	}
+	return messages
+}
+
+// toolResultText flattens tool_result content (a string or text blocks) into text
+func (o *OllamaAPIClient) toolResultText(content interface{}) string {
+	if text, ok := content.(string); ok {
+		return text
+	}
+	var texts []string
+	blocks, _ := content.([]interface{})
+	for _, item := range blocks {
+		block, _ := item.(map[string]interface{})
+		if text, ok := block["text"].(string); ok {
+			texts = append(texts, text)
+		} else {
+			o.warn(fmt.Sprintf("Ollama does not support %v tool result blocks; ignoring them", block["type"]))
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// TranslateOllamaResponse converts an Ollama chat response into a Messages API response
+func TranslateOllamaResponse(chatResponse map[string]interface{}) map[string]interface{} {
+	message, _ := chatResponse["message"].(map[string]interface{})
+
+	var content []interface{}
+	if text, _ := message["content"].(string); text != "" {
+		content = append(content, map[string]interface{}{"type": "text", "text": text})
+	}
+	toolCalls, _ := message["tool_calls"].([]interface{})
+	for i, item := range toolCalls {
+		call, _ := item.(map[string]interface{})
+		function, _ := call["function"].(map[string]interface{})
+		content = append(content, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    fmt.Sprintf("toolu_ollama_%d", i),
+			"name":  function["name"],
+			"input": function["arguments"],
+		})
+	}
+
+	stopReason := "end_turn"
+	if len(toolCalls) > 0 {
+		stopReason = "tool_use"
+	} else if doneReason, _ := chatResponse["done_reason"].(string); doneReason == "length" {
+		stopReason = "max_tokens"
+	}
+
+	return map[string]interface{}{
+		"type":        "message",
+		"role":        "assistant",
+		"model":       chatResponse["model"],
+		"content":     content,
+		"stop_reason": stopReason,
+		"usage": map[string]interface{}{
+			"input_tokens":  intValue(chatResponse["prompt_eval_count"]),
+			"output_tokens": intValue(chatResponse["eval_count"]),
+		},
+	}
+}
+
+// warn logs a warning about an unsupported feature once per client
+func (o *OllamaAPIClient) warn(message string) {
+	o.warnMu.Lock()
+	defer o.warnMu.Unlock()
+	if o.warned[message] {
+		return
+	}
+	o.warned[message] = true
+	log.Printf("Warning: %s", message)
+}
+
+// normalizeJSON converts v into the generic form encoding/json decodes into
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// intValue converts a decoded JSON number to an int, returning 0 for anything else
+func intValue(v interface{}) int {
+	if n, ok := v.(float64); ok {
+		return int(n)
+	}
+	return 0
+}
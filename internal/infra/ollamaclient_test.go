@@ -0,0 +1,162 @@
+package infra_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+)
+
+func TestOllamaAPIClient_TranslateRequest(t *testing.T) {
+	client := infra.NewOllamaAPIClient(infra.NewClaudeAPIClient(http.DefaultClient, ""))
+	requestMap := map[string]interface{}{
+		"model":      "llama3",
+		"max_tokens": 1024,
+		"system":     "Be brief.",
+		"metadata":   map[string]interface{}{"user_id": "user-1"}, // unsupported, dropped with a warning
+		"tools": []domain.Tool{{
+			Name:        "think",
+			Description: "Analyze a thought",
+			InputSchema: map[string]interface{}{"type": "object"},
+		}},
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": "Analyze this"},
+			{"role": "assistant", "content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "Let me think."},
+				map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "think", "input": map[string]interface{}{"thought": "Analyze this"}},
+			}},
+			{"role": "user", "content": []map[string]interface{}{
+				{"type": "tool_result", "tool_use_id": "toolu_1", "content": []map[string]interface{}{{"type": "text", "text": "Looks sound"}}},
+			}},
+		},
+	}
+
+	chatRequest, err := client.TranslateRequest(requestMap)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"model":   "llama3",
+		"stream":  false,
+		"options": map[string]interface{}{"num_predict": float64(1024)},
+		"messages": []interface{}{
+			map[string]interface{}{"role": "system", "content": "Be brief."},
+			map[string]interface{}{"role": "user", "content": "Analyze this"},
+			map[string]interface{}{
+				"role":    "assistant",
+				"content": "Let me think.",
+				"tool_calls": []interface{}{
+					map[string]interface{}{"function": map[string]interface{}{"name": "think", "arguments": map[string]interface{}{"thought": "Analyze this"}}},
+				},
+			},
+			map[string]interface{}{"role": "tool", "tool_name": "think", "content": "Looks sound"},
+		},
+		"tools": []interface{}{
+			map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        "think",
+					"description": "Analyze a thought",
+					"parameters":  map[string]interface{}{"type": "object"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(chatRequest, expected) {
+		got, _ := json.MarshalIndent(chatRequest, "", "  ")
+		t.Errorf("Unexpected chat request:\n%s", got)
+	}
+}
+
+func TestTranslateOllamaResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   map[string]interface{}
+		stopReason string
+		content    []interface{}
+	}{
+		{
+			name: "text reply",
+			response: map[string]interface{}{
+				"model":   "llama3",
+				"message": map[string]interface{}{"role": "assistant", "content": "Risk level: LOW"},
+			},
+			stopReason: "end_turn",
+			content:    []interface{}{map[string]interface{}{"type": "text", "text": "Risk level: LOW"}},
+		},
+		{
+			name: "tool call",
+			response: map[string]interface{}{
+				"model": "llama3",
+				"message": map[string]interface{}{"role": "assistant", "content": "", "tool_calls": []interface{}{
+					map[string]interface{}{"function": map[string]interface{}{"name": "think", "arguments": map[string]interface{}{"thought": "x"}}},
+				}},
+			},
+			stopReason: "tool_use",
+			content: []interface{}{map[string]interface{}{
+				"type": "tool_use", "id": "toolu_ollama_0", "name": "think", "input": map[string]interface{}{"thought": "x"},
+			}},
+		},
+		{
+			name: "length limit",
+			response: map[string]interface{}{
+				"message":     map[string]interface{}{"role": "assistant", "content": "Cut"},
+				"done_reason": "length",
+			},
+			stopReason: "max_tokens",
+			content:    []interface{}{map[string]interface{}{"type": "text", "text": "Cut"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := infra.TranslateOllamaResponse(tt.response)
+			if response["stop_reason"] != tt.stopReason {
+				t.Errorf("stop_reason = %v, want %s", response["stop_reason"], tt.stopReason)
+			}
+			if !reflect.DeepEqual(response["content"], tt.content) {
+				t.Errorf("content = %v, want %v", response["content"], tt.content)
+			}
+		})
+	}
+}
+
+func TestOllamaAPIClient_SendRequest(t *testing.T) {
+	var chatRequest map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&chatRequest)
+		w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"Fine.\nRisk level: LOW"},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":5}`))
+	}))
+	defer server.Close()
+
+	client := infra.NewOllamaAPIClient(infra.NewClaudeAPIClient(http.DefaultClient, ""))
+	client.BaseURL = server.URL
+	responseData, err := client.SendRequest(context.Background(), map[string]interface{}{
+		"model":    "llama3",
+		"messages": []map[string]interface{}{{"role": "user", "content": "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if chatRequest["model"] != "llama3" || chatRequest["stream"] != false {
+		t.Errorf("Expected a non-streaming chat request for llama3, got %v", chatRequest)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		t.Fatalf("Expected a JSON response, got error: %v", err)
+	}
+	expectedUsage := map[string]interface{}{"input_tokens": float64(12), "output_tokens": float64(5)}
+	if !reflect.DeepEqual(response["usage"], expectedUsage) {
+		t.Errorf("usage = %v, want %v", response["usage"], expectedUsage)
+	}
+	if response["stop_reason"] != "end_turn" {
+		t.Errorf("stop_reason = %v, want end_turn", response["stop_reason"])
+	}
+}
@@ -0,0 +1,72 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+
+	"claude-think-tool/internal/domain"
+)
+
+// ProviderClient sends requests through the API client of the provider
+// selected in the configuration. Providers are chosen in Configure because the
+// client is built before the flags are parsed.
+type ProviderClient struct {
+	domain.APIClient
+
+	anthropic *ClaudeAPIClient
+}
+
+// NewProviderClient creates a client that uses anthropic until configured otherwise
+func NewProviderClient(anthropic *ClaudeAPIClient) *ProviderClient {
+	return &ProviderClient{APIClient: anthropic, anthropic: anthropic}
+}
+
+// Configure configures the shared transport and selects the provider's client
+func (p *ProviderClient) Configure(config domain.Config) error {
+	if err := p.anthropic.Configure(config); err != nil {
+		return err
+	}
+
+	client, err := NewAPIClient(config, p.anthropic)
+	if err != nil {
+		return err
+	}
+	p.APIClient = client
+	return nil
+}
+
+// LastRequestID returns the request ID of the most recent response
+func (p *ProviderClient) LastRequestID() string {
+	return p.anthropic.LastRequestID()
+}
+
+// Preflight runs the selected client's preflight check, when it has one
+func (p *ProviderClient) Preflight(ctx context.Context) error {
+	if preflighter, ok := p.APIClient.(domain.Preflighter); ok {
+		return preflighter.Preflight(ctx)
+	}
+	return domain.ErrPreflightUnsupported
+}
+
+// NewAPIClient builds the API client for config.Provider on top of the
+// Anthropic client's transport, or the simulated client in simulated mode
+func NewAPIClient(config domain.Config, anthropic *ClaudeAPIClient) (domain.APIClient, error) {
+	if config.Simulated {
+		return NewFakeAPIClient(), nil
+	}
+	switch config.Provider {
+	case "", domain.ProviderAnthropic:
+		return anthropic, nil
+	case domain.ProviderVertex:
+		// The access token is passed like an API key (-apikey or ANTHROPIC_API_KEY)
+		accessToken := config.APIKey
+		if accessToken == "" {
+			accessToken = anthropic.APIKey
+		}
+		return NewVertexAPIClient(anthropic, config.VertexProject, config.VertexRegion, accessToken), nil
+	case domain.ProviderOllama:
+		return NewOllamaAPIClient(anthropic), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %q (expected one of %v)", config.Provider, domain.Providers)
+	}
+}
@@ -0,0 +1,108 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"claude-think-tool/internal/domain"
+)
+
+// VertexAPIVersion is the API version Claude on Vertex AI expects in the request body
+const VertexAPIVersion = "vertex-2023-10-16"
+
+// vertexModelDate matches the date suffix of an Anthropic model id, which
+// Vertex AI separates with "@" (claude-3-7-sonnet-20250219 -> claude-3-7-sonnet@20250219)
+var vertexModelDate = regexp.MustCompile(`-(\d{8})$`)
+
+// VertexAPIClient sends Anthropic Messages API requests to Claude on Vertex AI.
+// It reuses the Anthropic client's transport, retry policy and limits and only
+// changes the endpoint, authentication and request shape.
+type VertexAPIClient struct {
+	*ClaudeAPIClient
+
+	ProjectID   string
+	Region      string
+	AccessToken string // OAuth access token, e.g. from `gcloud auth print-access-token`
+	BaseURL     string // Can be overridden for testing
+}
+
+// NewVertexAPIClient creates a Vertex AI client on top of an Anthropic client
+func NewVertexAPIClient(transport *ClaudeAPIClient, projectID, region, accessToken string) *VertexAPIClient {
+	if region == "" {
+		region = domain.DefaultVertexRegion
+	}
+	return &VertexAPIClient{
+		ClaudeAPIClient: transport,
+		ProjectID:       projectID,
+		Region:          region,
+		AccessToken:     accessToken,
+		BaseURL:         vertexBaseURL(region),
+	}
+}
+
+// vertexBaseURL returns the Vertex AI endpoint serving region
+func vertexBaseURL(region string) string {
+	if region == "global" {
+		return "https://aiplatform.googleapis.com/v1"
+	}
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1", region)
+}
+
+// TranslateRequest converts an Anthropic Messages API request into the URL and
+// body of the equivalent Vertex AI rawPredict call: the model moves into the
+// URL and the API version into the body
+func (v *VertexAPIClient) TranslateRequest(requestMap map[string]interface{}) (string, map[string]interface{}, error) {
+	model, _ := requestMap["model"].(string)
+	if model == "" {
+		return "", nil, fmt.Errorf("request has no model")
+	}
+	if v.ProjectID == "" {
+		return "", nil, fmt.Errorf("no Vertex AI project configured")
+	}
+
+	body := make(map[string]interface{}, len(requestMap))
+	for k, val := range requestMap {
+		if k != "model" {
+			body[k] = val
+		}
+	}
+	body["anthropic_version"] = VertexAPIVersion
+
+	url := fmt.Sprintf("%s/projects/%s/locations/%s/publishers/anthropic/models/%s:rawPredict",
+		v.BaseURL, v.ProjectID, v.Region, VertexModelID(model))
+	return url, body, nil
+}
+
+// VertexModelID converts an Anthropic model id into its Vertex AI form
+func VertexModelID(model string) string {
+	return vertexModelDate.ReplaceAllString(model, "@$1")
+}
+
+// SendRequest sends a request to Claude on Vertex AI. The response uses the
+// Anthropic Messages API format, so it is returned unchanged.
+func (v *VertexAPIClient) SendRequest(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+	url, body, err := v.TranslateRequest(requestMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate request for Vertex AI: %w", err)
+	}
+	requestJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+	return v.post(ctx, url, requestJSON, v.setAuthHeaders)
+}
+
+// Preflight implements domain.Preflighter. Vertex AI has no equivalent of the
+// Anthropic models listing, and the promoted Anthropic check would send the
+// access token to api.anthropic.com, so there is no check.
+func (v *VertexAPIClient) Preflight(ctx context.Context) error {
+	return domain.ErrPreflightUnsupported
+}
+
+// setAuthHeaders authenticates a request to Vertex AI
+func (v *VertexAPIClient) setAuthHeaders(header http.Header) {
+	header.Set("Authorization", "Bearer "+v.AccessToken)
+}
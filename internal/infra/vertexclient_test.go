@@ -0,0 +1,188 @@
+package infra_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+)
+
+func TestVertexAPIClient_TranslateRequest(t *testing.T) {
+	requestMap := map[string]interface{}{
+		"model":      "claude-3-7-sonnet-20250219",
+		"max_tokens": 1024,
+		"messages":   []interface{}{map[string]interface{}{"role": "user", "content": "Hello"}},
+	}
+
+	tests := []struct {
+		name        string
+		region      string
+		expectedURL string
+	}{
+		{
+			name:        "regional endpoint",
+			region:      "europe-west1",
+			expectedURL: "https://europe-west1-aiplatform.googleapis.com/v1/projects/my-project/locations/europe-west1/publishers/anthropic/models/claude-3-7-sonnet@20250219:rawPredict",
+		},
+		{
+			name:        "global endpoint",
+			region:      "global",
+			expectedURL: "https://aiplatform.googleapis.com/v1/projects/my-project/locations/global/publishers/anthropic/models/claude-3-7-sonnet@20250219:rawPredict",
+		},
+		{
+			name:        "default region",
+			expectedURL: "https://us-east5-aiplatform.googleapis.com/v1/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-3-7-sonnet@20250219:rawPredict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := infra.NewVertexAPIClient(infra.NewClaudeAPIClient(http.DefaultClient, ""), "my-project", tt.region, "token")
+			url, body, err := client.TranslateRequest(requestMap)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if url != tt.expectedURL {
+				t.Errorf("URL = %q, want %q", url, tt.expectedURL)
+			}
+			expectedBody := map[string]interface{}{
+				"anthropic_version": infra.VertexAPIVersion,
+				"max_tokens":        1024,
+				"messages":          requestMap["messages"],
+			}
+			if !reflect.DeepEqual(body, expectedBody) {
+				t.Errorf("Body = %v, want %v", body, expectedBody)
+			}
+			if _, ok := requestMap["anthropic_version"]; ok {
+				t.Errorf("Expected the original request to be left untouched")
+			}
+		})
+	}
+}
+
+func TestVertexModelID(t *testing.T) {
+	tests := map[string]string{
+		"claude-3-7-sonnet-20250219": "claude-3-7-sonnet@20250219",
+		"claude-3-5-haiku@20241022":  "claude-3-5-haiku@20241022",
+		"claude-sonnet-4":            "claude-sonnet-4",
+	}
+	for model, expected := range tests {
+		if got := infra.VertexModelID(model); got != expected {
+			t.Errorf("VertexModelID(%q) = %q, want %q", model, got, expected)
+		}
+	}
+}
+
+func TestVertexAPIClient_SendRequest(t *testing.T) {
+	var path, authorization string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"id":"msg_123","type":"message"}`))
+	}))
+	defer server.Close()
+
+	client := infra.NewVertexAPIClient(infra.NewClaudeAPIClient(http.DefaultClient, ""), "my-project", "us-east5", "ya29.token")
+	client.BaseURL = server.URL
+	responseData, err := client.SendRequest(context.Background(), map[string]interface{}{"model": "claude-3-5-haiku-20241022", "max_tokens": 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if path != "/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-3-5-haiku@20241022:rawPredict" {
+		t.Errorf("Unexpected request path %q", path)
+	}
+	if authorization != "Bearer ya29.token" {
+		t.Errorf("Authorization = %q, want the bearer token", authorization)
+	}
+	if body["anthropic_version"] != infra.VertexAPIVersion || body["model"] != nil {
+		t.Errorf("Expected a Vertex request body, got %v", body)
+	}
+	if !strings.Contains(string(responseData), "msg_123") {
+		t.Errorf("Expected the response to be returned unchanged, got %s", responseData)
+	}
+}
+
+func TestProviderClient_Configure(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		expectVertex bool
+		expectError  bool
+	}{
+		{name: "default is anthropic", provider: ""},
+		{name: "anthropic", provider: domain.ProviderAnthropic},
+		{name: "vertex", provider: domain.ProviderVertex, expectVertex: true},
+		{name: "ollama", provider: domain.ProviderOllama},
+		{name: "unknown provider", provider: "bedrock", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := infra.NewProviderClient(infra.NewClaudeAPIClient(http.DefaultClient, "key"))
+			err := client.Configure(domain.Config{Timeout: 10 * time.Second, Provider: tt.provider, VertexProject: "my-project"})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Configure() error = %v, expectError %v", err, tt.expectError)
+			}
+			if err != nil {
+				return
+			}
+
+			_, isVertex := client.APIClient.(*infra.VertexAPIClient)
+			if isVertex != tt.expectVertex {
+				t.Errorf("Selected client %T, expected Vertex: %v", client.APIClient, tt.expectVertex)
+			}
+		})
+	}
+}
+
+func TestProviderClient_Preflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		wantRequest bool
+		wantErr     error
+	}{
+		{name: "anthropic lists the models", provider: domain.ProviderAnthropic, wantRequest: true},
+		{name: "vertex has no check", provider: domain.ProviderVertex, wantErr: domain.ErrPreflightUnsupported},
+		{name: "ollama has no check", provider: domain.ProviderOllama, wantErr: domain.ErrPreflightUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{}})
+			}))
+			defer server.Close()
+
+			anthropic := infra.NewClaudeAPIClient(http.DefaultClient, "key")
+			client := infra.NewProviderClient(anthropic)
+			if err := client.Configure(domain.Config{Timeout: 10 * time.Second, Provider: tt.provider, VertexProject: "my-project"}); err != nil {
+				t.Fatalf("Configure() error = %v", err)
+			}
+			// Any request to the Anthropic API, Vertex and Ollama included, reaches the test server
+			anthropic.BaseURL = server.URL + "/v1/messages"
+
+			err := client.Preflight(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Preflight() error = %v, want %v", err, tt.wantErr)
+			}
+			if (requests > 0) != tt.wantRequest {
+				t.Errorf("Preflight() sent %d requests to the Anthropic API, want a request: %v", requests, tt.wantRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package interfacelayer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"claude-think-tool/internal/domain"
+)
+
+// auditEntry is one line of the append-only audit log
+type auditEntry struct {
+	Timestamp   string       `json:"timestamp"`
+	RunID       string       `json:"run_id"`
+	User        string       `json:"user"`
+	Model       string       `json:"model"`
+	ThoughtHash string       `json:"thought_hash"`
+	Thought     string       `json:"thought,omitempty"`
+	Usage       domain.Usage `json:"usage"`
+	Status      string       `json:"status"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// writeAuditLog appends a JSON line describing this run to the audit log.
+// Both the hash and the thought, which is only recorded when full is set, are
+// of the thought as sent, with the run's redactions applied.
+func (c *CLI) writeAuditLog(path string, full bool, runID string, thought string, config domain.Config, response *domain.ThinkResponse, runErr error) error {
+	sent, err := sentThought(thought, config)
+	if err != nil {
+		return err
+	}
+	entry := auditEntry{
+		Timestamp:   c.formatTime(time.Now()),
+		RunID:       runID,
+		User:        currentUser(),
+		Model:       config.Model,
+		ThoughtHash: hashThought(sent),
+		Status:      "ok",
+	}
+	if full {
+		entry.Thought = sent
+	}
+	if response != nil {
+		entry.Usage = response.Usage
+		if response.Model != "" {
+			entry.Model = response.Model
+		}
+	}
+	if runErr != nil {
+		entry.Status = "error"
+		entry.Error = runErr.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	return c.fileStorage.AppendToFile(path, string(line)+"\n")
+}
+
+// sentThought returns thought as the service sends it: with its newlines
+// normalized and the -redact-pii and -redact-pattern redactions applied, so
+// that what is recorded about it reveals no more than the request did
+func sentThought(thought string, config domain.Config) (string, error) {
+	if config.NormalizeNewlines {
+		thought = domain.NormalizeNewlines(thought)
+	}
+	if config.RedactPII || len(config.RedactPatterns) > 0 {
+		redactor, err := domain.NewRedactor(config.RedactPII, config.RedactPatterns)
+		if err != nil {
+			return "", err
+		}
+		thought, _ = redactor.Redact(thought)
+	}
+	return thought, nil
+}
+
+// hashThought returns the hex SHA-256 of a thought
+func hashThought(thought string) string {
+	sum := sha256.Sum256([]byte(thought))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRunID returns a random identifier for a single CLI run
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// currentUser returns the name of the user running the tool
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
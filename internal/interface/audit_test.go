@@ -0,0 +1,84 @@
+package interfacelayer_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	interfacelayer "claude-think-tool/internal/interface"
+	"claude-think-tool/test/unit"
+)
+
+func TestCLI_AuditLog(t *testing.T) {
+	tests := []struct {
+		name        string
+		extraArgs   []string
+		wantThought string
+		wantHash    string // the text whose hash the entry carries
+	}{
+		{name: "hash only by default", extraArgs: nil, wantHash: "Secret thought"},
+		{name: "full thought with -audit-full", extraArgs: []string{"-audit-full"}, wantThought: "Secret thought", wantHash: "Secret thought"},
+		{name: "full thought is redacted", extraArgs: []string{"-audit-full", "-redact-pattern=Secret"}, wantThought: "[REDACTED] thought", wantHash: "[REDACTED] thought"},
+		{name: "hash of the redacted thought", extraArgs: []string{"-redact-pattern=Secret"}, wantHash: "[REDACTED] thought"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appended := map[string][]string{}
+			storage := &unit.MockFileStorage{
+				AppendToFileFunc: func(filePath string, content string) error {
+					appended[filePath] = append(appended[filePath], content)
+					return nil
+				},
+			}
+			service := staticService(&domain.ThinkResponse{
+				Raw:     map[string]interface{}{},
+				Content: "Analysis",
+				Model:   "claude-3-opus-20240229",
+				Usage:   domain.Usage{InputTokens: 120, OutputTokens: 45},
+			})
+
+			args := append([]string{"program", "-apikey=test-key", "-audit-log=audit.jsonl"}, tt.extraArgs...)
+			args = append(args, "Secret thought")
+			if code, _ := runCLI(t, args, service, storage); code != interfacelayer.ExitOK {
+				t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+			}
+
+			lines := appended["audit.jsonl"]
+			if len(lines) != 1 {
+				t.Fatalf("Expected exactly one audit line, got %d", len(lines))
+			}
+			if !strings.HasSuffix(lines[0], "\n") {
+				t.Errorf("Expected audit line to be newline-terminated")
+			}
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+				t.Fatalf("Audit line is not valid JSON: %v", err)
+			}
+			for _, field := range []string{"timestamp", "run_id", "user", "model", "thought_hash", "usage", "status"} {
+				if _, ok := entry[field]; !ok {
+					t.Errorf("Expected audit entry to contain %q, got %v", field, entry)
+				}
+			}
+			if entry["model"] != "claude-3-opus-20240229" {
+				t.Errorf("Expected model of the response, got %v", entry["model"])
+			}
+			if usage, _ := entry["usage"].(map[string]interface{}); usage["input_tokens"] != float64(120) {
+				t.Errorf("Expected input_tokens 120, got %v", entry["usage"])
+			}
+			if sum := sha256.Sum256([]byte(tt.wantHash)); entry["thought_hash"] != hex.EncodeToString(sum[:]) {
+				t.Errorf("Expected the hash of %q, got %v", tt.wantHash, entry["thought_hash"])
+			}
+			if thought, _ := entry["thought"].(string); thought != tt.wantThought {
+				t.Errorf("Expected thought %q, got entry %v", tt.wantThought, entry)
+			}
+			if strings.Contains(lines[0], "Secret") != (tt.wantThought == "Secret thought") {
+				t.Errorf("Thought text leaked into audit line: %s", lines[0])
+			}
+		})
+	}
+}
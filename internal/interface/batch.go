@@ -0,0 +1,278 @@
+package interfacelayer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"claude-think-tool/internal/domain"
+)
+
+// timeoutDirective matches the optional "timeout=<duration> |" prefix of a
+// batch input that overrides -timeout for that input
+var timeoutDirective = regexp.MustCompile(`^\s*timeout=(\S*)\s*\|`)
+
+// BatchResult is the analysis of one input file of a batch, or why it failed
+type BatchResult struct {
+	Index    int    // position of the input in the batch, from 0
+	Input    string // the input file
+	Thought  string // the thought as sent, with any redactions; empty if it couldn't be read
+	Response *domain.ThinkResponse
+	Error    string
+
+	// Latency is how long the input took to read and analyze; zero for an
+	// input restored from a checkpoint
+	Latency time.Duration
+}
+
+// batchOptions are the run options applied to every analysis of a batch
+type batchOptions struct {
+	auditLog      string
+	auditFull     bool
+	outputFile    string
+	toClipboard   bool
+	showChunks    bool
+	profile       bool
+	failOnRefusal bool
+	failOnEmpty   bool
+	riskThreshold domain.RiskLevel
+
+	// checkpoint records each completed input; with resume, inputs already
+	// recorded there are not analyzed again
+	checkpoint string
+	resume     bool
+
+	// delay, plus a random part of jitter, is waited between consecutive
+	// analyses to spread the requests out
+	delay  time.Duration
+	jitter time.Duration
+}
+
+// runBatch analyzes each input file separately and writes all results at
+// once. A failing file doesn't stop the others; the exit code reports the
+// first failure in the same order of precedence as a single analysis.
+func (c *CLI) runBatch(config domain.Config, inputs []string, opts batchOptions) int {
+	// NDJSON printed to stdout is streamed, one line as each input completes
+	stream := config.OutputFormat == "ndjson" && opts.outputFile == "" && !opts.toClipboard
+
+	var checkpoint *batchCheckpoint
+	var completed map[int]checkpointEntry
+	if opts.checkpoint != "" {
+		var err error
+		if opts.resume {
+			completed, err = loadCheckpoint(c.fileStorage, opts.checkpoint)
+		} else {
+			err = c.fileStorage.WriteToFile(opts.checkpoint, "")
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return ExitError
+		}
+		checkpoint = &batchCheckpoint{storage: c.fileStorage, path: opts.checkpoint}
+	}
+
+	results := make([]BatchResult, 0, len(inputs))
+	analyzed := false
+	for i, input := range inputs {
+		var result BatchResult
+		if entry, ok := completed[i]; ok && entry.Input == input {
+			log.Printf("Skipping %s, already analyzed according to checkpoint %s", input, opts.checkpoint)
+			result = BatchResult{Index: i, Input: input, Thought: entry.Thought, Response: entry.Response}
+		} else {
+			// Pause before every analysis but the first, so none follows the last
+			if analyzed {
+				time.Sleep(batchPause(opts.delay, opts.jitter))
+			}
+			analyzed = true
+			started := time.Now()
+			result = c.analyzeBatchInput(config, input, opts)
+			result.Index, result.Latency = i, time.Since(started)
+			if checkpoint != nil && result.Error == "" {
+				if err := checkpoint.record(i, result); err != nil {
+					log.Printf("Warning: failed to write checkpoint: %v", err)
+				}
+			}
+		}
+		if stream {
+			fmt.Println(c.formatter.FormatBatch([]BatchResult{result}, config.OutputFormat))
+		}
+		results = append(results, result)
+	}
+
+	if !stream {
+		if code := c.emitOutput(c.formatter.FormatBatch(results, config.OutputFormat), opts.outputFile, opts.toClipboard); code != ExitOK {
+			return code
+		}
+	}
+
+	failed, refused, empty, risky := 0, false, false, false
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			continue
+		}
+		if result.Response.Refused {
+			log.Printf("Notice: Claude refused to analyze %s (stop_reason: refusal); its output is not an analysis", result.Input)
+			refused = true
+		}
+		empty = empty || strings.TrimSpace(result.Response.Content) == ""
+		risky = risky || (opts.riskThreshold != domain.RiskUnknown && result.Response.RiskLevel.AtLeast(opts.riskThreshold))
+	}
+
+	switch {
+	case failed > 0:
+		log.Printf("Error: %d of %d inputs failed", failed, len(results))
+		return ExitError
+	case refused && opts.failOnRefusal:
+		return ExitRefusal
+	case empty && opts.failOnEmpty:
+		log.Printf("Analysis produced no content for at least one input")
+		return ExitEmpty
+	case risky:
+		log.Printf("Risk level of at least one input is at or above the -fail-on-risk threshold %s", opts.riskThreshold)
+		return ExitRiskLevel
+	}
+	return ExitOK
+}
+
+// batchPause returns the pause before the next analysis of a batch: delay
+// plus a random duration below jitter
+func batchPause(delay, jitter time.Duration) time.Duration {
+	if jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	return delay
+}
+
+// analyzeBatchInput reads and analyzes one input file with its own timeout
+func (c *CLI) analyzeBatchInput(config domain.Config, input string, opts batchOptions) BatchResult {
+	thought, err := c.fileStorage.ReadFromFile(input)
+	if err != nil {
+		err = fmt.Errorf("reading input file: %w", err)
+		c.recordItem(nil, err)
+		return BatchResult{Input: input, Error: err.Error()}
+	}
+
+	timeout, thought, err := parseTimeoutDirective(thought)
+	if err != nil {
+		c.recordItem(nil, err)
+		return BatchResult{Input: input, Error: err.Error()}
+	}
+	sent, err := sentThought(thought, config)
+	if err != nil {
+		c.recordItem(nil, err)
+		return BatchResult{Input: input, Error: err.Error()}
+	}
+	if timeout == 0 {
+		timeout = config.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "[%s] Analyzing %s with model %s\n", c.formatTime(time.Now()), input, config.Model)
+	}
+	response, err := c.thinkService.AnalyzeThought(ctx, thought, config)
+	c.recordItem(response, err)
+	if opts.auditLog != "" {
+		if auditErr := c.writeAuditLog(opts.auditLog, opts.auditFull, newRunID(), thought, config, response, err); auditErr != nil {
+			log.Printf("Warning: failed to write audit log: %v", auditErr)
+		}
+	}
+	if err != nil {
+		return BatchResult{Input: input, Thought: sent, Error: err.Error()}
+	}
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "[%s] Analysis of %s completed: %s\n", c.formatTime(time.Now()), input, FormatSummary(response))
+	}
+
+	if !opts.showChunks {
+		response.Chunks = nil
+	}
+	if !opts.profile {
+		response.Timings = nil
+	}
+	return BatchResult{Input: input, Thought: sent, Response: response}
+}
+
+// parseTimeoutDirective splits a leading timeout directive off a batch input,
+// returning the timeout (0 without a directive) and the remaining thought
+func parseTimeoutDirective(thought string) (time.Duration, string, error) {
+	match := timeoutDirective.FindStringSubmatchIndex(thought)
+	if match == nil {
+		return 0, thought, nil
+	}
+	value := thought[match[2]:match[3]]
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, "", fmt.Errorf("invalid timeout directive %q: must be a positive duration such as 60s", value)
+	}
+	return timeout, strings.TrimSpace(thought[match[1]:]), nil
+}
+
+// FormatBatch formats the results of a batch: a section per input for text
+// and gh-tasks output, otherwise an {"index", "input", "thought", "latency_ms",
+// "result" or "error"} object per input, as a JSON array or, for ndjson, one
+// line each
+func (f *Formatter) FormatBatch(results []BatchResult, format string) string {
+	if format == "text" || format == "gh-tasks" {
+		sections := make([]string, 0, len(results))
+		for _, result := range results {
+			var body string
+			if result.Error != "" {
+				body = "Error: " + result.Error
+			} else {
+				body = f.FormatOutput(result.Response, format)
+			}
+			sections = append(sections, fmt.Sprintf("=== %s ===\n%s", result.Input, body))
+		}
+		return strings.Join(sections, "\n\n")
+	}
+
+	items := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		item := map[string]interface{}{"index": result.Index, "input": result.Input}
+		if result.Thought != "" {
+			item["thought"] = result.Thought
+		}
+		if result.Latency > 0 {
+			item["latency_ms"] = result.Latency.Milliseconds()
+		}
+		switch {
+		case result.Error != "":
+			item["error"] = result.Error
+		case format == "minimal":
+			item["result"] = minimalOutput{
+				Thought: result.Response.Input,
+				Content: result.Response.Content,
+				Model:   result.Response.Model,
+				Usage:   result.Response.Usage,
+			}
+		default:
+			item["result"] = jsonPayload(result.Response)
+		}
+		items = append(items, item)
+	}
+
+	if format == "ndjson" {
+		lines := make([]string, 0, len(items))
+		for _, item := range items {
+			line, err := f.marshalLine(item)
+			if err != nil {
+				return fmt.Sprintf("Error formatting JSON: %v", err)
+			}
+			lines = append(lines, string(line))
+		}
+		return strings.Join(lines, "\n")
+	}
+	jsonBytes, err := f.marshalJSON(items)
+	if err != nil {
+		return fmt.Sprintf("Error formatting JSON: %v", err)
+	}
+	return string(jsonBytes)
+}
@@ -0,0 +1,47 @@
+package interfacelayer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// CanonicalJSON marshals v with recursively sorted object keys, two-space
+// indentation and normalized numbers, so equal values always produce
+// byte-identical output
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	generic, err := genericJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := jsonTree{leaf: canonicalLeaf}
+	var buf bytes.Buffer
+	if err := tree.write(&buf, generic, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalLeaf renders a number canonically and any other scalar as is
+func canonicalLeaf(v interface{}) (string, error) {
+	if number, ok := v.(json.Number); ok {
+		return canonicalNumber(number)
+	}
+	return jsonScalar(v)
+}
+
+// canonicalNumber renders integral values without a fraction or exponent and
+// everything else in the shortest round-tripping form
+func canonicalNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %q: %w", n, err)
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatInt(int64(f), 10), nil
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64), nil
+}
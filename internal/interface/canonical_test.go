@@ -0,0 +1,82 @@
+package interfacelayer_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	interfacelayer "claude-think-tool/internal/interface"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	t.Run("equivalent inputs are byte-identical", func(t *testing.T) {
+		inputs := []string{
+			`{"b": 1, "a": {"y": [1.0, {"z": true, "c": null}], "x": "s"}, "n": 2.50}`,
+			`{"n": 2.5, "a": {"x": "s", "y": [1, {"c": null, "z": true}]}, "b": 1e0}`,
+		}
+
+		var outputs []string
+		for _, input := range inputs {
+			var v interface{}
+			if err := json.Unmarshal([]byte(input), &v); err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+			out, err := interfacelayer.CanonicalJSON(v)
+			if err != nil {
+				t.Fatalf("CanonicalJSON returned error: %v", err)
+			}
+			outputs = append(outputs, string(out))
+		}
+
+		if outputs[0] != outputs[1] {
+			t.Errorf("Outputs differ:\n%s\n---\n%s", outputs[0], outputs[1])
+		}
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		v := map[string]interface{}{
+			"z":     []interface{}{},
+			"a":     map[string]interface{}{"k": 1e21, "j": 0.1},
+			"empty": map[string]interface{}{},
+			"html":  "<b>",
+		}
+		want := `{
+  "a": {
+    "j": 0.1,
+    "k": 1e+21
+  },
+  "empty": {},
+  "html": "<b>",
+  "z": []
+}`
+
+		out, err := interfacelayer.CanonicalJSON(v)
+		if err != nil {
+			t.Fatalf("CanonicalJSON returned error: %v", err)
+		}
+		if string(out) != want {
+			t.Errorf("Expected:\n%s\ngot:\n%s", want, out)
+		}
+	})
+
+	t.Run("formatter option", func(t *testing.T) {
+		response := &domain.ThinkResponse{
+			Raw: map[string]interface{}{
+				"usage": map[string]interface{}{"output_tokens": 45.0, "input_tokens": 120.0},
+				"id":    "msg_123",
+			},
+			RiskLevel: domain.RiskLow,
+		}
+
+		formatter := interfacelayer.NewFormatter()
+		formatter.Canonical = true
+		out := formatter.FormatOutput(response, "json")
+		want, err := interfacelayer.CanonicalJSON(json.RawMessage(out))
+		if err != nil {
+			t.Fatalf("CanonicalJSON returned error: %v", err)
+		}
+		if out != string(want) {
+			t.Errorf("Formatter output is not canonical:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package interfacelayer
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"claude-think-tool/internal/domain"
+)
+
+// flagCapability describes one command line flag in the -capabilities output
+type flagCapability struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+// capabilities is the JSON document printed by -capabilities
+type capabilities struct {
+	Version           string            `json:"version"`
+	Formats           []string          `json:"formats"`
+	Providers         []string          `json:"providers"`
+	ModelAliases      map[string]string `json:"model_aliases"`
+	ModelOutputLimits map[string]int    `json:"model_max_output_tokens"`
+	Presets           []string          `json:"presets"`
+	ContentBlockTypes []string          `json:"content_block_types"`
+	FocusCategories   []string          `json:"focus_categories"`
+	Features          map[string]bool   `json:"features"`
+	Flags             []flagCapability  `json:"flags"`
+}
+
+// printCapabilities prints what this build supports as JSON, for tools that
+// wrap the CLI. Everything but the feature toggles is read from the flag set
+// and the registries in effect, so it can't drift from the actual behavior.
+func (c *CLI) printCapabilities(fs *flag.FlagSet, aliases map[string]string, limits map[string]int) int {
+	report := capabilities{
+		Version:           Version,
+		Formats:           Formats(),
+		Providers:         domain.Providers,
+		ModelAliases:      aliases,
+		ModelOutputLimits: limits,
+		ContentBlockTypes: domain.ContentBlockTypes,
+		FocusCategories:   domain.FocusCategories,
+		Features: map[string]bool{
+			"batch":       true,
+			"checkpoint":  true,
+			"interactive": true,
+			"retries":     true,
+			"stdin_json":  true,
+			"streaming":   false,
+		},
+	}
+	for _, preset := range domain.PromptPresets {
+		report.Presets = append(report.Presets, preset.Name)
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		report.Flags = append(report.Flags, flagCapability{Name: f.Name, Type: flagType(f), Default: f.DefValue, Usage: f.Usage})
+	})
+
+	jsonBytes, err := c.formatter.marshalJSON(report)
+	if err != nil {
+		log.Printf("Error formatting capabilities: %v", err)
+		return ExitError
+	}
+	fmt.Println(string(jsonBytes))
+	return ExitOK
+}
+
+// flagType names the type of value a flag takes, as in -help: bool, string,
+// int, duration, or value for repeatable and other custom flags
+func flagType(f *flag.Flag) string {
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return "bool"
+	}
+	// UnquoteUsage derives the name from the value's type unless the usage
+	// quotes one, which this CLI's usage strings only do for examples
+	name, _ := flag.UnquoteUsage(&flag.Flag{Value: f.Value})
+	return name
+}
@@ -0,0 +1,64 @@
+package interfacelayer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// checkpointEntry is one line of a batch checkpoint: an input that was
+// analyzed successfully, with its position in the batch, the thought as sent
+// and its result
+type checkpointEntry struct {
+	Index    int                   `json:"index"`
+	Input    string                `json:"input"`
+	Thought  string                `json:"thought,omitempty"`
+	Response *domain.ThinkResponse `json:"response"`
+}
+
+// batchCheckpoint appends completed batch inputs to a checkpoint file, one
+// JSON line each; a line cut short by a crash is skipped when loading
+type batchCheckpoint struct {
+	storage domain.FileStorage
+	path    string
+}
+
+// record appends a completed input to the checkpoint
+func (c *batchCheckpoint) record(index int, result BatchResult) error {
+	line, err := json.Marshal(checkpointEntry{Index: index, Input: result.Input, Thought: result.Thought, Response: result.Response})
+	if err != nil {
+		return err
+	}
+	return c.storage.AppendToFile(c.path, string(line)+"\n")
+}
+
+// loadCheckpoint reads the completed inputs of a checkpoint, keyed by their
+// index in the batch. A missing checkpoint has none.
+func loadCheckpoint(storage domain.FileStorage, path string) (map[int]checkpointEntry, error) {
+	data, err := storage.ReadFromFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+
+	completed := make(map[int]checkpointEntry)
+	for i, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var entry checkpointEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Response == nil {
+			log.Printf("Warning: skipping unreadable line %d of checkpoint %s", i+1, path)
+			continue
+		}
+		completed[entry.Index] = entry
+	}
+	return completed, nil
+}
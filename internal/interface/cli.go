@@ -3,10 +3,16 @@ package interfacelayer
 import (
 	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,11 +24,46 @@ const (
 	Version = "0.1.0"
 )
 
+// Exit codes returned by the CLI
+const (
+	ExitOK        = 0
+	ExitError     = 1
+	ExitUsage     = 2
+	ExitRiskLevel = 3
+	ExitRefusal   = 4
+	ExitEmpty     = 5
+
+	ExitGoldenMismatch = 6
+)
+
+// gitDiffPrompt frames a -git-diff thought for a code review, unless -prompt
+// or -preset gives another prompt
+const gitDiffPrompt = "Review the following uncommitted code changes as a careful code reviewer: point out bugs, risky or incomplete changes, missing tests and unclear code before they are committed."
+
+// DefaultUserAgent identifies the tool and Go version to the API
+func DefaultUserAgent() string {
+	return fmt.Sprintf("claude-think-tool/%s (%s)", Version, runtime.Version())
+}
+
 // CLI handles command line interface functionality
 type CLI struct {
 	thinkService domain.ThinkService
 	fileStorage  domain.FileStorage
 	formatter    *Formatter
+	clipboard    domain.Clipboard
+	diffSource   domain.DiffSource
+	timeFormat   string
+	transcript   string      // file interactive turns are appended to, if any
+	simulated    bool        // the service answers with canned responses instead of calling the API
+	summary      *runSummary // collected for -summary-json, nil otherwise
+
+	normalizeOutput bool // convert CRLF and CR line endings in the output to LF
+
+	clock func() time.Time // times -measure iterations; time.Now when nil
+
+	// Model aliases for per-request models of -stdin-json (the defaults when nil)
+	modelAliases  map[string]string
+	strictAliases bool
 }
 
 // NewCLI creates a new CLI instance
@@ -34,76 +75,662 @@ func NewCLI(service domain.ThinkService, storage domain.FileStorage, formatter *
 	}
 }
 
+// SetClipboard enables -clipboard and -clipboard-out using the given clipboard
+func (c *CLI) SetClipboard(clipboard domain.Clipboard) {
+	c.clipboard = clipboard
+}
+
+// SetDiffSource enables -git-diff using the given source of code changes
+func (c *CLI) SetDiffSource(diffSource domain.DiffSource) {
+	c.diffSource = diffSource
+}
+
+// SetSimulated marks the service as simulated: no API key is needed and the
+// output is flagged as not coming from Claude
+func (c *CLI) SetSimulated(simulated bool) {
+	c.simulated = simulated
+}
+
+// SetClock replaces the clock that times -measure iterations
+func (c *CLI) SetClock(clock func() time.Time) {
+	c.clock = clock
+}
+
+// SetTranscript makes interactive mode append every completed turn to path
+func (c *CLI) SetTranscript(path string) {
+	c.transcript = path
+}
+
 // Run executes the CLI application
 func (c *CLI) Run() {
 	c.runWithExit(true)
 }
 
 // TestRun executes the CLI application without exiting the program (for testing)
-func (c *CLI) TestRun() {
-	c.runWithExit(false)
+// and returns the exit code the program would have terminated with
+func (c *CLI) TestRun() int {
+	return c.runWithExit(false)
 }
 
 // runWithExit executes the CLI application with option to exit program
-func (c *CLI) runWithExit(shouldExit bool) {
+func (c *CLI) runWithExit(shouldExit bool) int {
+	code := c.run()
+	if c.summary != nil {
+		c.writeSummary(code)
+	}
+	if shouldExit && code != ExitOK {
+		os.Exit(code)
+	}
+	return code
+}
+
+// run executes the CLI application and returns its exit code
+func (c *CLI) run() int {
+	c.summary = nil
+
 	// Define command line flags
 	apiKey := flag.String("apikey", "", "Anthropic API key (default: ANTHROPIC_API_KEY env var)")
-	model := flag.String("model", "claude-3-7-sonnet-20250219", "Claude model to use")
+	model := flag.String("model", "claude-3-7-sonnet-20250219", "Claude model to use (id or alias: sonnet, opus, haiku)")
+	modelAliases := flag.String("model-aliases", "", "Extra or overriding model aliases as alias=model-id,...")
+	strictModelAliases := flag.Bool("strict-model-aliases", false, "Fail on unknown model aliases instead of passing them through")
 	timeout := flag.Duration("timeout", 30*time.Second, "API request timeout")
+	attemptTimeout := flag.Duration("attempt-timeout", 0, "Timeout for each API request attempt, within -timeout, so a slow attempt leaves time for retries (0 for no per-attempt limit)")
+	connectTimeout := flag.Duration("connect-timeout", 10*time.Second, "Timeout for connecting to the API (dial and TLS handshake), within -timeout")
 	maxTokens := flag.Int("max-tokens", 1024, "Maximum tokens in Claude's response")
-	inputFile := flag.String("input", "", "Input file containing thought to analyze")
+	clampMaxTokens := flag.Bool("clamp-max-tokens", false, "Lower -max-tokens to the model's output limit with a warning instead of failing")
+	modelMaxTokens := flag.String("model-max-tokens", "", "Extra or overriding model output limits as model-id=tokens,...")
+	inputFiles := stringsFlag{}
+	flag.Var(&inputFiles, "input", "Input file containing thought to analyze (repeat to analyze several files as a batch)")
+	fromClipboard := flag.Bool("clipboard", false, "Read the thought from the system clipboard")
+	toClipboard := flag.Bool("clipboard-out", false, "Also copy the output to the system clipboard")
+	fromGitDiff := flag.Bool("git-diff", false, "Review the uncommitted changes of the current git repository (the output of git diff) as the thought")
+	gitDiffArgs := flag.String("git-diff-args", "", "Space-separated arguments for git diff with -git-diff, e.g. \"--staged\" or \"main...HEAD\"")
+	diagnoseResponse := flag.Bool("diagnose-response", false, "Explain the model's stop reason and tool use on stderr after the analysis")
+	golden := flag.String("golden", "", "Compare the analysis content with this golden file and fail (exit 6) if it differs")
+	goldenUpdate := flag.Bool("golden-update", false, "Write the analysis content to the -golden file instead of comparing")
+	goldenTolerance := flag.Float64("golden-tolerance", 0, "Normalized edit distance (0-1) tolerated by -golden before failing")
+	transcript := flag.String("transcript", "", "Append each interactive turn to this file as soon as it completes")
 	outputFile := flag.String("output", "", "Output file for analysis results")
-	outputFormat := flag.String("format", "text", "Output format (text, json)")
+	footer := flag.Bool("footer", false, "Append the model, timestamp and tool version to text output; on by default for -format gh-tasks")
+	outputOnError := flag.Bool("output-on-error", false, "When the analysis fails, write an error record (error, thought_hash, timestamp) to the -output file in the chosen format")
+	outputFormat := flag.String("format", "text", "Output format (text, json, minimal, pretty, ndjson, gh-tasks)")
+	noEscapeUnicode := flag.Bool("no-escape-unicode", true, "Write non-ASCII text literally in JSON output (-no-escape-unicode=false for \\uXXXX escapes)")
+	summaryJSON := flag.String("summary-json", "", "Write a JSON summary of the run (status, items, failures, tokens, duration) to this file, or stdout for -")
+	profile := flag.Bool("profile", false, "Print a timing breakdown of the run to stderr (and add a timings object to JSON output)")
+	canonical := flag.Bool("canonical", false, "Render JSON output canonically (sorted keys, stable formatting) for diffing")
 	verbose := flag.Bool("verbose", false, "Verbose output mode")
 	interactive := flag.Bool("interactive", false, "Interactive mode")
+	stdinJSON := flag.Bool("stdin-json", false, "Serve a JSON line protocol on stdin/stdout for editor integration")
 	version := flag.Bool("version", false, "Print version information")
 	help := flag.Bool("help", false, "Print help information")
-	thoughtPrompt := flag.String("prompt", "", "Custom prompt template (default: \"Please analyze the following thought: %s\")")
+	promptTemplate := flag.String("prompt-template", "", "File with a Go text/template user prompt, e.g. \"Critique: {{.Thought}}\" (overrides -prompt; - reads it from stdin)")
+	toolResultFile := flag.String("tool-result-file", "", "File whose content is sent as the think tool result instead of running the analyzer (for testing the follow-up)")
+	structuredOutput := flag.String("structured-output", "", "File with a JSON schema; Claude answers with a validated object matching it instead of the think tool analysis")
+	validateTemplate := flag.Bool("validate-template", false, "Render -prompt-template with a sample thought and exit without calling the API")
+	configFile := flag.String("config", "", "JSON config file whose keys are flag names (flags given on the command line take precedence)")
+	noEnv := flag.Bool("no-env", false, "Ignore environment variables (ANTHROPIC_API_KEY, CTT_FAKE_API and CTT_ options); settings come only from flags and the config file")
+	configRequired := flag.Bool("config-required", false, "Fail if the -config file does not exist instead of using defaults")
+	thoughtPrompt := flag.String("prompt", "", "Custom prompt template (default: \"Please analyze the following thought: %s\"; - reads it from stdin)")
+	preset := flag.String("preset", "", "Use a built-in prompt style (see -list-presets); -prompt takes precedence")
+	focus := flag.String("focus", "", "Comma-separated categories to restrict the analysis to (clarity, correctness, cost, feasibility, maintainability, performance, security)")
+	listPresets := flag.Bool("list-presets", false, "List the built-in prompt presets and exit")
+	showCapabilities := flag.Bool("capabilities", false, "Print the supported formats, providers, models, presets, features and flags as JSON and exit")
+	modelFallback := flag.String("model-fallback", "", "Comma-separated models to try in order when the primary model is unavailable")
+	raceModels := flag.String("race", "", "Comma-separated models sent the initial request along with -model; the first to answer is used and the others are cancelled (each may still be billed)")
+	caCert := flag.String("cacert", "", "PEM file with additional root CA certificates (e.g. for a TLS-inspecting proxy)")
+	insecureSkipVerify := flag.Bool("insecure-skip-verify", false, "Disable TLS certificate verification (INSECURE, testing only)")
+	timeFormat := flag.String("time-format", "rfc3339", "Format for emitted timestamps: rfc3339, unix, unixmilli or a Go layout")
+	auditLog := flag.String("audit-log", "", "Append a JSON line describing each run to this file")
+	auditFull := flag.Bool("audit-full", false, "Include the full thought in -audit-log entries (default: hash only)")
+	jsonPath := flag.String("json-path", "", "Print only the value at this dot/index path of the raw response (e.g. content.0.text)")
+	chunkSize := flag.Int("chunk-size", 0, "Split thoughts longer than this many characters into chunks analyzed separately and synthesized (0 disables)")
+	redactPII := flag.Bool("redact-pii", false, "Redact emails, phone numbers and card numbers from the thought before sending it")
+	redactPatterns := stringsFlag{}
+	flag.Var(&redactPatterns, "redact-pattern", "Additional regular expression to redact from the thought (repeatable)")
+	normalizeNewlines := flag.Bool("normalize-newlines", false, "Convert CRLF and CR line endings in the thought to LF before it is used")
+	normalizeOutputNewlines := flag.Bool("normalize-output-newlines", false, "Convert CRLF and CR line endings in the rendered output to LF")
+	scoreSentiment := flag.Bool("sentiment", false, "Score the thought's sentiment locally (positive, neutral or negative, -1 to 1) and add it to the output")
+	includeInput := flag.Bool("include-input", false, "Include the analyzed thought in the output: a \"thought\" field in JSON formats, a header in text and gh-tasks")
+	echoThought := flag.Bool("echo-thought", false, "Print the original, unredacted thought to stderr (it is never sent)")
+	userAgent := flag.String("user-agent", DefaultUserAgent(), "User-Agent header sent with API requests")
+	provider := flag.String("provider", domain.ProviderAnthropic, "API provider: anthropic, vertex (Claude on Google Vertex AI; pass an access token as the API key) or ollama (a local model)")
+	vertexProject := flag.String("vertex-project", "", "Google Cloud project for -provider vertex")
+	vertexRegion := flag.String("vertex-region", domain.DefaultVertexRegion, "Vertex AI region for -provider vertex")
+	orgID := flag.String("org-id", "", "Organization id sent as the anthropic-organization-id header (for multi-tenant gateways)")
+	headers := headerFlag{}
+	flag.Var(headers, "header", "Extra request header as \"Name: value\" (repeatable)")
+	retryOnStatus := flag.String("retry-on-status", "", "Comma-separated HTTP statuses to retry, replacing the built-in set (429,500,502,503,529)")
+	maxResponseBytes := flag.Int64("max-response-bytes", domain.DefaultMaxResponseBytes, "Maximum size of an API response body in bytes")
+	retryBudget := flag.Int("retry-budget", 0, "Maximum retries across all requests of the run combined (0 for unlimited)")
+	chunkOverlap := flag.Int("chunk-overlap", 200, "Characters of the previous chunk repeated at the start of the next one")
+	showChunks := flag.Bool("show-chunks", false, "Include the per-chunk analyses in the output")
+	requestExtra := flag.String("request-extra", "", "JSON object of extra top-level request fields, e.g. '{\"temperature\":0.2}' (model, messages and other fields the tool sets are protected)")
+	userID := flag.String("user-id", "", "End-user identifier sent as metadata.user_id for Anthropic abuse tracking")
+	sessionID := flag.String("session-id", "", "Container/session id sent with every request so server-side tool state persists across turns")
+	abortOnInvalidToolUse := flag.Bool("abort-on-invalid-tool-use", false, "Fail when Claude's tool_use has an empty or invalid input instead of asking it to retry")
+	errorOnEmptyToolResult := flag.Bool("error-on-empty-tool-result", false, "Fail when the analyzer produces no output instead of sending a tool_result without content")
+	followupPrompt := flag.String("followup-prompt", "", "Go text/template sent as a text block next to the tool result, e.g. \"Using the analysis above, give a final recommendation\"")
+	stripToolResultPrefix := flag.String("strip-tool-result-prefix", "", "Regular expression removed from the start of the analyzer's text before it is sent as the tool result")
+	maxPauseTurns := flag.Int("max-pause-turns", 3, "Maximum times a request is re-sent to let Claude continue a paused turn (stop_reason pause_turn)")
+	includeToolTrace := flag.Bool("include-tool-trace", false, "Record each tool_use and the tool_result sent back (in JSON output, and on stderr with -verbose)")
+	forceTool := flag.Bool("force-tool", false, "Require Claude to call the think tool (tool_choice), so the analysis always goes through the tool")
+	contentBlocks := flag.String("content-blocks", "text", "Comma-separated content block types included in the analysis text (text, thinking, redacted_thinking, tool_use, server_tool_use); others are skipped")
+	explainNoTool := flag.Bool("explain-no-tool", false, "When Claude answers without the think tool, ask it why in a follow-up request and include the answer")
+	noFollowup := flag.Bool("no-followup", false, "Stop after Claude's tool_use request and print it instead of running the analyzer and follow-up")
+	analyzerName := flag.String("analyzer", "default", "Analyzer producing the think tool result (default, fallacy)")
+	failOnRisk := flag.String("fail-on-risk", "", "Exit with code 3 when the risk level is at or above this level (low, medium, high)")
+	checkpointFile := flag.String("checkpoint", "", "File recording each completed -input file of a batch, so an interrupted batch can be continued with -resume")
+	resume := flag.Bool("resume", false, "Skip the batch inputs already recorded in the -checkpoint file")
+	batchDelay := flag.Duration("batch-delay", 0, "Pause between the analyses of a batch, to be gentle on the API")
+	batchJitter := flag.Duration("batch-jitter", 0, "Add a random pause of up to this duration to -batch-delay")
+	measure := flag.Int("measure", 0, "Run N analyses (cycling through the -input files, or repeating the thought), discard the content and print latency percentiles and average token usage")
+	validate := flag.Bool("validate", false, "Check the configuration, API key, prompt template and API connectivity, report each result and exit without analyzing")
+	validateOnly := flag.Bool("validate-only", false, "Check the thought locally (non-empty, valid UTF-8, not binary, within -max-thought-length) and exit without calling the API")
+	maxThoughtLength := flag.Int("max-thought-length", domain.DefaultMaxThoughtLength, "Maximum thought length in characters checked by -validate-only")
+	contentOnlyOnSuccess := flag.Bool("content-only-on-success", false, "With -format text, print exactly the analysis text on success and nothing on failure, failing when the response has no text content")
+	failOnEmpty := flag.Bool("fail-on-empty", false, "Exit with code 5 when the analysis has no text content")
+	failOnRefusal := flag.Bool("fail-on-refusal", false, "Exit with code 4 when Claude refuses to analyze the thought")
 	
-	flag.Parse()
+	// The flag package reports the error itself (and exits unless ContinueOnError is set)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if err := validateFlags(flag.CommandLine, os.Args[1:]); err != nil {
+		log.Printf("Error: %v", err)
+		return ExitUsage
+	}
 
 	// Print version and exit if requested
 	if *version {
 		c.printVersion()
-		if shouldExit {
-			os.Exit(0)
-		}
-		return
+		return ExitOK
 	}
 	
 	// Print help and exit if requested
 	if *help {
 		c.printHelp()
-		if shouldExit {
-			os.Exit(0)
+		return ExitOK
+	}
+
+	if *listPresets {
+		for _, p := range domain.PromptPresets {
+			fmt.Printf("%-18s %s\n", p.Name, p.Description)
+		}
+		return ExitOK
+	}
+
+	// Fill in anything not given on the command line from CTT_ environment
+	// variables, and then from the config file
+	sources := commandLineSources(flag.CommandLine)
+	if !*noEnv {
+		if err := applyEnv(flag.CommandLine, EnvConfigValues(os.Environ())); err != nil {
+			log.Printf("Error: %v", err)
+			return ExitUsage
+		}
+		sources.add(flag.CommandLine, sourceEnv)
+	}
+	if *configFile != "" {
+		values, err := LoadConfigFile(c.fileStorage, *configFile, *configRequired)
+		if err == nil {
+			err = applyConfigFile(flag.CommandLine, values)
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return ExitUsage
+		}
+		sources.add(flag.CommandLine, sourceConfig)
+	}
+	// The environment and config file can combine options just like the command line
+	if err := checkFlagCombinations(flag.CommandLine, sources); err != nil {
+		log.Printf("Error: %v", err)
+		return ExitUsage
+	}
+
+	// Resolve model aliases for the primary and fallback models
+	aliasOverrides, err := domain.ParseModelAliases(*modelAliases)
+	if err != nil {
+		log.Printf("Error: -model-aliases: %v", err)
+		return ExitUsage
+	}
+	aliases := domain.MergeModelAliases(aliasOverrides)
+	c.modelAliases, c.strictAliases = aliases, *strictModelAliases
+
+	limitOverrides, err := domain.ParseModelMaxTokens(*modelMaxTokens)
+	if err != nil {
+		log.Printf("Error: -model-max-tokens: %v", err)
+		return ExitUsage
+	}
+	resolvedModels := make([]string, 0, 1+len(splitList(*modelFallback)))
+	for _, name := range append([]string{*model}, splitList(*modelFallback)...) {
+		resolved, err := domain.ResolveModel(name, aliases, *strictModelAliases)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return ExitUsage
+		}
+		resolvedModels = append(resolvedModels, resolved)
+	}
+	var resolvedRaceModels []string
+	for _, name := range splitList(*raceModels) {
+		resolved, err := domain.ResolveModel(name, aliases, *strictModelAliases)
+		if err != nil {
+			log.Printf("Error: -race: %v", err)
+			return ExitUsage
+		}
+		resolvedRaceModels = append(resolvedRaceModels, resolved)
+	}
+
+	// Describe what is supported, with any configured aliases and limits
+	if *showCapabilities {
+		return c.printCapabilities(flag.CommandLine, aliases, domain.MergeModelMaxTokens(limitOverrides))
+	}
+
+	if *chunkSize < 0 || (*chunkSize > 0 && (*chunkOverlap < 0 || *chunkOverlap >= *chunkSize)) {
+		log.Printf("Error: -chunk-overlap must be between 0 and -chunk-size (exclusive), and -chunk-size must not be negative")
+		return ExitUsage
+	}
+
+	// "-" reads the prompt or the prompt template from stdin, which can feed
+	// only one of them and isn't free with -interactive or -stdin-json
+	promptFromStdin, templateFromStdin := *thoughtPrompt == "-", *promptTemplate == "-"
+	if promptFromStdin || templateFromStdin {
+		switch {
+		case promptFromStdin && templateFromStdin:
+			err = fmt.Errorf("-prompt and -prompt-template cannot both read stdin")
+		case *interactive:
+			err = fmt.Errorf("cannot read the prompt from stdin in -interactive mode")
+		case *stdinJSON:
+			err = fmt.Errorf("cannot read the prompt from stdin with -stdin-json")
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return ExitUsage
+		}
+	}
+	if promptFromStdin {
+		prompt, err := readStdin()
+		if err != nil {
+			log.Printf("Error reading prompt from stdin: %v", err)
+			return ExitError
+		}
+		if *thoughtPrompt = strings.TrimSpace(prompt); *thoughtPrompt == "" {
+			log.Printf("Error: -prompt - read an empty prompt from stdin")
+			return ExitUsage
+		}
+	}
+
+	// A preset supplies the prompt unless one was given, from any source
+	if *preset != "" {
+		p, err := domain.FindPromptPreset(*preset)
+		if err != nil {
+			log.Printf("Error: -preset: %v", err)
+			return ExitUsage
+		}
+		if *thoughtPrompt == "" {
+			*thoughtPrompt = p.Prompt
+		}
+	}
+
+	if _, err := domain.NewRedactor(*redactPII, redactPatterns); err != nil {
+		log.Printf("Error: -redact-pattern: %v", err)
+		return ExitUsage
+	}
+
+	var extraFields map[string]interface{}
+	if *requestExtra != "" {
+		if extraFields, err = domain.ParseRequestExtra(*requestExtra); err != nil {
+			log.Printf("Error: -request-extra: %v", err)
+			return ExitUsage
+		}
+	}
+
+	blockTypes, err := domain.ParseContentBlockTypes(*contentBlocks)
+	if err != nil {
+		log.Printf("Error: -content-blocks: %v", err)
+		return ExitUsage
+	}
+
+	var focusCategories []string
+	if *focus != "" {
+		if focusCategories, err = domain.ParseFocusCategories(*focus); err != nil {
+			log.Printf("Error: -focus: %v", err)
+			return ExitUsage
+		}
+	}
+
+	if _, err := regexp.Compile(*stripToolResultPrefix); err != nil {
+		log.Printf("Error: -strip-tool-result-prefix: %v", err)
+		return ExitUsage
+	}
+	if _, err := domain.RenderPromptTemplate(*followupPrompt, domain.PromptData{Thought: "sample"}); err != nil {
+		log.Printf("Error: -followup-prompt: %v", err)
+		return ExitUsage
+	}
+
+	switch *provider {
+	case domain.ProviderAnthropic, domain.ProviderOllama:
+	case domain.ProviderVertex:
+		if *vertexProject == "" {
+			log.Printf("Error: -provider vertex requires -vertex-project")
+			return ExitUsage
+		}
+	default:
+		log.Printf("Error: unknown -provider %q (expected one of %v)", *provider, domain.Providers)
+		return ExitUsage
+	}
+
+	orgIDGiven := false
+	flag.Visit(func(f *flag.Flag) {
+		orgIDGiven = orgIDGiven || f.Name == "org-id"
+	})
+	if orgIDGiven && strings.TrimSpace(*orgID) == "" {
+		log.Printf("Error: -org-id must not be empty")
+		return ExitUsage
+	}
+
+	if *checkpointFile != "" && len(inputFiles) < 2 {
+		log.Printf("Error: -checkpoint requires several -input files")
+		return ExitUsage
+	}
+
+	if *batchDelay < 0 || *batchJitter < 0 {
+		log.Printf("Error: -batch-delay and -batch-jitter must not be negative")
+		return ExitUsage
+	}
+	if (*batchDelay > 0 || *batchJitter > 0) && len(inputFiles) < 2 {
+		log.Printf("Error: -batch-delay and -batch-jitter require several -input files")
+		return ExitUsage
+	}
+
+	if *measure < 0 {
+		log.Printf("Error: -measure must not be negative")
+		return ExitUsage
+	}
+
+	if *forceTool {
+		// Forcing the think tool leaves nothing for these options to act on,
+		// and the API rejects forced tool use together with extended thinking
+		switch {
+		case *structuredOutput != "":
+			err = fmt.Errorf("-structured-output forces its own respond tool")
+		case *explainNoTool:
+			err = fmt.Errorf("-explain-no-tool has no effect when the tool is always called")
+		case extraFields["tool_choice"] != nil:
+			err = fmt.Errorf("-request-extra sets its own tool_choice")
+		case extraFields["thinking"] != nil:
+			err = fmt.Errorf("extended thinking from -request-extra cannot be combined with a forced tool")
+		}
+		if err != nil {
+			log.Printf("Error: -force-tool: %v", err)
+			return ExitUsage
+		}
+	}
+
+	if *contentOnlyOnSuccess && *outputFormat != "text" {
+		log.Printf("Error: -content-only-on-success requires -format text")
+		return ExitUsage
+	}
+
+	if *goldenTolerance < 0 || *goldenTolerance > 1 {
+		log.Printf("Error: -golden-tolerance must be between 0 and 1")
+		return ExitUsage
+	}
+
+	if *maxResponseBytes <= 0 {
+		log.Printf("Error: -max-response-bytes must be positive")
+		return ExitUsage
+	}
+
+	if *attemptTimeout < 0 {
+		log.Printf("Error: -attempt-timeout must not be negative")
+		return ExitUsage
+	}
+
+	if *connectTimeout <= 0 {
+		log.Printf("Error: -connect-timeout must be positive")
+		return ExitUsage
+	}
+
+	if *maxPauseTurns < 0 {
+		log.Printf("Error: -max-pause-turns must not be negative")
+		return ExitUsage
+	}
+
+	if *retryBudget < 0 {
+		log.Printf("Error: -retry-budget must not be negative")
+		return ExitUsage
+	}
+
+	var retryStatuses []int
+	if *retryOnStatus != "" {
+		retryStatuses, err = parseStatusCodes(*retryOnStatus)
+		if err != nil {
+			log.Printf("Error: -retry-on-status: %v", err)
+			return ExitUsage
+		}
+	}
+
+	c.formatter.Canonical = *canonical
+	c.formatter.EscapeUnicode = !*noEscapeUnicode
+	c.formatter.Color = *outputFile == "" && !*toClipboard && !*stdinJSON && ColorEnabled(os.Stdout)
+
+	// Resolve the timestamp format used wherever times are emitted
+	resolvedTimeFormat, err := ResolveTimeFormat(*timeFormat)
+	if err != nil {
+		log.Printf("Error: -time-format: %v", err)
+		return ExitUsage
+	}
+	c.timeFormat = resolvedTimeFormat
+	c.formatter.TimeFormat = resolvedTimeFormat
+
+	// Markdown output is meant for sharing, so it has a footer unless -footer
+	// was set, from any source
+	footerGiven := false
+	flag.Visit(func(f *flag.Flag) {
+		footerGiven = footerGiven || f.Name == "footer"
+	})
+	c.formatter.Footer = *footer || (!footerGiven && *outputFormat == "gh-tasks")
+	c.normalizeOutput = *normalizeOutputNewlines
+
+	c.SetTranscript(*transcript)
+
+	// Validate the risk threshold before spending an API call
+	var riskThreshold domain.RiskLevel
+	if *failOnRisk != "" {
+		var err error
+		riskThreshold, err = domain.ParseRiskLevel(*failOnRisk)
+		if err != nil {
+			log.Printf("Error: -fail-on-risk: %v", err)
+			return ExitUsage
 		}
-		return
 	}
 	
 	// Create config from flags
 	config := domain.Config{
 		APIKey:        *apiKey,
-		Model:         *model,
+		Model:         resolvedModels[0],
 		Timeout:       *timeout,
 		MaxTokens:     *maxTokens,
 		OutputFormat:  *outputFormat,
 		Verbose:       *verbose,
 		Interactive:   *interactive,
 		ThoughtPrompt: *thoughtPrompt,
+		ModelFallback: resolvedModels[1:],
+		RaceModels:    resolvedRaceModels,
+		Analyzer:      *analyzerName,
+		NoFollowup:    *noFollowup,
+		UserID:        *userID,
+		SessionID:     *sessionID,
+		RequestExtra:  extraFields,
+
+		AbortOnInvalidToolUse:  *abortOnInvalidToolUse,
+		ErrorOnEmptyToolResult: *errorOnEmptyToolResult,
+		StripToolResultPrefix:  *stripToolResultPrefix,
+		FollowupPrompt:         *followupPrompt,
+		Focus:                  focusCategories,
+		MaxPauseTurns:          *maxPauseTurns,
+		IncludeToolTrace:       *includeToolTrace,
+		ExplainNoTool:          *explainNoTool,
+		ContentBlockTypes:      blockTypes,
+		ForceTool:              forceToolName(*forceTool),
+
+		ModelMaxTokens: domain.MergeModelMaxTokens(limitOverrides),
+		ClampMaxTokens: *clampMaxTokens,
+
+		ChunkSize:    *chunkSize,
+		ChunkOverlap: *chunkOverlap,
+
+		RetryOnStatus: retryStatuses,
+		RetryBudget:   *retryBudget,
+
+		Sentiment:    *scoreSentiment,
+		IncludeInput: *includeInput,
+
+		NormalizeNewlines: *normalizeNewlines,
+		RedactPII:         *redactPII,
+		RedactPatterns:    redactPatterns,
+
+		UserAgent:        *userAgent,
+		MaxResponseBytes: *maxResponseBytes,
+
+		OrgID:   strings.TrimSpace(*orgID),
+		Headers: headers,
+
+		Provider:      *provider,
+		VertexProject: *vertexProject,
+		VertexRegion:  *vertexRegion,
+
+		ConnectTimeout: *connectTimeout,
+		AttemptTimeout: *attemptTimeout,
+
+		CACertFile:         *caCert,
+		InsecureSkipVerify: *insecureSkipVerify,
+
+		TimeFormat: resolvedTimeFormat,
+
+		Simulated: c.simulated && !*noEnv,
+		NoEnv:     *noEnv,
+	}
+
+	// Load the prompt template, if any
+	if *promptTemplate != "" {
+		var templateText string
+		var err error
+		if templateFromStdin {
+			templateText, err = readStdin()
+		} else {
+			templateText, err = c.fileStorage.ReadFromFile(*promptTemplate)
+		}
+		if err != nil {
+			log.Printf("Error reading prompt template: %v", err)
+			return ExitError
+		}
+		config.PromptTemplate = templateText
+	}
+
+	// Load the precomputed tool result, if any
+	if *toolResultFile != "" {
+		toolResult, err := c.fileStorage.ReadFromFile(*toolResultFile)
+		if err != nil {
+			log.Printf("Error reading tool result file: %v", err)
+			return ExitError
+		}
+		if strings.TrimSpace(toolResult) == "" {
+			log.Printf("Error: -tool-result-file %s is empty", *toolResultFile)
+			return ExitUsage
+		}
+		config.ToolResult = toolResult
+	}
+
+	// Load the structured output schema, if any
+	if *structuredOutput != "" {
+		if config.ChunkSize > 0 {
+			log.Printf("Error: -structured-output cannot be combined with -chunk-size")
+			return ExitUsage
+		}
+		schemaText, err := c.fileStorage.ReadFromFile(*structuredOutput)
+		if err != nil {
+			log.Printf("Error reading structured output schema: %v", err)
+			return ExitError
+		}
+		if config.StructuredOutput, err = domain.ParseOutputSchema(schemaText); err != nil {
+			log.Printf("Error: -structured-output: %v", err)
+			return ExitUsage
+		}
+	}
+
+	// Validate the template against a sample thought and stop before any API call
+	if *validateTemplate {
+		return c.validatePromptTemplate(config.PromptTemplate)
+	}
+
+	if config.InsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification is DISABLED (-insecure-skip-verify). Never use this outside of testing.")
+	}
+	if config.Simulated {
+		log.Printf("SIMULATED MODE: responses are canned and no API calls are made (unset CTT_FAKE_API for real analyses)")
+	}
+
+	// Let the service rebuild its API client from the final configuration
+	if configurable, ok := c.thinkService.(domain.Configurable); ok {
+		if err := configurable.Configure(config); err != nil {
+			log.Printf("Error configuring API client: %v", err)
+			return ExitError
+		}
 	}
 	
+	// Run every check short of an analysis and stop
+	if *validate {
+		ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+		defer cancel()
+		return c.validatePipeline(ctx, config)
+	}
+
+	// Summarize the analyses from here on; it's written once the exit code is known
+	if *summaryJSON != "" {
+		c.summary = &runSummary{path: *summaryJSON, started: time.Now()}
+	}
+
 	// Default thought
 	defaultThought := "I believe we should launch the new feature next week because our testing shows it improves user engagement by 23% and reduces load times by 15%, which addresses our Q2 goals. The only concern is that we haven't completed security testing, but I think we can do that in parallel during a limited rollout."
 	
 	// Determine the thought to analyze
 	var thought string
 	
-	if *inputFile != "" {
+	if len(inputFiles) > 1 {
+		// Several files are read and analyzed one by one as a batch below
+	} else if len(inputFiles) == 1 {
 		// Read thought from file
 		var err error
-		thought, err = c.fileStorage.ReadFromFile(*inputFile)
+		thought, err = c.fileStorage.ReadFromFile(inputFiles[0])
+		if err != nil {
+			log.Printf("Error reading input file: %v", err)
+			return ExitError
+		}
+	} else if *fromClipboard {
+		// Read thought from the clipboard
+		var err error
+		thought, err = c.readClipboard()
+		if err != nil {
+			log.Printf("Error reading clipboard: %v", err)
+			return ExitError
+		}
+	} else if *fromGitDiff {
+		// Review the code changes, unless there are none
+		diff, err := c.readGitDiff(strings.Fields(*gitDiffArgs))
 		if err != nil {
-			log.Fatalf("Error reading input file: %v", err)
+			log.Printf("Error reading git diff: %v", err)
+			return ExitError
+		}
+		if strings.TrimSpace(diff) == "" {
+			fmt.Fprintln(os.Stderr, "No changes to review: git diff is empty")
+			return ExitOK
+		}
+		thought = "```diff\n" + diff + "```"
+		if config.ThoughtPrompt == "" {
+			config.ThoughtPrompt = gitDiffPrompt
 		}
 	} else if flag.NArg() > 0 {
 		// Use first non-flag argument as thought
@@ -113,42 +740,258 @@ func (c *CLI) runWithExit(shouldExit bool) {
 		thought = defaultThought
 	}
 	
+	if *echoThought && thought != "" {
+		fmt.Fprintf(os.Stderr, "Original thought (local only): %s\n", thought)
+	}
+
+	// Lint the thought locally and stop before any API call
+	if *validateOnly {
+		return c.validateThought(thought, *maxThoughtLength)
+	}
+
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
 	defer cancel()
 	
 	// Check API key before proceeding
 	if config.APIKey == "" {
-		config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
-		if config.APIKey == "" {
-			log.Fatalf("Error: API key not found. Set it with -apikey flag or ANTHROPIC_API_KEY environment variable.")
+		if config.NoEnv {
+			if config.RequiresAPIKey() {
+				log.Printf("Error: API key not found. Set it with -apikey flag (-no-env ignores ANTHROPIC_API_KEY).")
+				return ExitError
+			}
+		} else {
+			config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+			if config.APIKey == "" && config.RequiresAPIKey() {
+				log.Printf("Error: API key not found. Set it with -apikey flag or ANTHROPIC_API_KEY environment variable.")
+				return ExitError
+			}
+		}
+	}
+
+	// Serve the JSON line protocol; each request gets its own timeout
+	if *stdinJSON {
+		if err := c.RunStdinJSON(context.Background(), config, os.Stdin, os.Stdout); err != nil {
+			log.Printf("Error in -stdin-json session: %v", err)
+			return ExitError
 		}
+		return ExitOK
+	}
+
+	// Measure latency and token usage over repeated analyses, discarding the content
+	if *measure > 0 {
+		thoughts := []string{thought}
+		if len(inputFiles) > 1 {
+			thoughts = thoughts[:0]
+			for _, input := range inputFiles {
+				content, err := c.fileStorage.ReadFromFile(input)
+				if err != nil {
+					log.Printf("Error reading input file: %v", err)
+					return ExitError
+				}
+				thoughts = append(thoughts, content)
+			}
+		}
+		return c.runMeasure(config, thoughts, *measure)
+	}
+
+	// Analyze each input file separately
+	if len(inputFiles) > 1 {
+		return c.runBatch(config, inputFiles, batchOptions{
+			auditLog:      *auditLog,
+			auditFull:     *auditFull,
+			outputFile:    *outputFile,
+			toClipboard:   *toClipboard,
+			showChunks:    *showChunks,
+			profile:       *profile,
+			failOnRefusal: *failOnRefusal,
+			failOnEmpty:   *failOnEmpty,
+			riskThreshold: riskThreshold,
+			checkpoint:    *checkpointFile,
+			resume:        *resume,
+			delay:         *batchDelay,
+			jitter:        *batchJitter,
+		})
 	}
 
 	// Handle interactive mode
 	if *interactive {
 		c.runInteractiveMode(ctx, config)
-		return
+		return ExitOK
 	}
 	
 	// Process the thought
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "[%s] Analyzing thought with model %s\n", c.formatTime(time.Now()), config.Model)
+	}
 	response, err := c.thinkService.AnalyzeThought(ctx, thought, config)
+	c.recordItem(response, err)
+	if *auditLog != "" {
+		if auditErr := c.writeAuditLog(*auditLog, *auditFull, newRunID(), thought, config, response, err); auditErr != nil {
+			log.Printf("Warning: failed to write audit log: %v", auditErr)
+		}
+	}
 	if err != nil {
-		log.Fatalf("Think tool call error: %v", err)
+		log.Printf("Think tool call error: %v", err)
+		if *outputOnError && *outputFile != "" {
+			c.writeErrorRecord(*outputFile, thought, config, err)
+		}
+		return ExitError
+	}
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "[%s] Analysis completed: %s\n", c.formatTime(time.Now()), FormatSummary(response))
+		if response.ToolInput != "" {
+			fmt.Fprintf(os.Stderr, "[%s] Tool input: %s\n", c.formatTime(time.Now()), response.ToolInput)
+		}
+		if response.RequestID != "" {
+			fmt.Fprintf(os.Stderr, "[%s] Request ID: %s\n", c.formatTime(time.Now()), response.RequestID)
+		}
+		for _, entry := range response.ToolTrace {
+			fmt.Fprintf(os.Stderr, "[%s] Tool trace: %s\n", c.formatTime(time.Now()), FormatToolTraceEntry(entry))
+		}
+		if response.RetryCount > 0 {
+			fmt.Fprintf(os.Stderr, "[%s] Retries: %d (waited %s)\n", c.formatTime(time.Now()), response.RetryCount, response.TotalRetryWait)
+		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintf(os.Stderr, "[%s] Parse warning: %s\n", c.formatTime(time.Now()), warning)
+		}
 	}
 	
-	// Format the output
+	// Diagnose before the chunks are dropped, since they tell a chunked
+	// analysis from an answer that skipped the tool
+	var diagnosis []string
+	if *diagnoseResponse {
+		diagnosis = DiagnoseResponse(response)
+	}
+	if !*showChunks {
+		response.Chunks = nil
+	}
+	timings := response.Timings
+	if !*profile {
+		response.Timings = nil
+	}
+
+	// Print nothing unless the response is an analysis with text to print
+	if *contentOnlyOnSuccess {
+		if response.Refused {
+			log.Printf("Error: Claude refused to analyze this thought (stop_reason: refusal); nothing was printed")
+			return ExitRefusal
+		}
+		if strings.TrimSpace(response.Content) == "" {
+			log.Printf("Error: the response has no text content (stop_reason: %s); nothing was printed", response.StopReason)
+			return ExitEmpty
+		}
+	}
+
+	// Format the output, or extract a single value if a JSON path was given
+	stopFormat := timings.Track("format")
 	output := c.formatter.FormatOutput(response, config.OutputFormat)
+	if *contentOnlyOnSuccess {
+		output = strings.TrimSpace(response.Content)
+	}
+	if *jsonPath != "" {
+		value, err := ExtractJSONPath(response.Raw, *jsonPath)
+		if err == nil {
+			output, err = FormatJSONPathValue(value)
+		}
+		if err != nil {
+			log.Printf("Error extracting -json-path: %v", err)
+			return ExitError
+		}
+	}
+	stopFormat()
+	if *profile {
+		fmt.Fprint(os.Stderr, FormatTimings(timings))
+	}
 	
+	if code := c.emitOutput(output, *outputFile, *toClipboard); code != ExitOK {
+		return code
+	}
+
+	if response.Sentiment != nil && config.OutputFormat == "text" {
+		fmt.Fprintf(os.Stderr, "Sentiment: %s (%.2f)\n", response.Sentiment.Label, response.Sentiment.Score)
+	}
+	if response.ToolDecisionRationale != "" && config.OutputFormat == "text" {
+		fmt.Fprintf(os.Stderr, "Why the think tool wasn't used: %s\n", response.ToolDecisionRationale)
+	}
+
+	if *diagnoseResponse {
+		fmt.Fprintln(os.Stderr, "Diagnosis:")
+		for _, note := range diagnosis {
+			fmt.Fprintf(os.Stderr, "  - %s\n", note)
+		}
+	}
+
+	if response.Refused {
+		log.Printf("Notice: Claude refused to analyze this thought (stop_reason: refusal); the output is not an analysis")
+		if *failOnRefusal {
+			return ExitRefusal
+		}
+	}
+
+	if *failOnEmpty && strings.TrimSpace(response.Content) == "" {
+		log.Printf("Analysis produced no content")
+		return ExitEmpty
+	}
+
+	if *golden != "" {
+		if code := c.checkGolden(*golden, response.Content, *goldenUpdate, *goldenTolerance); code != ExitOK {
+			return code
+		}
+	}
+
+	// Gate on the classified risk level if requested
+	if riskThreshold != domain.RiskUnknown && response.RiskLevel.AtLeast(riskThreshold) {
+		log.Printf("Risk level %s is at or above the -fail-on-risk threshold %s", response.RiskLevel, riskThreshold)
+		return ExitRiskLevel
+	}
+
+	return ExitOK
+}
+
+// emitOutput writes the output to outputFile, or prints it when none is given,
+// and also copies it to the clipboard if requested
+func (c *CLI) emitOutput(output, outputFile string, toClipboard bool) int {
+	if c.normalizeOutput {
+		output = domain.NormalizeNewlines(output)
+	}
+
 	// Write to file or print to console
-	if *outputFile != "" {
-		if err := c.fileStorage.WriteToFile(*outputFile, output); err != nil {
-			log.Fatalf("Error writing output file: %v", err)
+	if outputFile != "" {
+		if err := c.fileStorage.WriteToFile(outputFile, output); err != nil {
+			log.Printf("Error writing output file: %v", err)
+			return ExitError
 		}
-		fmt.Printf("Analysis written to %s\n", *outputFile)
+		fmt.Printf("Analysis written to %s\n", outputFile)
 	} else {
 		fmt.Println(output)
 	}
+
+	if toClipboard {
+		if err := c.writeClipboard(output); err != nil {
+			log.Printf("Error writing clipboard: %v", err)
+			return ExitError
+		}
+		fmt.Fprintln(os.Stderr, "Analysis copied to clipboard")
+	}
+	return ExitOK
+}
+
+// writeErrorRecord writes an error record for a failed analysis of thought to
+// outputFile, so whatever watches the file learns of the failure. The hash is
+// of the thought as sent, with the run's redactions applied.
+func (c *CLI) writeErrorRecord(outputFile, thought string, config domain.Config, runErr error) {
+	sent, err := sentThought(thought, config)
+	if err != nil {
+		log.Printf("Warning: failed to write error record: %v", err)
+		return
+	}
+	record := ErrorRecord{Error: runErr.Error(), ThoughtHash: hashThought(sent), Timestamp: c.formatTime(time.Now())}
+	if err := c.fileStorage.WriteToFile(outputFile, c.formatter.FormatError(record, config.OutputFormat)); err != nil {
+		log.Printf("Warning: failed to write error record: %v", err)
+		return
+	}
+	log.Printf("Error record written to %s", outputFile)
 }
 
 // runInteractiveMode handles interactive CLI mode
@@ -160,14 +1003,20 @@ func (c *CLI) RunInteractiveMode(ctx context.Context, config domain.Config) {
 // runInteractiveMode handles interactive CLI mode
 func (c *CLI) runInteractiveMode(ctx context.Context, config domain.Config) {
 	fmt.Println("Claude Think Tool Interactive Mode")
-	fmt.Println("Type 'exit' or 'quit' to exit")
+	fmt.Println("Type 'exit' or 'quit' to exit, or '/save <path>' to save the last result")
 	fmt.Println("Enter a thought to analyze:")
 	
+	// One scanner for the whole session, so buffered input isn't lost between turns
+	scanner := bufio.NewScanner(os.Stdin)
+	lastOutput := ""
 	for {
 		fmt.Print("> ")
-		scanner := bufio.NewScanner(os.Stdin)
 		if !scanner.Scan() {
-			// Handle EOF
+			// EOF (Ctrl-D) ends the session like "exit", on a line of its own
+			if err := scanner.Err(); err != nil {
+				log.Printf("Error reading input: %v", err)
+			}
+			fmt.Println()
 			break
 		}
 		input := strings.TrimSpace(scanner.Text())
@@ -179,9 +1028,15 @@ func (c *CLI) runInteractiveMode(ctx context.Context, config domain.Config) {
 		if input == "" {
 			continue
 		}
+
+		if input == "/save" || strings.HasPrefix(input, "/save ") {
+			c.saveInteractiveOutput(strings.TrimSpace(strings.TrimPrefix(input, "/save")), lastOutput)
+			continue
+		}
 		
 		// Process the thought
 		response, err := c.thinkService.AnalyzeThought(ctx, input, config)
+		c.recordItem(response, err)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			continue
@@ -190,11 +1045,188 @@ func (c *CLI) runInteractiveMode(ctx context.Context, config domain.Config) {
 		// Format and print the output
 		output := c.formatter.FormatOutput(response, config.OutputFormat)
 		fmt.Println(output)
+		lastOutput = output
+		if response.Refused {
+			fmt.Println("Notice: Claude refused to analyze this thought.")
+		}
+
+		// Save the turn right away so a crash doesn't lose the session
+		if c.transcript != "" {
+			if err := c.appendTranscript(input, output); err != nil {
+				log.Printf("Warning: failed to save transcript: %v", err)
+			}
+		}
 	}
 	
 	fmt.Println("Goodbye!")
 }
 
+// saveInteractiveOutput handles the interactive /save command, writing the
+// most recent formatted result to path
+func (c *CLI) saveInteractiveOutput(path, output string) {
+	if path == "" {
+		fmt.Println("Usage: /save <path>")
+		return
+	}
+	if output == "" {
+		fmt.Println("Nothing to save yet; analyze a thought first.")
+		return
+	}
+	if err := c.fileStorage.WriteToFile(path, output); err != nil {
+		fmt.Printf("Error: failed to save %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("Saved last result to %s\n", path)
+}
+
+// appendTranscript appends one interactive turn to the transcript file
+func (c *CLI) appendTranscript(thought, output string) error {
+	entry := fmt.Sprintf("## %s\n\n> %s\n\n%s\n\n", c.formatTime(time.Now()), thought, strings.TrimSpace(output))
+	return c.fileStorage.AppendToFile(c.transcript, entry)
+}
+
+// validatePromptTemplate renders the template with a sample thought, printing
+// the result or the parse/execution error
+func (c *CLI) validatePromptTemplate(templateText string) int {
+	if templateText == "" {
+		log.Printf("Error: -validate-template requires -prompt-template")
+		return ExitUsage
+	}
+
+	rendered, err := domain.RenderPromptTemplate(templateText, domain.PromptData{Thought: "sample"})
+	if err != nil {
+		log.Printf("Invalid prompt template: %v", err)
+		return ExitUsage
+	}
+
+	fmt.Println(rendered)
+	return ExitOK
+}
+
+// readClipboard reads the clipboard, failing when no clipboard was configured
+func (c *CLI) readClipboard() (string, error) {
+	if c.clipboard == nil {
+		return "", domain.ErrClipboardUnavailable
+	}
+	return c.clipboard.ReadClipboard()
+}
+
+// readStdin reads all of stdin
+func readStdin() (string, error) {
+	data, err := io.ReadAll(os.Stdin)
+	return string(data), err
+}
+
+// readGitDiff reads the code changes, failing when no diff source was configured
+func (c *CLI) readGitDiff(args []string) (string, error) {
+	if c.diffSource == nil {
+		return "", errors.New("no git diff source configured")
+	}
+	return c.diffSource.ReadDiff(args)
+}
+
+// writeClipboard writes the clipboard, failing when no clipboard was configured
+func (c *CLI) writeClipboard(text string) error {
+	if c.clipboard == nil {
+		return domain.ErrClipboardUnavailable
+	}
+	return c.clipboard.WriteClipboard(text)
+}
+
+// validateThought runs the local thought checks, printing PASS or FAIL with
+// the reasons
+func (c *CLI) validateThought(thought string, maxLength int) int {
+	problems := domain.ValidateThought(thought, maxLength)
+	if len(problems) == 0 {
+		fmt.Println("PASS")
+		return ExitOK
+	}
+
+	fmt.Println("FAIL")
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	return ExitError
+}
+
+// formatTime formats a timestamp using the configured -time-format
+func (c *CLI) formatTime(t time.Time) string {
+	return FormatTime(t, c.timeFormat)
+}
+
+// headerFlag collects repeatable -header "Name: value" flags
+type headerFlag map[string]string
+
+// String implements flag.Value
+func (h headerFlag) String() string {
+	pairs := make([]string, 0, len(h))
+	for name, value := range h {
+		pairs = append(pairs, name+": "+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// Set implements flag.Value, parsing one "Name: value" header
+func (h headerFlag) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid header %q (expected \"Name: value\")", value)
+	}
+	h[name] = strings.TrimSpace(headerValue)
+	return nil
+}
+
+// stringsFlag collects the values of a repeatable string flag
+type stringsFlag []string
+
+// String implements flag.Value
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+// Set implements flag.Value, appending one value
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// parseStatusCodes parses a comma-separated list of HTTP status codes
+func parseStatusCodes(value string) ([]int, error) {
+	var statuses []int
+	for _, item := range splitList(value) {
+		status, err := strconv.Atoi(item)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid HTTP status code %q", item)
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("no status codes given")
+	}
+	return statuses, nil
+}
+
+// forceToolName returns the tool that -force-tool makes Claude call
+func forceToolName(force bool) string {
+	if force {
+		return "think"
+	}
+	return ""
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // printVersion prints the version information
 func (c *CLI) printVersion() {
 	fmt.Printf("Claude Think Tool v%s\n", Version)
@@ -3,12 +3,22 @@ package interfacelayer_test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
 	interfacelayer "claude-think-tool/internal/interface"
 	"claude-think-tool/test/unit"
 )
@@ -231,4 +241,1748 @@ func TestCLI_ParseFlags(t *testing.T) {
 			os.Stdout = oldStdout
 		})
 	}
-}
\ No newline at end of file
+}
+
+// runCLI runs the CLI with the given arguments in test mode and returns the
+// exit code along with everything written to stdout
+func runCLI(t *testing.T, args []string, service domain.ThinkService, storage domain.FileStorage) (int, string) {
+	t.Helper()
+	return runCLIWithClipboard(t, args, service, storage, nil)
+}
+
+// runCLIWithClipboard is runCLI with a clipboard configured on the CLI
+func runCLIWithClipboard(t *testing.T, args []string, service domain.ThinkService, storage domain.FileStorage, clipboard domain.Clipboard) (int, string) {
+	t.Helper()
+	return runCLIWithSetup(t, args, service, storage, func(cli *interfacelayer.CLI) {
+		if clipboard != nil {
+			cli.SetClipboard(clipboard)
+		}
+	})
+}
+
+// runCLIWithSetup is runCLI with setup applied to the CLI before it runs
+func runCLIWithSetup(t *testing.T, args []string, service domain.ThinkService, storage domain.FileStorage, setup func(cli *interfacelayer.CLI)) (int, string) {
+	t.Helper()
+
+	oldArgs := os.Args
+	oldStdout := os.Stdout
+	defer func() {
+		os.Args = oldArgs
+		os.Stdout = oldStdout
+		flag.CommandLine = flag.NewFlagSet(oldArgs[0], flag.ExitOnError)
+	}()
+
+	flag.CommandLine = flag.NewFlagSet(args[0], flag.ContinueOnError)
+	os.Args = args
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create stdout pipe: %v", err)
+	}
+	os.Stdout = w
+
+	outputCh := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		outputCh <- buf.String()
+	}()
+
+	if storage == nil {
+		storage = &unit.MockFileStorage{
+			ReadFromFileFunc: func(filePath string) (string, error) { return "", nil },
+			WriteToFileFunc:  func(filePath string, content string) error { return nil },
+			AppendToFileFunc: func(filePath string, content string) error { return nil },
+		}
+	}
+
+	cli := interfacelayer.NewCLI(service, storage, interfacelayer.NewFormatter())
+	setup(cli)
+	code := cli.TestRun()
+
+	w.Close()
+	return code, <-outputCh
+}
+
+// staticService returns a mock service that always responds with the given response
+func staticService(response *domain.ThinkResponse) *unit.MockThinkService {
+	return &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			return response, nil
+		},
+	}
+}
+
+func TestCLI_FailOnRisk(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold string
+		level     domain.RiskLevel
+		wantCode  int
+	}{
+		{name: "no threshold", threshold: "", level: domain.RiskHigh, wantCode: interfacelayer.ExitOK},
+		{name: "below threshold", threshold: "high", level: domain.RiskMedium, wantCode: interfacelayer.ExitOK},
+		{name: "at threshold", threshold: "high", level: domain.RiskHigh, wantCode: interfacelayer.ExitRiskLevel},
+		{name: "above threshold", threshold: "medium", level: domain.RiskHigh, wantCode: interfacelayer.ExitRiskLevel},
+		{name: "unknown level never fails", threshold: "low", level: domain.RiskUnknown, wantCode: interfacelayer.ExitOK},
+		{name: "invalid threshold", threshold: "extreme", level: domain.RiskLow, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"program", "-apikey=test-key"}
+			if tt.threshold != "" {
+				args = append(args, "-fail-on-risk="+tt.threshold)
+			}
+			args = append(args, "Some thought")
+
+			service := staticService(&domain.ThinkResponse{
+				Raw:       map[string]interface{}{"id": "msg_123"},
+				Content:   "Analysis",
+				RiskLevel: tt.level,
+			})
+
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCLI_JSONPath(t *testing.T) {
+	service := staticService(&domain.ThinkResponse{
+		Raw: map[string]interface{}{
+			"id": "msg_123",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "Extracted text"},
+			},
+		},
+		Content: "Extracted text",
+	})
+
+	code, output := runCLI(t, []string{"program", "-apikey=test-key", "-json-path=content.0.text", "Some thought"}, service, nil)
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+	if strings.TrimSpace(output) != "Extracted text" {
+		t.Errorf("Expected only the extracted value, got %q", output)
+	}
+
+	code, _ = runCLI(t, []string{"program", "-apikey=test-key", "-json-path=content.3.text", "Some thought"}, service, nil)
+	if code != interfacelayer.ExitError {
+		t.Errorf("Exit code for missing path = %d, want %d", code, interfacelayer.ExitError)
+	}
+}
+
+func TestCLI_FailOnRefusal(t *testing.T) {
+	refusal := &domain.ThinkResponse{
+		Raw:     map[string]interface{}{"stop_reason": "refusal"},
+		Content: "I can't help with that.",
+		Refused: true,
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		response *domain.ThinkResponse
+		wantCode int
+	}{
+		{
+			name:     "refusal without flag",
+			args:     []string{"program", "-apikey=test-key", "Some thought"},
+			response: refusal,
+			wantCode: interfacelayer.ExitOK,
+		},
+		{
+			name:     "refusal with flag",
+			args:     []string{"program", "-apikey=test-key", "-fail-on-refusal", "Some thought"},
+			response: refusal,
+			wantCode: interfacelayer.ExitRefusal,
+		},
+		{
+			name:     "answer with flag",
+			args:     []string{"program", "-apikey=test-key", "-fail-on-refusal", "Some thought"},
+			response: &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"},
+			wantCode: interfacelayer.ExitOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := runCLI(t, tt.args, staticService(tt.response), nil)
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCLI_InvalidTimeFormat(t *testing.T) {
+	service := staticService(&domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"})
+
+	code, _ := runCLI(t, []string{"program", "-apikey=test-key", "-time-format=nonsense", "Some thought"}, service, nil)
+	if code != interfacelayer.ExitUsage {
+		t.Errorf("Exit code = %d, want %d", code, interfacelayer.ExitUsage)
+	}
+}
+
+func TestCLI_ValidateTemplate(t *testing.T) {
+	templates := map[string]string{
+		"good.tmpl":   "Critically evaluate: {{.Thought}}",
+		"broken.tmpl": "Critically evaluate: {{.Thought",
+		"typo.tmpl":   "Critically evaluate: {{.Thougth}}",
+	}
+	storage := &unit.MockFileStorage{
+		ReadFromFileFunc: func(filePath string) (string, error) {
+			if content, ok := templates[filePath]; ok {
+				return content, nil
+			}
+			return "", errors.New("not found")
+		},
+	}
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			t.Errorf("Expected no analysis in -validate-template mode")
+			return nil, errors.New("unexpected call")
+		},
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantOutput string
+	}{
+		{
+			name:       "valid template prints rendered prompt",
+			args:       []string{"program", "-validate-template", "-prompt-template=good.tmpl"},
+			wantCode:   interfacelayer.ExitOK,
+			wantOutput: "Critically evaluate: sample",
+		},
+		{
+			name:     "parse error",
+			args:     []string{"program", "-validate-template", "-prompt-template=broken.tmpl"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "execution error",
+			args:     []string{"program", "-validate-template", "-prompt-template=typo.tmpl"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "missing template flag",
+			args:     []string{"program", "-validate-template"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, output := runCLI(t, tt.args, service, storage)
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantOutput != "" && strings.TrimSpace(output) != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestCLI_ModelAliases(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantCode     int
+		wantModel    string
+		wantFallback []string
+	}{
+		{
+			name:      "built-in alias",
+			args:      []string{"program", "-apikey=test-key", "-model=opus", "Some thought"},
+			wantCode:  interfacelayer.ExitOK,
+			wantModel: domain.DefaultModelAliases["opus"],
+		},
+		{
+			name:         "aliases in fallback list and overrides",
+			args:         []string{"program", "-apikey=test-key", "-model=fast", "-model-aliases=fast=claude-fast-1", "-model-fallback=haiku,claude-x-1", "Some thought"},
+			wantCode:     interfacelayer.ExitOK,
+			wantModel:    "claude-fast-1",
+			wantFallback: []string{domain.DefaultModelAliases["haiku"], "claude-x-1"},
+		},
+		{
+			name:      "unknown alias passes through",
+			args:      []string{"program", "-apikey=test-key", "-model=llama", "Some thought"},
+			wantCode:  interfacelayer.ExitOK,
+			wantModel: "llama",
+		},
+		{
+			name:     "unknown alias rejected when strict",
+			args:     []string{"program", "-apikey=test-key", "-model=llama", "-strict-model-aliases", "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Config
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			code, _ := runCLI(t, tt.args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantCode != interfacelayer.ExitOK {
+				return
+			}
+			if got.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", got.Model, tt.wantModel)
+			}
+			if strings.Join(got.ModelFallback, ",") != strings.Join(tt.wantFallback, ",") {
+				t.Errorf("ModelFallback = %v, want %v", got.ModelFallback, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestCLI_FailOnEmpty(t *testing.T) {
+	empty := &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: " \n\t"}
+
+	tests := []struct {
+		name     string
+		args     []string
+		response *domain.ThinkResponse
+		wantCode int
+	}{
+		{
+			name:     "empty without flag",
+			args:     []string{"program", "-apikey=test-key", "Some thought"},
+			response: empty,
+			wantCode: interfacelayer.ExitOK,
+		},
+		{
+			name:     "empty with flag",
+			args:     []string{"program", "-apikey=test-key", "-fail-on-empty", "Some thought"},
+			response: empty,
+			wantCode: interfacelayer.ExitEmpty,
+		},
+		{
+			name:     "content with flag",
+			args:     []string{"program", "-apikey=test-key", "-fail-on-empty", "Some thought"},
+			response: &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"},
+			wantCode: interfacelayer.ExitOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := runCLI(t, tt.args, staticService(tt.response), nil)
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCLI_ContentOnlyOnSuccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		response   *domain.ThinkResponse
+		err        error
+		wantCode   int
+		wantOutput string
+	}{
+		{
+			name:       "content only",
+			response:   &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis\nRisk level: LOW\n", Chunks: []domain.ChunkResult{{Index: 1, Content: "Part"}}},
+			args:       []string{"-show-chunks"},
+			wantCode:   interfacelayer.ExitOK,
+			wantOutput: "Analysis\nRisk level: LOW\n",
+		},
+		{
+			name:     "no text content",
+			response: &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: " \n", StopReason: "end_turn"},
+			wantCode: interfacelayer.ExitEmpty,
+		},
+		{
+			name:     "refusal",
+			response: &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "I can't help with that.", Refused: true},
+			wantCode: interfacelayer.ExitRefusal,
+		},
+		{
+			name:     "analysis error",
+			err:      errors.New("request failed"),
+			wantCode: interfacelayer.ExitError,
+		},
+		{
+			name:     "not text format",
+			args:     []string{"-format=json"},
+			response: &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					return tt.response, tt.err
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key", "-content-only-on-success"}, tt.args...), "Some thought")
+			code, output := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if output != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestCLI_ForceTool(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantCode  int
+		wantForce string
+	}{
+		{name: "not forced", args: nil, wantCode: interfacelayer.ExitOK},
+		{name: "forced", args: []string{"-force-tool"}, wantCode: interfacelayer.ExitOK, wantForce: "think"},
+		{name: "with -explain-no-tool", args: []string{"-force-tool", "-explain-no-tool"}, wantCode: interfacelayer.ExitUsage},
+		{name: "with extended thinking", args: []string{"-force-tool", `-request-extra={"thinking":{"type":"enabled","budget_tokens":2048}}`}, wantCode: interfacelayer.ExitUsage},
+		{name: "with another tool_choice", args: []string{"-force-tool", `-request-extra={"tool_choice":{"type":"any"}}`}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config.ForceTool
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if got != tt.wantForce {
+				t.Errorf("ForceTool = %q, want %q", got, tt.wantForce)
+			}
+		})
+	}
+}
+
+func TestCLI_RetryOnStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		wantCode   int
+		wantStatus []int
+	}{
+		{name: "valid list", value: "429, 503,529", wantCode: interfacelayer.ExitOK, wantStatus: []int{429, 503, 529}},
+		{name: "not a number", value: "429,busy", wantCode: interfacelayer.ExitUsage},
+		{name: "out of range", value: "429,999", wantCode: interfacelayer.ExitUsage},
+		{name: "empty list", value: ",", wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotStatus []int
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotStatus = config.RetryOnStatus
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			code, _ := runCLI(t, []string{"program", "-apikey=test-key", "-retry-on-status=" + tt.value, "Some thought"}, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantCode == interfacelayer.ExitOK && !reflect.DeepEqual(gotStatus, tt.wantStatus) {
+				t.Errorf("RetryOnStatus = %v, want %v", gotStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCLI_Provider(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantCode     int
+		wantProvider string
+		wantRegion   string
+	}{
+		{name: "anthropic by default", wantCode: interfacelayer.ExitOK, wantProvider: domain.ProviderAnthropic, wantRegion: domain.DefaultVertexRegion},
+		{
+			name:         "vertex with project",
+			args:         []string{"-provider=vertex", "-vertex-project=my-project", "-vertex-region=europe-west1"},
+			wantCode:     interfacelayer.ExitOK,
+			wantProvider: domain.ProviderVertex,
+			wantRegion:   "europe-west1",
+		},
+		{name: "vertex without project", args: []string{"-provider=vertex"}, wantCode: interfacelayer.ExitUsage},
+		{name: "unknown provider", args: []string{"-provider=bedrock"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Config
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantCode != interfacelayer.ExitOK {
+				return
+			}
+			if got.Provider != tt.wantProvider || got.VertexRegion != tt.wantRegion {
+				t.Errorf("Provider = %q in %q, want %q in %q", got.Provider, got.VertexRegion, tt.wantProvider, tt.wantRegion)
+			}
+		})
+	}
+}
+
+func TestCLI_OrgIDAndHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantCode    int
+		wantOrgID   string
+		wantHeaders map[string]string
+	}{
+		{
+			name:        "org id and repeated headers",
+			args:        []string{"-org-id=org_123", "-header", "X-Tenant: acme", "-header", "X-Route:eu-1"},
+			wantCode:    interfacelayer.ExitOK,
+			wantOrgID:   "org_123",
+			wantHeaders: map[string]string{"X-Tenant": "acme", "X-Route": "eu-1"},
+		},
+		{name: "empty org id", args: []string{"-org-id= "}, wantCode: interfacelayer.ExitUsage},
+		{name: "header without colon", args: []string{"-header", "X-Tenant acme"}, wantCode: interfacelayer.ExitUsage},
+		{name: "header without name", args: []string{"-header", ": acme"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Config
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantCode != interfacelayer.ExitOK {
+				return
+			}
+			if got.OrgID != tt.wantOrgID {
+				t.Errorf("OrgID = %q, want %q", got.OrgID, tt.wantOrgID)
+			}
+			if !reflect.DeepEqual(got.Headers, tt.wantHeaders) {
+				t.Errorf("Headers = %v, want %v", got.Headers, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestCLI_ConflictingFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "input and clipboard", args: []string{"-input=a.txt", "-clipboard"}, wantError: "cannot use -input and -clipboard together"},
+		{name: "interactive and input", args: []string{"-interactive", "-input=a.txt"}, wantError: "cannot use -interactive and -input together"},
+		{name: "interactive and clipboard", args: []string{"-interactive", "-clipboard"}, wantError: "cannot use -interactive and -clipboard together"},
+		{name: "interactive and stdin-json", args: []string{"-interactive", "-stdin-json"}, wantError: "cannot use -interactive and -stdin-json together"},
+		{name: "stdin-json and input", args: []string{"-stdin-json", "-input=a.txt"}, wantError: "cannot use -stdin-json and -input together"},
+		{name: "stdin-json and clipboard", args: []string{"-stdin-json", "-clipboard"}, wantError: "cannot use -stdin-json and -clipboard together"},
+		{name: "validate-only and interactive", args: []string{"-validate-only", "-interactive"}, wantError: "cannot use -validate-only and -interactive together"},
+		{name: "validate-only and stdin-json", args: []string{"-validate-only", "-stdin-json"}, wantError: "cannot use -validate-only and -stdin-json together"},
+		{name: "input and thought argument", args: []string{"-input=a.txt", "A thought"}, wantError: "cannot use -input together with a thought argument"},
+		{name: "clipboard and thought argument", args: []string{"-clipboard", "A thought"}, wantError: "cannot use -clipboard together with a thought argument"},
+		{name: "interactive and thought argument", args: []string{"-interactive", "A thought"}, wantError: "cannot use -interactive together with a thought argument"},
+		{name: "stdin-json and thought argument", args: []string{"-stdin-json", "A thought"}, wantError: "cannot use -stdin-json together with a thought argument"},
+		{name: "golden-update without golden", args: []string{"-golden-update", "A thought"}, wantError: "-golden-update requires -golden"},
+		{name: "golden-tolerance without golden", args: []string{"-golden-tolerance=0.1", "A thought"}, wantError: "-golden-tolerance requires -golden"},
+		{name: "transcript without interactive", args: []string{"-transcript=session.md", "A thought"}, wantError: "-transcript requires -interactive"},
+		{name: "duplicate flag", args: []string{"-model", "haiku", "-model=opus", "A thought"}, wantError: "-model given more than once"},
+		{name: "duplicate bool flag", args: []string{"-verbose", "--verbose", "A thought"}, wantError: "-verbose given more than once"},
+		{name: "golden with several inputs", args: []string{"-input=a.txt", "-input=b.txt", "-golden=expected.txt"}, wantError: "cannot use -golden with several -input files"},
+		{name: "json-path with several inputs", args: []string{"-input=a.txt", "-input=b.txt", "-json-path=id"}, wantError: "cannot use -json-path with several -input files"},
+		{name: "repeatable flags", args: []string{"-header", "X-A: 1", "-header", "X-B: 2", "-redact-pattern=a", "-redact-pattern=b", "A thought"}},
+		{name: "flag-like thought after the first argument", args: []string{"-model=haiku", "A thought", "-model=opus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+			code, _ := runCLI(t, append([]string{"program", "-apikey=test-key"}, tt.args...), service, nil)
+
+			if tt.wantError == "" {
+				if code != interfacelayer.ExitOK {
+					t.Errorf("Exit code = %d, want %d (log: %s)", code, interfacelayer.ExitOK, logs.String())
+				}
+				return
+			}
+			if code != interfacelayer.ExitUsage {
+				t.Errorf("Exit code = %d, want %d", code, interfacelayer.ExitUsage)
+			}
+			if !strings.Contains(logs.String(), tt.wantError) {
+				t.Errorf("Expected error %q, got log: %s", tt.wantError, logs.String())
+			}
+		})
+	}
+}
+
+func TestCLI_Golden(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		golden      string
+		wantCode    int
+		wantWritten string
+	}{
+		{name: "identical", golden: "Risk level: LOW\n", wantCode: interfacelayer.ExitOK},
+		{name: "drift beyond tolerance", golden: "Risk level: HIGH", wantCode: interfacelayer.ExitGoldenMismatch},
+		{name: "drift within tolerance", args: []string{"-golden-tolerance=0.3"}, golden: "Risk level: HIGH", wantCode: interfacelayer.ExitOK},
+		{name: "update writes the golden file", args: []string{"-golden-update"}, wantCode: interfacelayer.ExitOK, wantWritten: "Risk level: LOW\n"},
+		{name: "missing golden file", wantCode: interfacelayer.ExitError},
+		{name: "tolerance out of range", args: []string{"-golden-tolerance=2"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written string
+			storage := &unit.MockFileStorage{
+				ReadFromFileFunc: func(filePath string) (string, error) {
+					if tt.golden == "" {
+						return "", errors.New("not found")
+					}
+					return tt.golden, nil
+				},
+				WriteToFileFunc: func(filePath string, content string) error {
+					written = content
+					return nil
+				},
+			}
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Risk level: LOW\n"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key", "-golden=expected.txt"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, storage)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("Written golden file = %q, want %q", written, tt.wantWritten)
+			}
+		})
+	}
+}
+
+func TestCLI_MultipleInputs(t *testing.T) {
+	files := map[string]string{
+		"a.txt": "First thought",
+		"b.txt": "Second thought",
+	}
+	storage := &unit.MockFileStorage{
+		ReadFromFileFunc: func(filePath string) (string, error) {
+			if content, ok := files[filePath]; ok {
+				return content, nil
+			}
+			return "", errors.New("no such file")
+		},
+	}
+	newService := func(analyzed *[]string) *unit.MockThinkService {
+		return &unit.MockThinkService{
+			AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+				*analyzed = append(*analyzed, thought)
+				return &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg"}, Content: "Analysis of " + thought}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantCode     int
+		wantAnalyzed []string
+		wantOutput   string
+	}{
+		{
+			name:         "single input is unchanged",
+			args:         []string{"-input=a.txt"},
+			wantCode:     interfacelayer.ExitOK,
+			wantAnalyzed: []string{"First thought"},
+			wantOutput:   "Analysis of First thought\n",
+		},
+		{
+			name:         "several inputs are analyzed separately",
+			args:         []string{"-input=a.txt", "-input", "b.txt"},
+			wantCode:     interfacelayer.ExitOK,
+			wantAnalyzed: []string{"First thought", "Second thought"},
+			wantOutput:   "=== a.txt ===\nAnalysis of First thought\n\n=== b.txt ===\nAnalysis of Second thought\n",
+		},
+		{
+			name:         "a missing file fails the batch but not the other inputs",
+			args:         []string{"-input=missing.txt", "-input=b.txt"},
+			wantCode:     interfacelayer.ExitError,
+			wantAnalyzed: []string{"Second thought"},
+			wantOutput:   "=== missing.txt ===\nError: reading input file: no such file\n\n=== b.txt ===\nAnalysis of Second thought\n",
+		},
+		{
+			name:         "ndjson prints one object per input and line",
+			args:         []string{"-format=ndjson", "-input=missing.txt", "-input=b.txt"},
+			wantCode:     interfacelayer.ExitError,
+			wantAnalyzed: []string{"Second thought"},
+			wantOutput:   "{\"error\":\"reading input file: no such file\",\"index\":0,\"input\":\"missing.txt\",\"latency_ms\":0}\n{\"index\":1,\"input\":\"b.txt\",\"latency_ms\":0,\"result\":{\"id\":\"msg\"},\"thought\":\"Second thought\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var analyzed []string
+			code, output := runCLI(t, append([]string{"program", "-apikey=test-key"}, tt.args...), newService(&analyzed), storage)
+
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !reflect.DeepEqual(analyzed, tt.wantAnalyzed) {
+				t.Errorf("Analyzed thoughts = %q, want %q", analyzed, tt.wantAnalyzed)
+			}
+			if output != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+
+	t.Run("JSON output is an array with one entry per input", func(t *testing.T) {
+		var analyzed []string
+		code, output := runCLI(t, []string{"program", "-apikey=test-key", "-format=json", "-input=a.txt", "-input=b.txt"}, newService(&analyzed), storage)
+		if code != interfacelayer.ExitOK {
+			t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+		}
+
+		var results []map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &results); err != nil {
+			t.Fatalf("Expected a JSON array, got error: %v\n%s", err, output)
+		}
+		if len(results) != 2 || results[0]["input"] != "a.txt" || results[1]["input"] != "b.txt" {
+			t.Fatalf("Expected results for a.txt and b.txt, got %v", results)
+		}
+		if result, ok := results[1]["result"].(map[string]interface{}); !ok || result["id"] != "msg" {
+			t.Errorf("Expected the JSON payload as result, got %v", results[1]["result"])
+		}
+	})
+}
+
+func TestCLI_BatchTimeoutDirective(t *testing.T) {
+	files := map[string]string{
+		"long.txt":    "timeout=60s | A long thought",
+		"default.txt": "A short thought",
+		"invalid.txt": "timeout=soon | Another thought",
+	}
+	storage := &unit.MockFileStorage{
+		ReadFromFileFunc: func(filePath string) (string, error) {
+			return files[filePath], nil
+		},
+	}
+	deadlines := map[string]time.Duration{}
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			deadline, _ := ctx.Deadline()
+			deadlines[thought] = time.Until(deadline)
+			return &domain.ThinkResponse{Content: "ok"}, nil
+		},
+	}
+
+	code, output := runCLI(t, []string{"program", "-apikey=test-key", "-timeout=5s", "-input=long.txt", "-input=default.txt", "-input=invalid.txt"}, service, storage)
+
+	if code != interfacelayer.ExitError {
+		t.Errorf("Exit code = %d, want %d for the invalid directive", code, interfacelayer.ExitError)
+	}
+	if len(deadlines) != 2 {
+		t.Fatalf("Expected 2 analyzed thoughts without directives, got %v", deadlines)
+	}
+	if d, ok := deadlines["A long thought"]; !ok || d <= 55*time.Second || d > 60*time.Second {
+		t.Errorf("Expected a 60s deadline for the directive, got %v", deadlines)
+	}
+	if d, ok := deadlines["A short thought"]; !ok || d <= 0 || d > 5*time.Second {
+		t.Errorf("Expected the global 5s deadline, got %v", deadlines)
+	}
+	if !strings.Contains(output, `Error: invalid timeout directive "soon"`) {
+		t.Errorf("Expected an error for the invalid directive, got:\n%s", output)
+	}
+}
+
+func TestCLI_BatchDelay(t *testing.T) {
+	tempDir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"first", "second", "third"} {
+		path := filepath.Join(tempDir, name+".txt")
+		os.WriteFile(path, []byte(name+" thought"), 0644)
+		inputs = append(inputs, "-input="+path)
+	}
+
+	const delay = 100 * time.Millisecond
+	var calls []time.Time
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			calls = append(calls, time.Now())
+			return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis of " + thought}, nil
+		},
+	}
+
+	args := append([]string{"program", "-apikey=test-key", "-batch-delay=100ms", "-batch-jitter=20ms"}, inputs...)
+	code, _ := runCLI(t, args, service, infra.NewFileStorage())
+	finished := time.Now()
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("Analyzed %d inputs, want 3", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		if gap := calls[i].Sub(calls[i-1]); gap < delay {
+			t.Errorf("Gap before input %d = %s, want at least %s", i+1, gap, delay)
+		}
+	}
+	if tail := finished.Sub(calls[2]); tail >= delay {
+		t.Errorf("Run ended %s after the last analysis, want no pause after it", tail)
+	}
+
+	t.Run("single input", func(t *testing.T) {
+		code, _ := runCLI(t, []string{"program", "-apikey=test-key", "-batch-delay=1s", inputs[0]}, service, infra.NewFileStorage())
+		if code != interfacelayer.ExitUsage {
+			t.Errorf("Exit code = %d, want %d", code, interfacelayer.ExitUsage)
+		}
+	})
+}
+
+func TestCLI_BatchCheckpointResume(t *testing.T) {
+	tempDir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"first", "second", "third"} {
+		path := filepath.Join(tempDir, name+".txt")
+		os.WriteFile(path, []byte(name+" thought"), 0644)
+		inputs = append(inputs, "-input="+path)
+	}
+	checkpoint := filepath.Join(tempDir, "batch.checkpoint")
+
+	var analyzed []string
+	failSecond := true
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			analyzed = append(analyzed, thought)
+			if thought == "second thought" && failSecond {
+				return nil, errors.New("rate limited")
+			}
+			return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis of " + thought, RiskLevel: domain.RiskLow}, nil
+		},
+	}
+
+	// The first run fails on the second input and records the others
+	args := append([]string{"program", "-apikey=test-key", "-checkpoint=" + checkpoint}, inputs...)
+	code, _ := runCLI(t, args, service, infra.NewFileStorage())
+	if code != interfacelayer.ExitError {
+		t.Fatalf("Exit code = %d, want %d for the failed input", code, interfacelayer.ExitError)
+	}
+	data, _ := os.ReadFile(checkpoint)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("Checkpoint has %d lines, want 2 completed inputs:\n%s", lines, data)
+	}
+
+	// The resumed run analyzes only the failed input and reports all three
+	analyzed, failSecond = nil, false
+	code, output := runCLI(t, append(args, "-resume"), service, infra.NewFileStorage())
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+	if len(analyzed) != 1 || analyzed[0] != "second thought" {
+		t.Errorf("Resumed run analyzed %q, want only the second thought", analyzed)
+	}
+	for _, name := range []string{"first", "second", "third"} {
+		if !strings.Contains(output, "Analysis of "+name+" thought") {
+			t.Errorf("Expected the analysis of the %s thought in the output, got:\n%s", name, output)
+		}
+	}
+	data, _ = os.ReadFile(checkpoint)
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("Checkpoint has %d lines after resuming, want 3", lines)
+	}
+}
+
+func TestCLI_SummaryJSON(t *testing.T) {
+	written := map[string]string{}
+	storage := &unit.MockFileStorage{
+		ReadFromFileFunc: func(filePath string) (string, error) {
+			if filePath == "missing.txt" {
+				return "", errors.New("no such file")
+			}
+			return "Thought in " + filePath, nil
+		},
+		WriteToFileFunc: func(filePath string, content string) error {
+			written[filePath] = content
+			return nil
+		},
+	}
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			return &domain.ThinkResponse{
+				Raw:     map[string]interface{}{},
+				Content: "Analysis",
+				Usage:   domain.Usage{InputTokens: 100, OutputTokens: 40},
+			}, nil
+		},
+	}
+
+	args := []string{"program", "-apikey=test-key", "-summary-json=summary.json", "-input=a.txt", "-input=b.txt", "-input=missing.txt"}
+	code, _ := runCLI(t, args, service, storage)
+	if code != interfacelayer.ExitError {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitError)
+	}
+
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(written["summary.json"]), &summary); err != nil {
+		t.Fatalf("Expected a JSON summary, got error: %v (%q)", err, written["summary.json"])
+	}
+	expected := map[string]interface{}{
+		"status":    "failed",
+		"exit_code": float64(interfacelayer.ExitError),
+		"items":     float64(3),
+		"failures":  float64(1),
+		"tokens":    map[string]interface{}{"input_tokens": float64(200), "output_tokens": float64(80)},
+	}
+	for field, want := range expected {
+		if !reflect.DeepEqual(summary[field], want) {
+			t.Errorf("summary[%q] = %v, want %v", field, summary[field], want)
+		}
+	}
+	if _, ok := summary["duration_ms"].(float64); !ok {
+		t.Errorf("Expected a numeric duration_ms, got %v", summary["duration_ms"])
+	}
+
+	// A single analysis to stdout, after the analysis itself
+	code, output := runCLI(t, []string{"program", "-apikey=test-key", "-summary-json=-", "Some thought"}, service, storage)
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil || summary["status"] != "ok" || summary["items"] != float64(1) {
+		t.Errorf("Expected an ok summary of one item as the last line, got %q", output)
+	}
+}
+
+func TestCLI_ValidateOnly(t *testing.T) {
+	inputs := map[string]string{
+		"empty.txt":  "  \n",
+		"latin1.txt": "caf\xe9 au lait",
+		"long.txt":   strings.Repeat("word ", 30),
+	}
+	storage := &unit.MockFileStorage{
+		ReadFromFileFunc: func(filePath string) (string, error) {
+			if content, ok := inputs[filePath]; ok {
+				return content, nil
+			}
+			return "", errors.New("not found")
+		},
+	}
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			t.Errorf("Expected no analysis in -validate-only mode")
+			return nil, errors.New("unexpected call")
+		},
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantOutput string
+	}{
+		{
+			name:       "valid thought passes without an API key",
+			args:       []string{"program", "-validate-only", "We should launch next week"},
+			wantCode:   interfacelayer.ExitOK,
+			wantOutput: "PASS",
+		},
+		{
+			name:       "empty",
+			args:       []string{"program", "-validate-only", "-input=empty.txt"},
+			wantCode:   interfacelayer.ExitError,
+			wantOutput: "thought is empty",
+		},
+		{
+			name:       "non-UTF-8",
+			args:       []string{"program", "-validate-only", "-input=latin1.txt"},
+			wantCode:   interfacelayer.ExitError,
+			wantOutput: "not valid UTF-8",
+		},
+		{
+			name:       "over length",
+			args:       []string{"program", "-validate-only", "-max-thought-length=100", "-input=long.txt"},
+			wantCode:   interfacelayer.ExitError,
+			wantOutput: "150 characters long (limit 100)",
+		},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, output := runCLI(t, tt.args, service, storage)
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !strings.Contains(output, tt.wantOutput) {
+				t.Errorf("Expected output to contain %q, got %q", tt.wantOutput, output)
+			}
+		})
+	}
+}
+
+func TestCLI_RequestExtra(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		wantCode int
+		want     map[string]interface{}
+	}{
+		{name: "extra field", value: `{"temperature":0.2}`, wantCode: interfacelayer.ExitOK, want: map[string]interface{}{"temperature": 0.2}},
+		{name: "protected field", value: `{"model":"other"}`, wantCode: interfacelayer.ExitUsage},
+		{name: "not an object", value: `"temperature"`, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config.RequestExtra
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			code, _ := runCLI(t, []string{"program", "-apikey=test-key", "-request-extra=" + tt.value, "Some thought"}, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RequestExtra = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLI_UserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "default includes the version", args: nil, want: "claude-think-tool/" + interfacelayer.Version + " (go"},
+		{name: "override", args: []string{"-user-agent=my-wrapper/2.0"}, want: "my-wrapper/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config.UserAgent
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			if code, _ := runCLI(t, args, service, nil); code != interfacelayer.ExitOK {
+				t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+			}
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("UserAgent = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLI_NoEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantKey    string
+		wantCalled bool
+	}{
+		{name: "key from the environment", args: nil, wantCode: interfacelayer.ExitOK, wantKey: "env-key", wantCalled: true},
+		{name: "environment ignored", args: []string{"-no-env"}, wantCode: interfacelayer.ExitError},
+		{name: "key from the flag", args: []string{"-no-env", "-apikey=flag-key"}, wantCode: interfacelayer.ExitOK, wantKey: "flag-key", wantCalled: true},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "env-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			called := false
+			var gotKey string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					called = true
+					gotKey = config.APIKey
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d (logs: %s)", code, tt.wantCode, logs.String())
+			}
+			if called != tt.wantCalled {
+				t.Errorf("Service called = %v, want %v", called, tt.wantCalled)
+			}
+			if gotKey != tt.wantKey {
+				t.Errorf("APIKey = %q, want %q", gotKey, tt.wantKey)
+			}
+			if !tt.wantCalled && !strings.Contains(logs.String(), "-no-env ignores ANTHROPIC_API_KEY") {
+				t.Errorf("Expected the error to mention -no-env, got %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestCLI_Clipboard(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		noClipboard bool
+		readErr     error
+		wantCode    int
+		wantThought string
+		wantCopied  string
+	}{
+		{
+			name:        "thought from clipboard",
+			args:        []string{"program", "-apikey=test-key", "-clipboard"},
+			wantCode:    interfacelayer.ExitOK,
+			wantThought: "Copied thought",
+		},
+		{
+			name:        "result back to clipboard",
+			args:        []string{"program", "-apikey=test-key", "-clipboard", "-clipboard-out"},
+			wantCode:    interfacelayer.ExitOK,
+			wantThought: "Copied thought",
+			wantCopied:  "Analysis of: Copied thought",
+		},
+		{
+			name:     "headless system",
+			args:     []string{"program", "-apikey=test-key", "-clipboard"},
+			readErr:  domain.ErrClipboardUnavailable,
+			wantCode: interfacelayer.ExitError,
+		},
+		{
+			name:        "no clipboard configured",
+			args:        []string{"program", "-apikey=test-key", "-clipboard"},
+			noClipboard: true,
+			wantCode:    interfacelayer.ExitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotThought, copied string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotThought = thought
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis of: " + thought}, nil
+				},
+			}
+
+			var clipboard domain.Clipboard
+			if !tt.noClipboard {
+				clipboard = &unit.MockClipboard{
+					ReadClipboardFunc: func() (string, error) {
+						if tt.readErr != nil {
+							return "", tt.readErr
+						}
+						return "Copied thought", nil
+					},
+					WriteClipboardFunc: func(text string) error {
+						copied = text
+						return nil
+					},
+				}
+			}
+
+			code, _ := runCLIWithClipboard(t, tt.args, service, nil, clipboard)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if gotThought != tt.wantThought {
+				t.Errorf("Analyzed thought = %q, want %q", gotThought, tt.wantThought)
+			}
+			if copied != tt.wantCopied {
+				t.Errorf("Copied output = %q, want %q", copied, tt.wantCopied)
+			}
+		})
+	}
+}
+
+func TestCLI_Race(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantModels []string
+	}{
+		{name: "no race", wantCode: interfacelayer.ExitOK},
+		{name: "aliases resolved", args: []string{"-race=haiku, sonnet"}, wantCode: interfacelayer.ExitOK, wantModels: []string{"claude-3-5-haiku-20241022", "claude-3-7-sonnet-20250219"}},
+		{name: "unknown alias with strict aliases", args: []string{"-strict-model-aliases", "-race=sonet"}, wantCode: interfacelayer.ExitUsage},
+		{name: "conflicts with -model-fallback", args: []string{"-race=haiku", "-model-fallback=opus"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotModels []string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotModels = config.RaceModels
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !reflect.DeepEqual(gotModels, tt.wantModels) {
+				t.Errorf("RaceModels = %v, want %v", gotModels, tt.wantModels)
+			}
+		})
+	}
+}
+
+func TestCLI_NormalizeOutputNewlines(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "unchanged by default", want: "Strengths:\r\n- Clear\rConcerns:\n- None\n"},
+		{name: "normalized", args: []string{"-normalize-output-newlines"}, want: "Strengths:\n- Clear\nConcerns:\n- None\n"},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Strengths:\r\n- Clear\rConcerns:\n- None"}, nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, stdout := runCLI(t, args, service, nil)
+			if code != interfacelayer.ExitOK {
+				t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+			}
+			if stdout != tt.want {
+				t.Errorf("Output = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+
+	t.Run("passes -normalize-newlines on", func(t *testing.T) {
+		var normalize bool
+		service := &unit.MockThinkService{
+			AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+				normalize = config.NormalizeNewlines
+				return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+			},
+		}
+		if code, _ := runCLI(t, []string{"program", "-normalize-newlines", "Some thought"}, service, nil); code != interfacelayer.ExitOK || !normalize {
+			t.Errorf("Exit code = %d, NormalizeNewlines = %v, want %d and true", code, normalize, interfacelayer.ExitOK)
+		}
+	})
+}
+
+func TestCLI_Footer(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantFooter bool
+	}{
+		{name: "off for text", args: []string{"-format=text"}},
+		{name: "on for text with -footer", args: []string{"-format=text", "-footer"}, wantFooter: true},
+		{name: "on for gh-tasks", args: []string{"-format=gh-tasks"}, wantFooter: true},
+		{name: "off for gh-tasks with -footer=false", args: []string{"-format=gh-tasks", "-footer=false"}},
+		{name: "never for json", args: []string{"-format=json", "-footer"}},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis", Model: "claude-test"}, nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, stdout := runCLI(t, args, service, nil)
+			if code != interfacelayer.ExitOK {
+				t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+			}
+			wantFooter := "Generated by claude-think-tool " + interfacelayer.Version + " with claude-test at "
+			if got := strings.Contains(stdout, wantFooter); got != tt.wantFooter {
+				t.Errorf("Footer printed = %v, want %v:\n%s", got, tt.wantFooter, stdout)
+			}
+		})
+	}
+}
+
+func TestCLI_OutputOnError(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantWrite bool
+		wantHash  string // the text whose hash the record carries
+	}{
+		{name: "off by default", args: []string{"-output=result.json"}},
+		{name: "writes the error record", args: []string{"-output=result.json", "-output-on-error", "-format=json"}, wantWrite: true, wantHash: "Some thought"},
+		{name: "hashes the redacted thought", args: []string{"-output=result.json", "-output-on-error", "-format=json", "-redact-pattern=Some"}, wantWrite: true, wantHash: "[REDACTED] thought"},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					return nil, errors.New("API returned error: overloaded")
+				},
+			}
+			written := make(map[string]string)
+			storage := &unit.MockFileStorage{
+				WriteToFileFunc: func(filePath string, content string) error {
+					written[filePath] = content
+					return nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, storage)
+			if code != interfacelayer.ExitError {
+				t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitError)
+			}
+			content, ok := written["result.json"]
+			if ok != tt.wantWrite {
+				t.Fatalf("Output file written = %v, want %v", ok, tt.wantWrite)
+			}
+			if !tt.wantWrite {
+				return
+			}
+
+			var record map[string]string
+			if err := json.Unmarshal([]byte(content), &record); err != nil {
+				t.Fatalf("Error record is not JSON: %v\n%s", err, content)
+			}
+			sum := sha256.Sum256([]byte(tt.wantHash))
+			if record["error"] != "API returned error: overloaded" || record["thought_hash"] != hex.EncodeToString(sum[:]) || record["timestamp"] == "" {
+				t.Errorf("Error record = %v, want the error, thought hash and a timestamp", record)
+			}
+		})
+	}
+
+	t.Run("requires -output", func(t *testing.T) {
+		code, _ := runCLI(t, []string{"program", "-output-on-error", "Some thought"}, &unit.MockThinkService{}, nil)
+		if code != interfacelayer.ExitUsage {
+			t.Errorf("Exit code = %d, want %d", code, interfacelayer.ExitUsage)
+		}
+	})
+}
+
+func TestCLI_Focus(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantCode  int
+		wantFocus []string
+	}{
+		{name: "no focus", wantCode: interfacelayer.ExitOK},
+		{name: "categories", args: []string{"-focus=Security, clarity"}, wantCode: interfacelayer.ExitOK, wantFocus: []string{"security", "clarity"}},
+		{name: "unknown category", args: []string{"-focus=security,vibes"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotFocus []string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotFocus = config.Focus
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !reflect.DeepEqual(gotFocus, tt.wantFocus) {
+				t.Errorf("Focus = %v, want %v", gotFocus, tt.wantFocus)
+			}
+		})
+	}
+}
+
+func TestCLI_Preset(t *testing.T) {
+	skeptic, err := domain.FindPromptPreset("skeptic")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantPrompt string
+	}{
+		{name: "preset resolves", args: []string{"-preset=skeptic"}, wantCode: interfacelayer.ExitOK, wantPrompt: skeptic.Prompt},
+		{name: "prompt overrides preset", args: []string{"-preset=skeptic", "-prompt=Critique:"}, wantCode: interfacelayer.ExitOK, wantPrompt: "Critique:"},
+		{name: "unknown preset", args: []string{"-preset=cheerleader"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPrompt string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotPrompt = config.ThoughtPrompt
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if gotPrompt != tt.wantPrompt {
+				t.Errorf("ThoughtPrompt = %q, want %q", gotPrompt, tt.wantPrompt)
+			}
+		})
+	}
+
+	t.Run("list presets", func(t *testing.T) {
+		code, stdout := runCLI(t, []string{"program", "-list-presets"}, &unit.MockThinkService{}, nil)
+		if code != interfacelayer.ExitOK {
+			t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+		}
+		for _, preset := range domain.PromptPresets {
+			if !strings.Contains(stdout, preset.Name) {
+				t.Errorf("Expected %s in the preset list, got %q", preset.Name, stdout)
+			}
+		}
+	})
+}
+
+func TestCLI_GitDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		diff         string
+		readErr      error
+		wantCode     int
+		wantArgs     []string
+		wantCalled   bool
+		wantInPrompt string
+	}{
+		{
+			name:         "changes are reviewed",
+			args:         []string{"-git-diff"},
+			diff:         "+func main() {}\n",
+			wantCode:     interfacelayer.ExitOK,
+			wantArgs:     []string{},
+			wantCalled:   true,
+			wantInPrompt: "code reviewer",
+		},
+		{
+			name:         "diff arguments",
+			args:         []string{"-git-diff", "-git-diff-args=--staged -- internal"},
+			diff:         "+func main() {}\n",
+			wantCode:     interfacelayer.ExitOK,
+			wantArgs:     []string{"--staged", "--", "internal"},
+			wantCalled:   true,
+			wantInPrompt: "code reviewer",
+		},
+		{
+			name:         "prompt overrides the review prompt",
+			args:         []string{"-git-diff", "-prompt=Summarize:"},
+			diff:         "+func main() {}\n",
+			wantCode:     interfacelayer.ExitOK,
+			wantArgs:     []string{},
+			wantCalled:   true,
+			wantInPrompt: "Summarize:",
+		},
+		{
+			name:     "no changes",
+			args:     []string{"-git-diff"},
+			diff:     "\n",
+			wantCode: interfacelayer.ExitOK,
+			wantArgs: []string{},
+		},
+		{
+			name:     "not a repository",
+			args:     []string{"-git-diff"},
+			readErr:  errors.New("not a git repository"),
+			wantCode: interfacelayer.ExitError,
+			wantArgs: []string{},
+		},
+		{
+			name:     "with a thought argument",
+			args:     []string{"-git-diff", "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "arguments without -git-diff",
+			args:     []string{"-git-diff-args=--staged"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotThought, gotPrompt string
+			called := false
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					called = true
+					gotThought, gotPrompt = thought, config.ThoughtPrompt
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Review"}, nil
+				},
+			}
+			var gotArgs []string
+			diffSource := &unit.MockDiffSource{
+				ReadDiffFunc: func(args []string) (string, error) {
+					gotArgs = append([]string{}, args...)
+					return tt.diff, tt.readErr
+				},
+			}
+
+			args := append([]string{"program", "-apikey=test-key"}, tt.args...)
+			code, _ := runCLIWithSetup(t, args, service, nil, func(cli *interfacelayer.CLI) {
+				cli.SetDiffSource(diffSource)
+			})
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("git diff args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+			if called != tt.wantCalled {
+				t.Fatalf("Service called = %v, want %v", called, tt.wantCalled)
+			}
+			if !called {
+				return
+			}
+			if !strings.Contains(gotThought, tt.diff) {
+				t.Errorf("Thought = %q, want it to contain the diff", gotThought)
+			}
+			if !strings.Contains(gotPrompt, tt.wantInPrompt) {
+				t.Errorf("ThoughtPrompt = %q, want it to contain %q", gotPrompt, tt.wantInPrompt)
+			}
+		})
+	}
+}
+
+func TestCLI_ToolResultFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		fileContent    string
+		wantCode       int
+		wantToolResult string
+	}{
+		{name: "result from file", args: []string{"-tool-result-file=result.txt"}, fileContent: "Precomputed analysis", wantCode: interfacelayer.ExitOK, wantToolResult: "Precomputed analysis"},
+		{name: "empty file", args: []string{"-tool-result-file=result.txt"}, fileContent: " \n", wantCode: interfacelayer.ExitUsage},
+		{name: "with an analyzer", args: []string{"-tool-result-file=result.txt", "-analyzer=fallacy"}, wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotToolResult, readPath string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotToolResult = config.ToolResult
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+			storage := &unit.MockFileStorage{
+				ReadFromFileFunc: func(filePath string) (string, error) {
+					readPath = filePath
+					return tt.fileContent, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, storage)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if gotToolResult != tt.wantToolResult {
+				t.Errorf("ToolResult = %q, want %q", gotToolResult, tt.wantToolResult)
+			}
+			if tt.fileContent != "" && readPath != "result.txt" {
+				t.Errorf("Read %q, want result.txt", readPath)
+			}
+		})
+	}
+}
+
+func TestCLI_Capabilities(t *testing.T) {
+	code, output := runCLI(t, []string{"program", "-capabilities", "-model-aliases=fast=claude-3-5-haiku-latest"}, &unit.MockThinkService{}, nil)
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+
+	var report struct {
+		Formats      []string          `json:"formats"`
+		ModelAliases map[string]string `json:"model_aliases"`
+		Features     map[string]bool   `json:"features"`
+		Flags        []struct {
+			Name    string `json:"name"`
+			Type    string `json:"type"`
+			Default string `json:"default"`
+		} `json:"flags"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v\n%s", err, output)
+	}
+
+	for _, format := range []string{"json", "text", "ndjson", "gh-tasks"} {
+		found := false
+		for _, name := range report.Formats {
+			found = found || name == format
+		}
+		if !found {
+			t.Errorf("Expected format %s in %v", format, report.Formats)
+		}
+	}
+	if report.ModelAliases["fast"] != "claude-3-5-haiku-latest" || report.ModelAliases["opus"] == "" {
+		t.Errorf("Expected built-in and configured aliases, got %v", report.ModelAliases)
+	}
+	if report.Features["streaming"] || !report.Features["batch"] {
+		t.Errorf("Unexpected features %v", report.Features)
+	}
+
+	wantFlags := map[string][2]string{
+		"format":     {"string", "text"},
+		"timeout":    {"duration", "30s"},
+		"max-tokens": {"int", "1024"},
+		"verbose":    {"bool", "false"},
+		"input":      {"value", ""},
+	}
+	for _, f := range report.Flags {
+		if want, ok := wantFlags[f.Name]; ok {
+			if f.Type != want[0] || f.Default != want[1] {
+				t.Errorf("Flag %s = %s (default %q), want %s (default %q)", f.Name, f.Type, f.Default, want[0], want[1])
+			}
+			delete(wantFlags, f.Name)
+		}
+	}
+	for name := range wantFlags {
+		t.Errorf("Expected flag %s in the capabilities", name)
+	}
+}
+
+func TestCLI_PromptFromStdin(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		stdin        string
+		wantCode     int
+		wantPrompt   string
+		wantTemplate string
+	}{
+		{name: "prompt", args: []string{"-prompt=-"}, stdin: "Critique this plan:\n", wantCode: interfacelayer.ExitOK, wantPrompt: "Critique this plan:"},
+		{name: "prompt template", args: []string{"-prompt-template=-"}, stdin: "Critique: {{.Thought}}", wantCode: interfacelayer.ExitOK, wantTemplate: "Critique: {{.Thought}}"},
+		{name: "empty prompt", args: []string{"-prompt=-"}, stdin: "\n", wantCode: interfacelayer.ExitUsage},
+		{name: "both from stdin", args: []string{"-prompt=-", "-prompt-template=-"}, stdin: "Critique", wantCode: interfacelayer.ExitUsage},
+		{name: "stdin already used", args: []string{"-prompt=-", "-stdin-json"}, stdin: "Critique", wantCode: interfacelayer.ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("Failed to create stdin pipe: %v", err)
+			}
+			oldStdin := os.Stdin
+			os.Stdin = r
+			defer func() { os.Stdin = oldStdin }()
+			w.WriteString(tt.stdin)
+			w.Close()
+
+			var gotPrompt, gotTemplate string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					gotPrompt, gotTemplate = config.ThoughtPrompt, config.PromptTemplate
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, nil)
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if gotPrompt != tt.wantPrompt || gotTemplate != tt.wantTemplate {
+				t.Errorf("ThoughtPrompt, PromptTemplate = %q, %q, want %q, %q", gotPrompt, gotTemplate, tt.wantPrompt, tt.wantTemplate)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package interfacelayer
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// nonConfigurableFlags cannot be set from a config file
+var nonConfigurableFlags = map[string]bool{
+	"capabilities":    true,
+	"config":          true,
+	"config-required": true,
+	"help":            true,
+	"list-presets":    true,
+	"version":         true,
+}
+
+// EnvPrefix starts the environment variables that set options: CTT_MAX_TOKENS
+// sets -max-tokens
+const EnvPrefix = "CTT_"
+
+// nonEnvFlags cannot be set from the environment
+var nonEnvFlags = map[string]bool{
+	"capabilities": true,
+	"help":         true,
+	"list-presets": true,
+	"no-env":       true,
+	"version":      true,
+}
+
+// nonOptionEnvVars use the prefix without naming an option
+var nonOptionEnvVars = map[string]bool{
+	"CTT_FAKE_API": true, // selects simulated mode, read by main
+}
+
+// listFlags take a comma-separated list, so a config array is joined into one
+// value and a config object into "key=value" pairs
+var listFlags = map[string]bool{
+	"content-blocks":   true,
+	"focus":            true,
+	"model-aliases":    true,
+	"model-fallback":   true,
+	"model-max-tokens": true,
+	"race":             true,
+	"retry-on-status":  true,
+}
+
+// ConfigValue is an option value from the config file or the environment, as
+// flag strings
+type ConfigValue struct {
+	Items  []string          // the value, or an array's elements
+	List   bool              // Items came from an array
+	Fields map[string]string // an object's entries, instead of Items
+}
+
+// LoadConfigFile reads a JSON config file whose keys are flag names, returning
+// the values as flag strings. A missing file yields no values (with a warning)
+// unless required is set; a malformed file is always an error.
+func LoadConfigFile(storage domain.FileStorage, path string, required bool) (map[string]ConfigValue, error) {
+	data, err := storage.ReadFromFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			log.Printf("Warning: config file %s not found, using defaults", path)
+			return nil, nil
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s not found (required by -config-required)", path)
+		}
+		return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("malformed config file %s: %w", path, err)
+	}
+
+	values := make(map[string]ConfigValue, len(raw))
+	for key, value := range raw {
+		configValue, err := parseConfigValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("malformed config file %s: key %q: %w", path, key, err)
+		}
+		values[key] = configValue
+	}
+	return values, nil
+}
+
+// parseConfigValue converts a JSON value into flag strings: a scalar, an array
+// of scalars or an object of scalars
+func parseConfigValue(value interface{}) (ConfigValue, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := configValueString(item)
+			if err != nil {
+				return ConfigValue{}, err
+			}
+			items = append(items, s)
+		}
+		return ConfigValue{Items: items, List: true}, nil
+	case map[string]interface{}:
+		fields := make(map[string]string, len(v))
+		for k, item := range v {
+			s, err := configValueString(item)
+			if err != nil {
+				return ConfigValue{}, err
+			}
+			fields[k] = s
+		}
+		return ConfigValue{Fields: fields}, nil
+	default:
+		s, err := configValueString(value)
+		if err != nil {
+			return ConfigValue{}, err
+		}
+		return ConfigValue{Items: []string{s}}, nil
+	}
+}
+
+// configValueString converts a scalar JSON value into the string a flag accepts
+func configValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// flagValues returns the strings to Set on the flag for value: one per element
+// or entry for a repeatable flag, a single comma-separated one for a list flag.
+// Other flags take a single value, so arrays and objects are rejected.
+func flagValues(f *flag.Flag, value ConfigValue) ([]string, error) {
+	_, repeatable := f.Value.(*stringsFlag)
+	_, header := f.Value.(headerFlag)
+
+	if value.Fields != nil {
+		keys := make([]string, 0, len(value.Fields))
+		for k := range value.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		switch {
+		case header:
+			items := make([]string, 0, len(keys))
+			for _, k := range keys {
+				items = append(items, k+": "+value.Fields[k])
+			}
+			return items, nil
+		case listFlags[f.Name]:
+			pairs := make([]string, 0, len(keys))
+			for _, k := range keys {
+				pairs = append(pairs, k+"="+value.Fields[k])
+			}
+			return []string{strings.Join(pairs, ",")}, nil
+		default:
+			return nil, errors.New("takes a single value, not an object")
+		}
+	}
+
+	switch {
+	case repeatable || header || !value.List:
+		return value.Items, nil
+	case listFlags[f.Name]:
+		return []string{strings.Join(value.Items, ",")}, nil
+	default:
+		return nil, errors.New("takes a single value, not a list")
+	}
+}
+
+// setFlag sets the named flag from value, once per string flagValues returns
+func setFlag(flagSet *flag.FlagSet, name string, value ConfigValue) error {
+	items, err := flagValues(flagSet.Lookup(name), value)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := flagSet.Set(name, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyConfigFile sets every flag from the config file that was not given
+// explicitly on the command line, so flags take precedence over the file
+func applyConfigFile(flagSet *flag.FlagSet, values map[string]ConfigValue) error {
+	explicit := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if nonConfigurableFlags[key] || flagSet.Lookup(key) == nil {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		if explicit[key] {
+			continue
+		}
+		if err := setFlag(flagSet, key, values[key]); err != nil {
+			return fmt.Errorf("invalid value for config key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// EnvConfigValues collects the CTT_ variables of environ ("KEY=value" pairs, as
+// returned by os.Environ) as flag values keyed by flag name: CTT_MAX_TOKENS
+// becomes max-tokens. Each variable is a single value, so it adds one -input,
+// -redact-pattern or -header. Empty variables are skipped.
+func EnvConfigValues(environ []string) map[string]ConfigValue {
+	values := make(map[string]ConfigValue)
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, EnvPrefix) || nonOptionEnvVars[key] || value == "" {
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, EnvPrefix), "_", "-"))
+		values[name] = ConfigValue{Items: []string{value}}
+	}
+	return values
+}
+
+// applyEnv sets every flag from the environment values that was not given
+// explicitly on the command line, warning about variables that name no option.
+// It runs before applyConfigFile, which then treats these flags as given, so
+// the environment takes precedence over the file.
+func applyEnv(flagSet *flag.FlagSet, values map[string]ConfigValue) error {
+	explicit := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := envVarName(name)
+		if nonEnvFlags[name] || flagSet.Lookup(name) == nil {
+			log.Printf("Warning: ignoring unknown environment variable %s", key)
+			continue
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := setFlag(flagSet, name, values[name]); err != nil {
+			return fmt.Errorf("invalid value for environment variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// envVarName returns the environment variable that sets the named flag
+func envVarName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
@@ -0,0 +1,517 @@
+package interfacelayer_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+	interfacelayer "claude-think-tool/internal/interface"
+	"claude-think-tool/test/unit"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	validFile := filepath.Join(tempDir, "valid.json")
+	os.WriteFile(validFile, []byte(`{"model": "claude-3-opus-20240229", "max-tokens": 2048, "verbose": true, "model-fallback": ["a", "b"], "header": {"X-Team": "core"}}`), 0644)
+	malformedFile := filepath.Join(tempDir, "malformed.json")
+	os.WriteFile(malformedFile, []byte(`{"model": "claude-3-opus-20240229",`), 0644)
+	missingFile := filepath.Join(tempDir, "missing.json")
+
+	tests := []struct {
+		name        string
+		path        string
+		required    bool
+		want        map[string]interfacelayer.ConfigValue
+		expectError string
+	}{
+		{
+			name: "valid file",
+			path: validFile,
+			want: map[string]interfacelayer.ConfigValue{
+				"model":          {Items: []string{"claude-3-opus-20240229"}},
+				"max-tokens":     {Items: []string{"2048"}},
+				"verbose":        {Items: []string{"true"}},
+				"model-fallback": {Items: []string{"a", "b"}, List: true},
+				"header":         {Fields: map[string]string{"X-Team": "core"}},
+			},
+		},
+		{name: "absent file uses defaults", path: missingFile, want: map[string]interfacelayer.ConfigValue{}},
+		{name: "absent file when required", path: missingFile, required: true, expectError: "not found"},
+		{name: "malformed file", path: malformedFile, expectError: "malformed config file"},
+		{name: "malformed file even if not required", path: malformedFile, required: false, expectError: "malformed config file"},
+	}
+
+	storage := infra.NewFileStorage()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := interfacelayer.LoadConfigFile(storage, tt.path, tt.required)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(values) != len(tt.want) {
+				t.Errorf("Expected %d values, got %v", len(tt.want), values)
+			}
+			for key, want := range tt.want {
+				if !reflect.DeepEqual(values[key], want) {
+					t.Errorf("values[%q] = %+v, want %+v", key, values[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_ConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config.json")
+	os.WriteFile(configFile, []byte(`{"model": "model-from-config", "max-tokens": 2048}`), 0644)
+	unknownKeyFile := filepath.Join(tempDir, "unknown.json")
+	os.WriteFile(unknownKeyFile, []byte(`{"no-such-flag": true}`), 0644)
+	badValueFile := filepath.Join(tempDir, "bad.json")
+	os.WriteFile(badValueFile, []byte(`{"max-tokens": "many"}`), 0644)
+	inputFile := filepath.Join(tempDir, "input.json")
+	os.WriteFile(inputFile, []byte(`{"input": "thought.txt"}`), 0644)
+	clipboardOffFile := filepath.Join(tempDir, "clipboard-off.json")
+	os.WriteFile(clipboardOffFile, []byte(`{"clipboard": false}`), 0644)
+
+	tests := []struct {
+		name          string
+		args          []string
+		wantCode      int
+		wantModel     string
+		wantMaxTokens int
+	}{
+		{
+			name:          "config file supplies values",
+			args:          []string{"program", "-apikey=test-key", "-config=" + configFile, "Some thought"},
+			wantCode:      interfacelayer.ExitOK,
+			wantModel:     "model-from-config",
+			wantMaxTokens: 2048,
+		},
+		{
+			name:          "flags override config file",
+			args:          []string{"program", "-apikey=test-key", "-model=flag-model", "-config=" + configFile, "Some thought"},
+			wantCode:      interfacelayer.ExitOK,
+			wantModel:     "flag-model",
+			wantMaxTokens: 2048,
+		},
+		{
+			name:          "missing config falls back to defaults",
+			args:          []string{"program", "-apikey=test-key", "-config=" + filepath.Join(tempDir, "missing.json"), "Some thought"},
+			wantCode:      interfacelayer.ExitOK,
+			wantModel:     "claude-3-7-sonnet-20250219",
+			wantMaxTokens: 1024,
+		},
+		{
+			name:     "missing config with -config-required",
+			args:     []string{"program", "-apikey=test-key", "-config-required", "-config=" + filepath.Join(tempDir, "missing.json"), "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "unknown key",
+			args:     []string{"program", "-apikey=test-key", "-config=" + unknownKeyFile, "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "invalid value",
+			args:     []string{"program", "-apikey=test-key", "-config=" + badValueFile, "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "config input conflicts with a thought argument",
+			args:     []string{"program", "-apikey=test-key", "-config=" + inputFile, "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:          "disabled thought source in config",
+			args:          []string{"program", "-apikey=test-key", "-config=" + clipboardOffFile, "Some thought"},
+			wantCode:      interfacelayer.ExitOK,
+			wantModel:     "claude-3-7-sonnet-20250219",
+			wantMaxTokens: 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Config
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			code, _ := runCLI(t, tt.args, service, infra.NewFileStorage())
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantCode != interfacelayer.ExitOK {
+				return
+			}
+			if got.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", got.Model, tt.wantModel)
+			}
+			if got.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("MaxTokens = %d, want %d", got.MaxTokens, tt.wantMaxTokens)
+			}
+		})
+	}
+}
+
+func TestCLI_ConfigFileLists(t *testing.T) {
+	tempDir := t.TempDir()
+	firstInput := filepath.Join(tempDir, "a.txt")
+	os.WriteFile(firstInput, []byte("First thought"), 0644)
+	secondInput := filepath.Join(tempDir, "b.txt")
+	os.WriteFile(secondInput, []byte("Second thought"), 0644)
+	writeConfig := func(name, content string) string {
+		path := filepath.Join(tempDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+	inputs := writeConfig("inputs.json", `{"input": ["`+firstInput+`", "`+secondInput+`"]}`)
+	patterns := writeConfig("patterns.json", `{"redact-pattern": ["alpha-\\d+", "beta,gamma"]}`)
+	headers := writeConfig("headers.json", `{"header": {"X-Tenant": "acme", "X-Route": "eu-1"}}`)
+	scalarArray := writeConfig("scalar-array.json", `{"model": ["a", "b"]}`)
+	scalarObject := writeConfig("scalar-object.json", `{"format": {"json": true}}`)
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantCode     int
+		wantAnalyzed []string
+		check        func(t *testing.T, config domain.Config)
+	}{
+		{
+			name:         "input array analyzes each file",
+			args:         []string{"-config=" + inputs},
+			wantCode:     interfacelayer.ExitOK,
+			wantAnalyzed: []string{"First thought", "Second thought"},
+		},
+		{
+			name:     "redact-pattern array adds each pattern",
+			args:     []string{"-config=" + patterns, "Some thought"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				want := []string{`alpha-\d+`, "beta,gamma"}
+				if !reflect.DeepEqual(config.RedactPatterns, want) {
+					t.Errorf("RedactPatterns = %q, want %q", config.RedactPatterns, want)
+				}
+			},
+		},
+		{
+			name:     "header object adds each header",
+			args:     []string{"-config=" + headers, "Some thought"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				want := map[string]string{"X-Tenant": "acme", "X-Route": "eu-1"}
+				if !reflect.DeepEqual(config.Headers, want) {
+					t.Errorf("Headers = %v, want %v", config.Headers, want)
+				}
+			},
+		},
+		{
+			name:     "array for a single-valued flag",
+			args:     []string{"-config=" + scalarArray, "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "object for a single-valued flag",
+			args:     []string{"-config=" + scalarObject, "Some thought"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Config
+			var analyzed []string
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config
+					analyzed = append(analyzed, thought)
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append([]string{"program", "-apikey=test-key"}, tt.args...)
+			code, _ := runCLI(t, args, service, infra.NewFileStorage())
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantAnalyzed != nil && !reflect.DeepEqual(analyzed, tt.wantAnalyzed) {
+				t.Errorf("Analyzed thoughts = %q, want %q", analyzed, tt.wantAnalyzed)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+func TestCLI_ConfiguredFlagCombinations(t *testing.T) {
+	tempDir := t.TempDir()
+	firstInput := filepath.Join(tempDir, "a.txt")
+	os.WriteFile(firstInput, []byte("First thought"), 0644)
+	secondInput := filepath.Join(tempDir, "b.txt")
+	os.WriteFile(secondInput, []byte("Second thought"), 0644)
+	writeConfig := func(name, content string) string {
+		path := filepath.Join(tempDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+	interactiveConfig := writeConfig("interactive.json", `{"interactive": true}`)
+	diagnoseConfig := writeConfig("diagnose.json", `{"diagnose-response": true}`)
+	clipboardOffConfig := writeConfig("clipboard-off.json", `{"clipboard": false}`)
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		args      []string
+		wantCode  int
+		wantError string
+	}{
+		{
+			name:      "environment conflicts with a flag",
+			env:       map[string]string{"CTT_INTERACTIVE": "true"},
+			args:      []string{"-input=" + firstInput},
+			wantCode:  interfacelayer.ExitUsage,
+			wantError: "cannot use CTT_INTERACTIVE from the environment and -input together",
+		},
+		{
+			name:      "config file conflicts with a flag",
+			args:      []string{"-config=" + interactiveConfig, "-input=" + firstInput},
+			wantCode:  interfacelayer.ExitUsage,
+			wantError: "cannot use -interactive from the config file and -input together",
+		},
+		{
+			name:      "environment conflicts with the config file",
+			env:       map[string]string{"CTT_INPUT": firstInput},
+			args:      []string{"-config=" + interactiveConfig},
+			wantCode:  interfacelayer.ExitUsage,
+			wantError: "cannot use -interactive from the config file and CTT_INPUT from the environment together",
+		},
+		{
+			name:      "environment thought source with a thought argument",
+			env:       map[string]string{"CTT_INPUT": firstInput},
+			args:      []string{"Some thought"},
+			wantCode:  interfacelayer.ExitUsage,
+			wantError: "cannot use CTT_INPUT from the environment together with a thought argument",
+		},
+		{
+			name:      "dependent option from the environment",
+			env:       map[string]string{"CTT_GOLDEN_UPDATE": "true"},
+			args:      []string{"Some thought"},
+			wantCode:  interfacelayer.ExitUsage,
+			wantError: "CTT_GOLDEN_UPDATE from the environment requires -golden",
+		},
+		{
+			name:      "batch-incompatible option from the config file",
+			args:      []string{"-config=" + diagnoseConfig, "-input=" + firstInput, "-input=" + secondInput},
+			wantCode:  interfacelayer.ExitUsage,
+			wantError: "cannot use -diagnose-response from the config file with several -input files",
+		},
+		{
+			name:     "option switched off in the config file",
+			args:     []string{"-config=" + clipboardOffConfig, "-input=" + firstInput},
+			wantCode: interfacelayer.ExitOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			service := staticService(&domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"})
+			args := append([]string{"program", "-apikey=test-key"}, tt.args...)
+			code, _ := runCLI(t, args, service, infra.NewFileStorage())
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d (log: %s)", code, tt.wantCode, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.wantError) {
+				t.Errorf("Expected an error containing %q, got %q", tt.wantError, logs.String())
+			}
+		})
+	}
+}
+
+func TestEnvConfigValues(t *testing.T) {
+	environ := []string{
+		"CTT_MODEL=env-model",
+		"CTT_MAX_TOKENS=2048",
+		"CTT_FAKE_API=1",
+		"CTT_FORMAT=",
+		"ANTHROPIC_API_KEY=test-key",
+		"PATH=/usr/bin",
+	}
+	got := interfacelayer.EnvConfigValues(environ)
+	want := map[string]interfacelayer.ConfigValue{
+		"model":      {Items: []string{"env-model"}},
+		"max-tokens": {Items: []string{"2048"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvConfigValues = %+v, want %+v", got, want)
+	}
+}
+
+func TestCLI_EnvConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config.json")
+	os.WriteFile(configFile, []byte(`{"model": "model-from-config", "max-tokens": 2048}`), 0644)
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		wantCode int
+		check    func(t *testing.T, config domain.Config)
+	}{
+		{
+			name:     "CTT_MODEL",
+			env:      map[string]string{"CTT_MODEL": "env-model"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Model != "env-model" {
+					t.Errorf("Model = %q, want %q", config.Model, "env-model")
+				}
+			},
+		},
+		{
+			name:     "CTT_MAX_TOKENS",
+			env:      map[string]string{"CTT_MAX_TOKENS": "4096"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.MaxTokens != 4096 {
+					t.Errorf("MaxTokens = %d, want 4096", config.MaxTokens)
+				}
+			},
+		},
+		{
+			name:     "CTT_FORMAT",
+			env:      map[string]string{"CTT_FORMAT": "json"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.OutputFormat != "json" {
+					t.Errorf("OutputFormat = %q, want %q", config.OutputFormat, "json")
+				}
+			},
+		},
+		{
+			name:     "CTT_TIMEOUT",
+			env:      map[string]string{"CTT_TIMEOUT": "90s"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Timeout != 90*time.Second {
+					t.Errorf("Timeout = %v, want 90s", config.Timeout)
+				}
+			},
+		},
+		{
+			name:     "CTT_CONFIG",
+			env:      map[string]string{"CTT_CONFIG": configFile},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Model != "model-from-config" {
+					t.Errorf("Model = %q, want %q", config.Model, "model-from-config")
+				}
+			},
+		},
+		{
+			name:     "flags override the environment",
+			env:      map[string]string{"CTT_MODEL": "env-model"},
+			args:     []string{"-model=flag-model"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Model != "flag-model" {
+					t.Errorf("Model = %q, want %q", config.Model, "flag-model")
+				}
+			},
+		},
+		{
+			name:     "environment overrides the config file",
+			env:      map[string]string{"CTT_MODEL": "env-model"},
+			args:     []string{"-config=" + configFile},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Model != "env-model" || config.MaxTokens != 2048 {
+					t.Errorf("Model, MaxTokens = %q, %d, want %q, 2048", config.Model, config.MaxTokens, "env-model")
+				}
+			},
+		},
+		{
+			name:     "-no-env ignores the environment",
+			env:      map[string]string{"CTT_MODEL": "env-model"},
+			args:     []string{"-no-env"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Model != "claude-3-7-sonnet-20250219" {
+					t.Errorf("Model = %q, want the default", config.Model)
+				}
+			},
+		},
+		{
+			name:     "unknown variable is ignored",
+			env:      map[string]string{"CTT_NO_SUCH_FLAG": "1", "CTT_MODEL": "env-model"},
+			wantCode: interfacelayer.ExitOK,
+			check: func(t *testing.T, config domain.Config) {
+				if config.Model != "env-model" {
+					t.Errorf("Model = %q, want %q", config.Model, "env-model")
+				}
+			},
+		},
+		{
+			name:     "invalid value",
+			env:      map[string]string{"CTT_MAX_TOKENS": "many"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+		{
+			name:     "thought source conflicts with a thought argument",
+			env:      map[string]string{"CTT_INPUT": "thought.txt"},
+			wantCode: interfacelayer.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var got domain.Config
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					got = config
+					return &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Analysis"}, nil
+				},
+			}
+
+			args := append(append([]string{"program", "-apikey=test-key"}, tt.args...), "Some thought")
+			code, _ := runCLI(t, args, service, infra.NewFileStorage())
+			if code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package interfacelayer
+
+import (
+	"fmt"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// stopReasonExplanations explains the stop reasons the Messages API reports
+var stopReasonExplanations = map[string]string{
+	"end_turn":      "Model finished its answer normally",
+	"max_tokens":    "Output truncated at max_tokens; increase -max-tokens",
+	"stop_sequence": "Output ended at a stop sequence",
+	"tool_use":      "Stopped at the think tool call, so the analysis was not run (-no-followup)",
+	"pause_turn":    "Model paused a long-running turn more often than -max-pause-turns allows; the answer is incomplete",
+	"refusal":       "Model refused to analyze the thought; rephrase it or check it against the usage policy",
+}
+
+// DiagnoseResponse explains why an analysis looks the way it does, based on
+// the stop reason, the use of the think tool and the token usage
+func DiagnoseResponse(response *domain.ThinkResponse) []string {
+	var notes []string
+	if explanation, ok := stopReasonExplanations[response.StopReason]; ok {
+		notes = append(notes, explanation)
+	} else if response.StopReason == "" {
+		notes = append(notes, "No stop reason reported by the API")
+	} else {
+		notes = append(notes, fmt.Sprintf("Unrecognized stop reason %q", response.StopReason))
+	}
+
+	// Chunked analyses end with a synthesis turn that never offers the tool
+	if response.ToolUse == nil && len(response.Chunks) == 0 {
+		notes = append(notes, "Model answered directly without using the think tool")
+	}
+
+	if response.StopReason != "refusal" {
+		if strings.TrimSpace(response.Content) == "" {
+			notes = append(notes, "The answer contains no text")
+		} else if response.RiskLevel == domain.RiskUnknown {
+			notes = append(notes, "The answer has no \"Risk level\" line, so -fail-on-risk cannot gate on it")
+		}
+	}
+
+	if response.Usage == (domain.Usage{}) {
+		notes = append(notes, "The API reported no token usage")
+	}
+	return notes
+}
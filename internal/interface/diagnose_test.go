@@ -0,0 +1,116 @@
+package interfacelayer_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+	interfacelayer "claude-think-tool/internal/interface"
+)
+
+func TestDiagnoseResponse(t *testing.T) {
+	toolUse := &domain.ToolUse{ID: "toolu_1", Name: "think"}
+	usage := domain.Usage{InputTokens: 10, OutputTokens: 5}
+
+	tests := []struct {
+		name     string
+		response domain.ThinkResponse
+		expected []string
+	}{
+		{
+			name:     "complete analysis",
+			response: domain.ThinkResponse{StopReason: "end_turn", ToolUse: toolUse, Content: "Fine.", RiskLevel: domain.RiskLow, Usage: usage},
+			expected: []string{"Model finished its answer normally"},
+		},
+		{
+			name:     "truncated",
+			response: domain.ThinkResponse{StopReason: "max_tokens", ToolUse: toolUse, Content: "Strengths:", Usage: usage},
+			expected: []string{
+				"Output truncated at max_tokens; increase -max-tokens",
+				"The answer has no \"Risk level\" line, so -fail-on-risk cannot gate on it",
+			},
+		},
+		{
+			name:     "direct answer",
+			response: domain.ThinkResponse{StopReason: "end_turn", Content: "Risk level: LOW", RiskLevel: domain.RiskLow, Usage: usage},
+			expected: []string{"Model finished its answer normally", "Model answered directly without using the think tool"},
+		},
+		{
+			name:     "refusal",
+			response: domain.ThinkResponse{StopReason: "refusal", ToolUse: toolUse, Refused: true, Usage: usage},
+			expected: []string{"Model refused to analyze the thought; rephrase it or check it against the usage policy"},
+		},
+		{
+			name:     "tool use without follow-up",
+			response: domain.ThinkResponse{StopReason: "tool_use", ToolUse: toolUse, Usage: usage},
+			expected: []string{"Stopped at the think tool call, so the analysis was not run (-no-followup)", "The answer contains no text"},
+		},
+		{
+			name:     "unknown stop reason without usage",
+			response: domain.ThinkResponse{StopReason: "model_context_window_exceeded", ToolUse: toolUse, Content: "Risk level: HIGH", RiskLevel: domain.RiskHigh},
+			expected: []string{"Unrecognized stop reason \"model_context_window_exceeded\"", "The API reported no token usage"},
+		},
+		{
+			name:     "chunked analysis",
+			response: domain.ThinkResponse{StopReason: "end_turn", Chunks: []domain.ChunkResult{{Index: 0}}, Content: "Risk level: LOW", RiskLevel: domain.RiskLow, Usage: usage},
+			expected: []string{"Model finished its answer normally"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if notes := interfacelayer.DiagnoseResponse(&tt.response); !reflect.DeepEqual(notes, tt.expected) {
+				t.Errorf("DiagnoseResponse() = %q, want %q", notes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCLI_DiagnoseChunkedResponse(t *testing.T) {
+	for _, args := range [][]string{{"-diagnose-response"}, {"-diagnose-response", "-show-chunks"}} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			// Every chunk called the tool; the synthesis that ends the analysis never does
+			service := staticService(&domain.ThinkResponse{
+				Raw:        map[string]interface{}{},
+				Content:    "Risk level: LOW",
+				RiskLevel:  domain.RiskLow,
+				StopReason: "end_turn",
+				Usage:      domain.Usage{InputTokens: 10, OutputTokens: 5},
+				Chunks:     []domain.ChunkResult{{Index: 0, Content: "First"}, {Index: 1, Content: "Second"}},
+			})
+
+			oldStderr := os.Stderr
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("Failed to create stderr pipe: %v", err)
+			}
+			os.Stderr = w
+			stderrCh := make(chan string)
+			go func() {
+				var buf bytes.Buffer
+				io.Copy(&buf, r)
+				stderrCh <- buf.String()
+			}()
+
+			code, _ := runCLI(t, append(append([]string{"program", "-apikey=test-key"}, args...), "Some thought"), service, infra.NewFileStorage())
+			w.Close()
+			os.Stderr = oldStderr
+			stderr := <-stderrCh
+
+			if code != interfacelayer.ExitOK {
+				t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+			}
+			if !strings.Contains(stderr, "Diagnosis:") {
+				t.Fatalf("Expected a diagnosis, got %q", stderr)
+			}
+			if strings.Contains(stderr, "answered directly") {
+				t.Errorf("Expected no direct-answer note for a chunked analysis, got %q", stderr)
+			}
+		})
+	}
+}
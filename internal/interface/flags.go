@@ -0,0 +1,175 @@
+package interfacelayer
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// conflictingFlags are options that select different inputs or modes, so only
+// one of each pair can take effect
+var conflictingFlags = [][2]string{
+	{"input", "clipboard"},
+	{"git-diff", "input"},
+	{"git-diff", "clipboard"},
+	{"interactive", "input"},
+	{"interactive", "clipboard"},
+	{"interactive", "git-diff"},
+	{"interactive", "stdin-json"},
+	{"stdin-json", "input"},
+	{"stdin-json", "clipboard"},
+	{"stdin-json", "git-diff"},
+	{"content-only-on-success", "json-path"},
+	{"measure", "interactive"},
+	{"measure", "stdin-json"},
+	{"measure", "validate"},
+	{"measure", "validate-only"},
+	{"race", "model-fallback"},
+	{"race", "structured-output"},
+	{"tool-result-file", "analyzer"},
+	{"tool-result-file", "no-followup"},
+	{"tool-result-file", "strip-tool-result-prefix"},
+	{"validate", "validate-only"},
+	{"validate", "interactive"},
+	{"validate", "stdin-json"},
+	{"validate-only", "interactive"},
+	{"validate-only", "stdin-json"},
+}
+
+// thoughtSourceFlags are options that replace the positional thought argument
+var thoughtSourceFlags = []string{"input", "clipboard", "git-diff", "interactive", "stdin-json"}
+
+// dependentFlags are options that only have an effect together with another one
+var dependentFlags = [][2]string{
+	{"git-diff-args", "git-diff"},
+	{"golden-update", "golden"},
+	{"golden-tolerance", "golden"},
+	{"output-on-error", "output"},
+	{"resume", "checkpoint"},
+	{"transcript", "interactive"},
+}
+
+// batchIncompatibleFlags are options that act on a single analysis and so
+// can't be combined with several -input files
+var batchIncompatibleFlags = []string{"golden", "json-path", "diagnose-response", "validate-only", "echo-thought", "content-only-on-success"}
+
+// Sources a flag can be set from, in order of precedence
+const (
+	sourceCommandLine = "command line"
+	sourceEnv         = "environment"
+	sourceConfig      = "config file"
+)
+
+// flagSources records where each flag that takes effect was set
+type flagSources map[string]string
+
+// commandLineSources returns the flags given on the command line
+func commandLineSources(fs *flag.FlagSet) flagSources {
+	sources := make(flagSources)
+	fs.Visit(func(f *flag.Flag) {
+		sources[f.Name] = sourceCommandLine
+	})
+	return sources
+}
+
+// add records source for every set flag that has none yet. A flag set to its
+// default, such as "clipboard": false, has no effect and so gets no source.
+func (s flagSources) add(fs *flag.FlagSet, source string) {
+	fs.Visit(func(f *flag.Flag) {
+		if _, ok := s[f.Name]; !ok && f.Value.String() != f.DefValue {
+			s[f.Name] = source
+		}
+	})
+}
+
+// describe names a flag as it was set, for error messages
+func (s flagSources) describe(name string) string {
+	switch s[name] {
+	case sourceEnv:
+		return envVarName(name) + " from the environment"
+	case sourceConfig:
+		return "-" + name + " from the config file"
+	default:
+		return "-" + name
+	}
+}
+
+// validateFlags rejects command lines that repeat a single-valued flag or
+// combine options that would otherwise silently override each other. It checks
+// only what was given on the command line; checkFlagCombinations runs again
+// once the environment and config file are applied.
+func validateFlags(fs *flag.FlagSet, args []string) error {
+	if name := repeatedFlag(fs, args); name != "" {
+		return fmt.Errorf("-%s given more than once", name)
+	}
+	return checkFlagCombinations(fs, commandLineSources(fs))
+}
+
+// checkFlagCombinations rejects the flags in sources that conflict with each
+// other or with a thought argument, that need a flag that wasn't set or that
+// can't be used in a batch
+func checkFlagCombinations(fs *flag.FlagSet, sources flagSources) error {
+	set := func(name string) bool {
+		_, ok := sources[name]
+		return ok
+	}
+
+	for _, pair := range conflictingFlags {
+		if set(pair[0]) && set(pair[1]) {
+			return fmt.Errorf("cannot use %s and %s together", sources.describe(pair[0]), sources.describe(pair[1]))
+		}
+	}
+	if fs.NArg() > 0 {
+		for _, name := range thoughtSourceFlags {
+			if set(name) {
+				return fmt.Errorf("cannot use %s together with a thought argument", sources.describe(name))
+			}
+		}
+	}
+	if input := fs.Lookup("input"); input != nil {
+		if files, ok := input.Value.(*stringsFlag); ok && len(*files) > 1 {
+			for _, name := range batchIncompatibleFlags {
+				if set(name) {
+					return fmt.Errorf("cannot use %s with several -input files", sources.describe(name))
+				}
+			}
+		}
+	}
+	for _, pair := range dependentFlags {
+		if set(pair[0]) && !set(pair[1]) {
+			return fmt.Errorf("%s requires -%s", sources.describe(pair[0]), pair[1])
+		}
+	}
+	return nil
+}
+
+// repeatedFlag returns the first single-valued flag given more than once in
+// args, which the flag package would otherwise resolve by keeping the last value
+func repeatedFlag(fs *flag.FlagSet, args []string) string {
+	seen := make(map[string]bool)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			break // the flag package stops at the first non-flag argument
+		}
+		name := strings.TrimLeft(arg, "-")
+		name, _, hasValue := strings.Cut(name, "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && boolFlag.IsBoolFlag()) {
+			i++ // the value is the next argument
+		}
+
+		switch f.Value.(type) {
+		case *stringsFlag, headerFlag:
+			continue // repeatable
+		}
+		if seen[name] {
+			return name
+		}
+		seen[name] = true
+	}
+	return ""
+}
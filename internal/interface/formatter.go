@@ -1,38 +1,404 @@
 package interfacelayer
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"claude-think-tool/internal/domain"
 )
 
+// FormatOptions are the settings an output format renders with
+type FormatOptions struct {
+	// Canonical renders JSON with recursively sorted keys and normalized numbers
+	Canonical bool
+
+	// EscapeUnicode writes non-ASCII characters as \uXXXX escapes instead of literally
+	EscapeUnicode bool
+
+	// Color enables ANSI colors in -format pretty output
+	Color bool
+
+	// Footer appends the model, the time of formatting and the tool version
+	// to text and gh-tasks output, in TimeFormat (RFC 3339 when empty)
+	Footer     bool
+	TimeFormat string
+}
+
+// FormatFunc renders a response in one output format
+type FormatFunc func(response *domain.ThinkResponse, opts FormatOptions) (string, error)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]FormatFunc)
+)
+
+func init() {
+	builtin := map[string]FormatFunc{
+		"json":     formatJSON,
+		"minimal":  formatMinimal,
+		"ndjson":   formatNDJSON,
+		"pretty":   formatPrettyOutput,
+		"gh-tasks": formatGHTasksOutput,
+		"text":     formatText,
+	}
+	for name, fn := range builtin {
+		if err := RegisterFormat(name, fn); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterFormat adds an output format selectable by name, e.g. with -format.
+// Registering a name twice is an error, so built-in formats can't be replaced.
+func RegisterFormat(name string, fn FormatFunc) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("format needs a name and a function")
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if _, exists := formats[name]; exists {
+		return fmt.Errorf("format %q is already registered", name)
+	}
+	formats[name] = fn
+	return nil
+}
+
+// Formats returns the names of the registered output formats, sorted
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupFormat returns the function registered for a format name
+func lookupFormat(name string) (FormatFunc, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	fn, ok := formats[name]
+	return fn, ok
+}
+
 // Formatter handles formatting of responses
-type Formatter struct{}
+type Formatter struct {
+	FormatOptions
+}
 
 // NewFormatter creates a new formatter
 func NewFormatter() *Formatter {
 	return &Formatter{}
 }
 
-// FormatOutput formats the response according to the specified format
+// FormatOutput formats the response according to the specified format,
+// defaulting to JSON for formats that aren't registered
 func (f *Formatter) FormatOutput(response *domain.ThinkResponse, format string) string {
+	fn, ok := lookupFormat(format)
+	if !ok {
+		fn = formatJSON
+	}
+	output, err := fn(response, f.FormatOptions)
+	if err != nil {
+		return fmt.Sprintf("Error formatting output: %v", err)
+	}
+	return output
+}
+
+// formatJSON renders the raw response with the analysis fields added
+func formatJSON(response *domain.ThinkResponse, opts FormatOptions) (string, error) {
+	jsonBytes, err := opts.marshalJSON(jsonPayload(response))
+	return string(jsonBytes), err
+}
+
+// formatMinimal renders only the typed fields, without the raw API payload
+func formatMinimal(response *domain.ThinkResponse, opts FormatOptions) (string, error) {
+	jsonBytes, err := opts.marshalJSON(minimalOutput{
+		Thought: response.Input,
+		Content: response.Content,
+		Model:   response.Model,
+		Usage:   response.Usage,
+	})
+	return string(jsonBytes), err
+}
+
+// formatNDJSON renders the JSON payload as a single line, for consumers
+// reading one object per line
+func formatNDJSON(response *domain.ThinkResponse, opts FormatOptions) (string, error) {
+	jsonBytes, err := opts.marshalLine(jsonPayload(response))
+	return string(jsonBytes), err
+}
+
+// formatPrettyOutput renders the raw response as a (colorized) indented tree
+// for reading in a terminal
+func formatPrettyOutput(response *domain.ThinkResponse, opts FormatOptions) (string, error) {
+	return opts.formatPretty(response.Raw)
+}
+
+// formatGHTasksOutput renders concerns and recommendations as GitHub task lists
+func formatGHTasksOutput(response *domain.ThinkResponse, opts FormatOptions) (string, error) {
+	return inputHeader(response, "**Thought:** ") + formatGHTasks(response) + opts.footer(response), nil
+}
+
+// formatText returns just the extracted text content, preceded by per-chunk
+// analyses if any
+func formatText(response *domain.ThinkResponse, opts FormatOptions) (string, error) {
+	return inputHeader(response, "Thought: ") + chunkSections(response) + response.Content + pendingToolUse(response) + opts.footer(response), nil
+}
+
+// footer returns the line appended to shareable output with Footer, after a
+// horizontal rule, or nothing
+func (o FormatOptions) footer(response *domain.ThinkResponse) string {
+	if !o.Footer {
+		return ""
+	}
+	model := response.Model
+	if model == "" {
+		model = "an unknown model"
+	}
+	return fmt.Sprintf("\n\n---\nGenerated by claude-think-tool %s with %s at %s", Version, model, FormatTime(time.Now(), o.TimeFormat))
+}
+
+// ErrorRecord is written to the -output file in place of an analysis that
+// failed, with -output-on-error
+type ErrorRecord struct {
+	Error       string `json:"error"`
+	ThoughtHash string `json:"thought_hash"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// FormatError formats an error record: labeled lines for text and gh-tasks
+// output, a single line for ndjson, otherwise a JSON object
+func (f *Formatter) FormatError(record ErrorRecord, format string) string {
 	switch format {
-	case "json":
-		jsonBytes, err := json.MarshalIndent(response.Raw, "", "  ")
+	case "text", "gh-tasks":
+		return fmt.Sprintf("Error: %s\nThought hash: %s\nTimestamp: %s", record.Error, record.ThoughtHash, record.Timestamp)
+	case "ndjson":
+		line, err := f.marshalLine(record)
 		if err != nil {
 			return fmt.Sprintf("Error formatting JSON: %v", err)
 		}
-		return string(jsonBytes)
-	case "text":
-		// Just return the extracted text content
-		return response.Content
+		return string(line)
 	default:
-		// Default to JSON format
-		jsonBytes, err := json.MarshalIndent(response.Raw, "", "  ")
+		jsonBytes, err := f.marshalJSON(record)
 		if err != nil {
-			return fmt.Sprintf("Error formatting output: %v", err)
+			return fmt.Sprintf("Error formatting JSON: %v", err)
 		}
 		return string(jsonBytes)
 	}
-}
\ No newline at end of file
+}
+
+// minimalOutput is the JSON document produced by -format minimal
+type minimalOutput struct {
+	Thought string       `json:"thought,omitempty"`
+	Content string       `json:"content"`
+	Model   string       `json:"model"`
+	Usage   domain.Usage `json:"usage"`
+}
+
+// marshalJSON renders JSON output, canonically if requested. HTML characters
+// are never escaped; other non-ASCII text only with EscapeUnicode.
+func (o FormatOptions) marshalJSON(v interface{}) ([]byte, error) {
+	var jsonBytes []byte
+	if o.Canonical {
+		var err error
+		if jsonBytes, err = CanonicalJSON(v); err != nil {
+			return nil, err
+		}
+	} else {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			return nil, err
+		}
+		jsonBytes = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+
+	if o.EscapeUnicode {
+		jsonBytes = escapeNonASCII(jsonBytes)
+	}
+	return jsonBytes, nil
+}
+
+// marshalLine renders JSON output like marshalJSON, but compacted onto a
+// single line. Newlines inside strings are always escaped, so the line is a
+// complete JSON document.
+func (o FormatOptions) marshalLine(v interface{}) ([]byte, error) {
+	jsonBytes, err := o.marshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, jsonBytes); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeNonASCII rewrites every non-ASCII character of a JSON document as a
+// \uXXXX escape (a surrogate pair outside the BMP). Non-ASCII bytes can only
+// occur inside strings, so the result is equivalent JSON.
+func escapeNonASCII(jsonBytes []byte) []byte {
+	var buf bytes.Buffer
+	for _, r := range string(jsonBytes) {
+		switch {
+		case r < utf8.RuneSelf:
+			buf.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&buf, "\\u%04x", r)
+		}
+	}
+	return buf.Bytes()
+}
+
+// jsonPayload returns the raw API response extended with the fields parsed
+// by the tool, leaving response.Raw itself untouched
+func jsonPayload(response *domain.ThinkResponse) map[string]interface{} {
+	payload := make(map[string]interface{}, len(response.Raw)+5)
+	for k, v := range response.Raw {
+		payload[k] = v
+	}
+	if response.Input != "" {
+		payload["thought"] = response.Input
+	}
+	if response.RiskLevel != domain.RiskUnknown {
+		payload["risk_level"] = response.RiskLevel
+	}
+	if response.Refused {
+		payload["refused"] = true
+	}
+	if len(response.Fallacies) > 0 {
+		payload["fallacies"] = response.Fallacies
+	}
+	if len(response.Chunks) > 0 {
+		payload["chunks"] = response.Chunks
+	}
+	if response.ToolUse != nil {
+		payload["tool_use"] = response.ToolUse
+	}
+	if len(response.Timings) > 0 {
+		payload["timings"] = timingsMillis(response.Timings)
+	}
+	if response.RequestID != "" {
+		payload["request_id"] = response.RequestID
+	}
+	if len(response.Warnings) > 0 {
+		payload["warnings"] = response.Warnings
+	}
+	if len(response.ToolTrace) > 0 {
+		payload["tool_trace"] = response.ToolTrace
+	}
+	if response.Sentiment != nil {
+		payload["sentiment"] = response.Sentiment
+	}
+	if response.Structured != nil {
+		payload["structured"] = response.Structured
+	}
+	if response.ToolDecisionRationale != "" {
+		payload["tool_decision_rationale"] = response.ToolDecisionRationale
+	}
+	if response.RetryCount > 0 {
+		payload["retries"] = map[string]interface{}{
+			"count":   response.RetryCount,
+			"wait_ms": response.TotalRetryWait.Milliseconds(),
+		}
+	}
+	return payload
+}
+
+// timingsMillis converts stage durations to milliseconds keyed by stage name
+func timingsMillis(timings domain.Timings) map[string]float64 {
+	millis := make(map[string]float64, len(timings))
+	for _, timing := range timings {
+		millis[timing.Stage] = float64(timing.Duration.Microseconds()) / 1000
+	}
+	return millis
+}
+
+// FormatSummary renders a one-line summary of the model and token usage,
+// using the typed integer counts rather than the float64 values of Raw
+func FormatSummary(response *domain.ThinkResponse) string {
+	summary := fmt.Sprintf("%d input tokens, %d output tokens", response.Usage.InputTokens, response.Usage.OutputTokens)
+	if response.Model != "" {
+		summary = fmt.Sprintf("model %s, %s", response.Model, summary)
+	}
+	return summary
+}
+
+// FormatToolTraceEntry renders a tool invocation and its result on one line
+func FormatToolTraceEntry(entry domain.ToolTraceEntry) string {
+	input, _ := json.Marshal(entry.Input)
+	line := fmt.Sprintf("%s (id %s) input=%s", entry.Name, entry.ID, input)
+	switch {
+	case entry.Result == nil:
+		line += " (no tool_result sent)"
+	case entry.IsError:
+		result, _ := json.Marshal(entry.Result)
+		line += fmt.Sprintf(" error result=%s", result)
+	default:
+		result, _ := json.Marshal(entry.Result)
+		line += fmt.Sprintf(" result=%s", result)
+	}
+	return line
+}
+
+// FormatTimings renders a per-stage timing breakdown for -profile
+func FormatTimings(timings domain.Timings) string {
+	var b strings.Builder
+	b.WriteString("Timing breakdown:\n")
+	for _, timing := range timings {
+		fmt.Fprintf(&b, "  %-18s %12s\n", timing.Stage, timing.Duration.Round(time.Microsecond))
+	}
+	fmt.Fprintf(&b, "  %-18s %12s\n", "total", timings.Total().Round(time.Microsecond))
+	return b.String()
+}
+
+// chunkSections renders per-chunk analyses ahead of the synthesized content
+func chunkSections(response *domain.ThinkResponse) string {
+	if len(response.Chunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, chunk := range response.Chunks {
+		fmt.Fprintf(&b, "=== Chunk %d/%d ===\n%s\n\n", chunk.Index+1, len(response.Chunks), strings.TrimSpace(chunk.Content))
+	}
+	b.WriteString("=== Combined analysis ===\n")
+	return b.String()
+}
+
+// inputHeader renders the analyzed thought behind label as a header for the
+// text formats, or nothing when the response doesn't carry it
+func inputHeader(response *domain.ThinkResponse, label string) string {
+	if response.Input == "" {
+		return ""
+	}
+	return label + response.Input + "\n\n"
+}
+
+// pendingToolUse renders the tool invocation when the analysis stopped at it (-no-followup)
+func pendingToolUse(response *domain.ThinkResponse) string {
+	if response.StopReason != "tool_use" || response.ToolUse == nil {
+		return ""
+	}
+
+	input, err := json.MarshalIndent(response.ToolUse.Input, "", "  ")
+	if err != nil {
+		input = []byte(fmt.Sprintf("%v", response.ToolUse.Input))
+	}
+	return fmt.Sprintf("\nTool use: %s (id %s)\nInput: %s", response.ToolUse.Name, response.ToolUse.ID, input)
+}
@@ -2,8 +2,11 @@ package interfacelayer_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"claude-think-tool/internal/domain"
 	interfacelayer "claude-think-tool/internal/interface"
@@ -87,4 +90,545 @@ func TestFormatter_FormatOutput(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestFormatter_RiskLevelInJSON(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:       map[string]interface{}{"id": "msg_123"},
+		Content:   "Analysis",
+		RiskLevel: domain.RiskHigh,
+	}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if jsonObj["risk_level"] != "HIGH" {
+		t.Errorf("Expected risk_level HIGH, got %v", jsonObj["risk_level"])
+	}
+	if _, ok := response.Raw["risk_level"]; ok {
+		t.Errorf("Expected Raw to be left untouched")
+	}
+}
+
+func TestFormatter_WarningsInJSON(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:      map[string]interface{}{"id": "msg_123"},
+		Content:  "Analysis",
+		Warnings: []string{"no risk level found"},
+	}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	warnings, ok := jsonObj["warnings"].([]interface{})
+	if !ok || len(warnings) != 1 || warnings[0] != "no risk level found" {
+		t.Errorf("Expected warnings [no risk level found], got %v", jsonObj["warnings"])
+	}
+}
+
+func TestFormatter_RetriesInJSON(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:            map[string]interface{}{"id": "msg_123"},
+		Content:        "Analysis",
+		RetryCount:     2,
+		TotalRetryWait: 3 * time.Second,
+	}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	expected := map[string]interface{}{"count": float64(2), "wait_ms": float64(3000)}
+	if !reflect.DeepEqual(jsonObj["retries"], expected) {
+		t.Errorf("Expected retries %v, got %v", expected, jsonObj["retries"])
+	}
+
+	response.RetryCount, response.TotalRetryWait = 0, 0
+	jsonObj = nil
+	json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj)
+	if _, ok := jsonObj["retries"]; ok {
+		t.Errorf("Expected no retries field without retries, got %v", jsonObj["retries"])
+	}
+}
+
+func TestFormatter_SentimentInJSON(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:       map[string]interface{}{"id": "msg_123"},
+		Content:   "Analysis",
+		Sentiment: &domain.Sentiment{Label: "positive", Score: 0.5},
+	}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	expected := map[string]interface{}{"label": "positive", "score": 0.5}
+	if !reflect.DeepEqual(jsonObj["sentiment"], expected) {
+		t.Errorf("Expected sentiment %v, got %v", expected, jsonObj["sentiment"])
+	}
+}
+
+func TestFormatter_IncludeInput(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:     map[string]interface{}{"id": "msg_123"},
+		Content: "Analysis",
+		Input:   "Should we ship on Friday?",
+	}
+
+	for _, format := range []string{"json", "minimal", "ndjson"} {
+		var jsonObj map[string]interface{}
+		if err := json.Unmarshal([]byte(formatter.FormatOutput(response, format)), &jsonObj); err != nil {
+			t.Fatalf("%s: expected valid JSON, got error: %v", format, err)
+		}
+		if jsonObj["thought"] != response.Input {
+			t.Errorf("%s: expected thought %q, got %v", format, response.Input, jsonObj["thought"])
+		}
+	}
+
+	headers := map[string]string{
+		"text":     "Thought: Should we ship on Friday?\n\nAnalysis",
+		"gh-tasks": "**Thought:** Should we ship on Friday?\n\nAnalysis",
+	}
+	for format, expected := range headers {
+		if output := formatter.FormatOutput(response, format); output != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, output)
+		}
+	}
+
+	response.Input = ""
+	if output := formatter.FormatOutput(response, "text"); output != "Analysis" {
+		t.Errorf("Expected no header without an input, got %q", output)
+	}
+	var jsonObj map[string]interface{}
+	json.Unmarshal([]byte(formatter.FormatOutput(response, "minimal")), &jsonObj)
+	if _, ok := jsonObj["thought"]; ok {
+		t.Errorf("Expected no thought field without an input, got %v", jsonObj["thought"])
+	}
+}
+
+func TestFormatToolTraceEntry(t *testing.T) {
+	toolUse := domain.ToolUse{ID: "toolu_1", Name: "think", Input: map[string]interface{}{"thought": "Ship it"}}
+	tests := []struct {
+		name  string
+		entry domain.ToolTraceEntry
+		want  string
+	}{
+		{name: "result", entry: domain.ToolTraceEntry{ToolUse: toolUse, Result: "Sound"}, want: `think (id toolu_1) input={"thought":"Ship it"} result="Sound"`},
+		{name: "error result", entry: domain.ToolTraceEntry{ToolUse: toolUse, Result: "Retry", IsError: true}, want: `think (id toolu_1) input={"thought":"Ship it"} error result="Retry"`},
+		{name: "no result", entry: domain.ToolTraceEntry{ToolUse: toolUse}, want: `think (id toolu_1) input={"thought":"Ship it"} (no tool_result sent)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interfacelayer.FormatToolTraceEntry(tt.entry); got != tt.want {
+				t.Errorf("FormatToolTraceEntry() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{Raw: map[string]interface{}{}, ToolTrace: []domain.ToolTraceEntry{tests[0].entry}}
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	expected := []interface{}{map[string]interface{}{"id": "toolu_1", "name": "think", "input": map[string]interface{}{"thought": "Ship it"}, "result": "Sound"}}
+	if !reflect.DeepEqual(jsonObj["tool_trace"], expected) {
+		t.Errorf("Expected tool_trace %v, got %v", expected, jsonObj["tool_trace"])
+	}
+}
+
+func TestFormatter_Chunks(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:     map[string]interface{}{"id": "msg_123"},
+		Content: "Combined",
+		Chunks: []domain.ChunkResult{
+			{Index: 0, Thought: "part one", Content: "First chunk analysis"},
+			{Index: 1, Thought: "part two", Content: "Second chunk analysis"},
+		},
+	}
+
+	text := formatter.FormatOutput(response, "text")
+	for _, want := range []string{"=== Chunk 1/2 ===", "Second chunk analysis", "=== Combined analysis ===\nCombined"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected text output to contain %q, got %q", want, text)
+		}
+	}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if chunks, _ := jsonObj["chunks"].([]interface{}); len(chunks) != 2 {
+		t.Errorf("Expected 2 chunks in JSON output, got %v", jsonObj["chunks"])
+	}
+}
+
+func TestFormatter_PendingToolUse(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	toolUse := &domain.ToolUse{ID: "tu_123", Name: "think", Input: map[string]interface{}{"thought": "Restated"}}
+
+	pending := &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Let me think.", StopReason: "tool_use", ToolUse: toolUse}
+	text := formatter.FormatOutput(pending, "text")
+	if !strings.Contains(text, "Tool use: think (id tu_123)") || !strings.Contains(text, `"thought": "Restated"`) {
+		t.Errorf("Expected pending tool use in text output, got %q", text)
+	}
+
+	completed := &domain.ThinkResponse{Raw: map[string]interface{}{}, Content: "Final answer", StopReason: "end_turn", ToolUse: toolUse}
+	if text := formatter.FormatOutput(completed, "text"); text != "Final answer" {
+		t.Errorf("Expected only the content once the tool cycle completed, got %q", text)
+	}
+}
+
+func TestFormatter_Timings(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	timings := domain.Timings{
+		{Stage: "prompt_build", Duration: 250 * time.Microsecond},
+		{Stage: "initial_request", Duration: 1500 * time.Millisecond},
+	}
+	response := &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg_123"}, Content: "Analysis", Timings: timings}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "json")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	want := map[string]interface{}{"prompt_build": 0.25, "initial_request": 1500.0}
+	if !reflect.DeepEqual(jsonObj["timings"], want) {
+		t.Errorf("timings = %v, want %v", jsonObj["timings"], want)
+	}
+
+	breakdown := interfacelayer.FormatTimings(timings)
+	for _, want := range []string{"Timing breakdown:", "prompt_build", "250µs", "initial_request", "1.5s", "total", "1.50025s"} {
+		if !strings.Contains(breakdown, want) {
+			t.Errorf("Expected breakdown to contain %q, got %q", want, breakdown)
+		}
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	// Token counts decoded from JSON are float64 in Raw; the summary uses the typed ints
+	response := &domain.ThinkResponse{
+		Raw:   map[string]interface{}{"usage": map[string]interface{}{"input_tokens": 120.0, "output_tokens": 45.0}},
+		Model: "claude-3-7-sonnet-20250219",
+		Usage: domain.Usage{InputTokens: 120, OutputTokens: 45},
+	}
+
+	summary := interfacelayer.FormatSummary(response)
+	want := "model claude-3-7-sonnet-20250219, 120 input tokens, 45 output tokens"
+	if summary != want {
+		t.Errorf("FormatSummary() = %q, want %q", summary, want)
+	}
+	if strings.Contains(summary, "120.000000") {
+		t.Errorf("Expected integral token counts, got %q", summary)
+	}
+}
+
+func TestFormatter_Minimal(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{
+		Raw:       map[string]interface{}{"id": "msg_123", "content": []interface{}{"large payload"}},
+		Content:   "Analysis",
+		Model:     "claude-3-7-sonnet-20250219",
+		Usage:     domain.Usage{InputTokens: 120, OutputTokens: 45},
+		RiskLevel: domain.RiskLow,
+	}
+
+	var jsonObj map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatOutput(response, "minimal")), &jsonObj); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"content": "Analysis",
+		"model":   "claude-3-7-sonnet-20250219",
+		"usage":   map[string]interface{}{"input_tokens": 120.0, "output_tokens": 45.0},
+	}
+	if !reflect.DeepEqual(jsonObj, want) {
+		t.Errorf("minimal output = %v, want %v", jsonObj, want)
+	}
+}
+
+func TestFormatter_NDJSON(t *testing.T) {
+	response := &domain.ThinkResponse{
+		Raw:       map[string]interface{}{"id": "msg_123", "content": []interface{}{map[string]interface{}{"type": "text", "text": "Line one\nLine two"}}},
+		Content:   "Line one\nLine two",
+		RiskLevel: domain.RiskLow,
+	}
+
+	for _, canonical := range []bool{false, true} {
+		formatter := interfacelayer.NewFormatter()
+		formatter.Canonical = canonical
+		output := formatter.FormatOutput(response, "ndjson")
+
+		if strings.Contains(output, "\n") {
+			t.Fatalf("Expected a single line (canonical %v), got %q", canonical, output)
+		}
+		var jsonObj map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &jsonObj); err != nil {
+			t.Fatalf("Expected valid JSON, got error: %v", err)
+		}
+		if jsonObj["id"] != "msg_123" || jsonObj["risk_level"] != "LOW" {
+			t.Errorf("Expected the JSON payload, got %v", jsonObj)
+		}
+	}
+}
+
+func TestFormatter_GHTasks(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+
+	response := &domain.ThinkResponse{
+		Content:   "I've analyzed the thought.\n\nStrengths:\n- Clear goal\n\nConcerns:\n- Security testing is incomplete\n- No rollback plan\n\nRecommendation:\n- Finish security testing first\n\nRisk level: HIGH",
+		RiskLevel: domain.RiskHigh,
+	}
+	want := `**Risk level:** HIGH
+
+### Concerns
+
+- [ ] Security testing is incomplete
+- [ ] No rollback plan
+
+### Recommendation
+
+- [ ] Finish security testing first
+
+<details>
+<summary>Strengths</summary>
+
+- Clear goal
+
+</details>`
+	if got := formatter.FormatOutput(response, "gh-tasks"); got != want {
+		t.Errorf("gh-tasks output =\n%s\nwant\n%s", got, want)
+	}
+
+	// Without sections there is nothing to turn into tasks
+	plain := &domain.ThinkResponse{Content: "The plan looks fine."}
+	if got := formatter.FormatOutput(plain, "gh-tasks"); got != plain.Content {
+		t.Errorf("Expected unsectioned content unchanged, got %q", got)
+	}
+}
+
+func TestFormatter_UnicodeEscaping(t *testing.T) {
+	response := &domain.ThinkResponse{
+		Raw:     map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": "日本はかっこいい <b>&</b> 🎌"}}},
+		Content: "日本はかっこいい <b>&</b> 🎌",
+		Model:   "claude-3-7-sonnet-20250219",
+	}
+
+	tests := []struct {
+		name          string
+		escapeUnicode bool
+		canonical     bool
+		format        string
+		wantContains  string
+		wantAbsent    string
+	}{
+		{name: "literal by default", format: "json", wantContains: "日本はかっこいい <b>&</b> 🎌", wantAbsent: `\u`},
+		{name: "literal minimal", format: "minimal", wantContains: "日本はかっこいい <b>&</b> 🎌", wantAbsent: `\u`},
+		{name: "literal canonical", format: "json", canonical: true, wantContains: "日本はかっこいい", wantAbsent: `\u`},
+		{name: "escaped", format: "json", escapeUnicode: true, wantContains: `\u65e5\u672c`, wantAbsent: "日本"},
+		{name: "escaped outside the BMP", format: "minimal", escapeUnicode: true, wantContains: `\ud83c\udf8c`, wantAbsent: "🎌"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := interfacelayer.NewFormatter()
+			formatter.EscapeUnicode = tt.escapeUnicode
+			formatter.Canonical = tt.canonical
+			output := formatter.FormatOutput(response, tt.format)
+
+			if !strings.Contains(output, tt.wantContains) {
+				t.Errorf("Expected output to contain %q, got %s", tt.wantContains, output)
+			}
+			if strings.Contains(output, tt.wantAbsent) {
+				t.Errorf("Expected output not to contain %q, got %s", tt.wantAbsent, output)
+			}
+
+			// Escaped or not, the document must decode to the same text
+			var decoded map[string]interface{}
+			if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+				t.Fatalf("Expected valid JSON, got error: %v", err)
+			}
+			if tt.format == "minimal" && decoded["content"] != response.Content {
+				t.Errorf("Decoded content = %q, want %q", decoded["content"], response.Content)
+			}
+		})
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	// The registry is global, so the name must be new on every run of the test
+	name := fmt.Sprintf("upper-%d", time.Now().UnixNano())
+	var gotOptions interfacelayer.FormatOptions
+	upper := func(response *domain.ThinkResponse, opts interfacelayer.FormatOptions) (string, error) {
+		gotOptions = opts
+		return strings.ToUpper(response.Content), nil
+	}
+	if err := interfacelayer.RegisterFormat(name, upper); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	formatter := interfacelayer.NewFormatter()
+	formatter.Canonical = true
+	response := &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg_123"}, Content: "Analysis"}
+	if output := formatter.FormatOutput(response, name); output != "ANALYSIS" {
+		t.Errorf("Expected the registered format to be used, got %q", output)
+	}
+	if !gotOptions.Canonical {
+		t.Errorf("Expected the formatter's options to be passed, got %+v", gotOptions)
+	}
+
+	found := false
+	for _, format := range interfacelayer.Formats() {
+		found = found || format == name
+	}
+	if !found {
+		t.Errorf("Expected %s in %v", name, interfacelayer.Formats())
+	}
+
+	errorTests := []struct {
+		name   string
+		format string
+		fn     interfacelayer.FormatFunc
+	}{
+		{name: "duplicate", format: name, fn: upper},
+		{name: "built-in", format: "json", fn: upper},
+		{name: "no name", format: "", fn: upper},
+		{name: "no function", format: name + "-nil", fn: nil},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := interfacelayer.RegisterFormat(tt.format, tt.fn); err == nil {
+				t.Errorf("Expected an error registering %q", tt.format)
+			}
+		})
+	}
+}
+
+func TestFormatter_Dispatch(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	response := &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg_123"}, Content: "Analysis"}
+
+	for _, format := range []string{"gh-tasks", "json", "minimal", "ndjson", "pretty", "text"} {
+		found := false
+		for _, registered := range interfacelayer.Formats() {
+			found = found || registered == format
+		}
+		if !found {
+			t.Errorf("Expected built-in format %s to be registered", format)
+		}
+	}
+
+	if output := formatter.FormatOutput(response, "text"); output != "Analysis" {
+		t.Errorf("Expected text output, got %q", output)
+	}
+	if unknown, json := formatter.FormatOutput(response, "xml"), formatter.FormatOutput(response, "json"); unknown != json {
+		t.Errorf("Expected an unknown format to fall back to JSON, got %q", unknown)
+	}
+}
+
+func TestFormatter_FormatBatch(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	results := []interfacelayer.BatchResult{
+		{Index: 0, Input: "a.txt", Thought: "Thought a", Response: &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg_a"}, Content: "Analysis of a"}, Latency: 1500 * time.Millisecond},
+		{Index: 1, Input: "b.txt", Thought: "Thought b", Error: "rate limited", Latency: 20 * time.Millisecond},
+		{Index: 2, Input: "c.txt", Response: &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg_c"}, Content: "Analysis of c"}},
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal([]byte(formatter.FormatBatch(results, "json")), &items); err != nil {
+		t.Fatalf("Expected a JSON array, got error: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+
+	if items[0]["index"] != 0.0 || items[0]["latency_ms"] != 1500.0 || items[0]["error"] != nil {
+		t.Errorf("Unexpected success item %v", items[0])
+	}
+	if result, ok := items[0]["result"].(map[string]interface{}); !ok || result["id"] != "msg_a" {
+		t.Errorf("Expected the payload as result, got %v", items[0]["result"])
+	}
+
+	if items[1]["index"] != 1.0 || items[1]["error"] != "rate limited" || items[1]["result"] != nil {
+		t.Errorf("Expected the failure inline, got %v", items[1])
+	}
+	if items[0]["thought"] != "Thought a" || items[1]["thought"] != "Thought b" {
+		t.Errorf("Expected each item's thought, got %v and %v", items[0]["thought"], items[1]["thought"])
+	}
+	if items[1]["latency_ms"] != 20.0 {
+		t.Errorf("Expected the failure's latency, got %v", items[1]["latency_ms"])
+	}
+
+	// An input restored from a checkpoint wasn't timed in this run
+	if _, ok := items[2]["thought"]; ok {
+		t.Errorf("Expected no thought for an item without one, got %v", items[2])
+	}
+	if _, ok := items[2]["latency_ms"]; ok || items[2]["index"] != 2.0 {
+		t.Errorf("Expected no latency for a restored item, got %v", items[2])
+	}
+
+	lines := strings.Split(formatter.FormatBatch(results, "ndjson"), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"error":"rate limited"`) || !strings.Contains(lines[1], `"index":1`) {
+		t.Errorf("Expected one line per item with the failure inline, got %q", lines)
+	}
+}
+
+func TestFormatter_FormatError(t *testing.T) {
+	formatter := interfacelayer.NewFormatter()
+	record := interfacelayer.ErrorRecord{Error: "rate limited", ThoughtHash: "abc123", Timestamp: "2024-01-02T03:04:05Z"}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "ndjson", want: `{"error":"rate limited","thought_hash":"abc123","timestamp":"2024-01-02T03:04:05Z"}`},
+		{format: "text", want: "Error: rate limited\nThought hash: abc123\nTimestamp: 2024-01-02T03:04:05Z"},
+		{format: "json", want: "{\n  \"error\": \"rate limited\",\n  \"thought_hash\": \"abc123\",\n  \"timestamp\": \"2024-01-02T03:04:05Z\"\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := formatter.FormatError(record, tt.format); got != tt.want {
+				t.Errorf("FormatError(%s) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatter_Footer(t *testing.T) {
+	response := &domain.ThinkResponse{Raw: map[string]interface{}{"id": "msg_1"}, Content: "Concerns:\n- No tests", Model: "claude-test"}
+	wantFooter := "Generated by claude-think-tool " + interfacelayer.Version + " with claude-test at "
+
+	tests := []struct {
+		name       string
+		footer     bool
+		format     string
+		wantFooter bool
+	}{
+		{name: "text without footer", format: "text"},
+		{name: "text with footer", footer: true, format: "text", wantFooter: true},
+		{name: "gh-tasks with footer", footer: true, format: "gh-tasks", wantFooter: true},
+		{name: "json stays clean", footer: true, format: "json"},
+		{name: "minimal stays clean", footer: true, format: "minimal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := interfacelayer.NewFormatter()
+			formatter.Footer = tt.footer
+			output := formatter.FormatOutput(response, tt.format)
+			if got := strings.Contains(output, wantFooter); got != tt.wantFooter {
+				t.Errorf("Footer in %s output = %v, want %v:\n%s", tt.format, got, tt.wantFooter, output)
+			}
+		})
+	}
+}
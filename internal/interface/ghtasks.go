@@ -0,0 +1,43 @@
+package interfacelayer
+
+import (
+	"fmt"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// formatGHTasks renders an analysis as GitHub-flavored Markdown for pasting
+// into an issue: concerns and recommendations become task lists and the
+// strengths a collapsed section. Content without those sections is returned
+// unchanged.
+func formatGHTasks(response *domain.ThinkResponse) string {
+	sections := domain.ParseSections(response.Content)
+	if len(sections.Concerns) == 0 && len(sections.Recommendation) == 0 {
+		return response.Content
+	}
+
+	var blocks []string
+	if response.RiskLevel != domain.RiskUnknown {
+		blocks = append(blocks, fmt.Sprintf("**Risk level:** %s", response.RiskLevel))
+	}
+	if len(sections.Concerns) > 0 {
+		blocks = append(blocks, "### Concerns\n\n"+markdownList(sections.Concerns, "- [ ] "))
+	}
+	if len(sections.Recommendation) > 0 {
+		blocks = append(blocks, "### Recommendation\n\n"+markdownList(sections.Recommendation, "- [ ] "))
+	}
+	if len(sections.Strengths) > 0 {
+		blocks = append(blocks, "<details>\n<summary>Strengths</summary>\n\n"+markdownList(sections.Strengths, "- ")+"\n\n</details>")
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// markdownList renders items one per line behind marker
+func markdownList(items []string, marker string) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = marker + item
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+package interfacelayer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// EditDistanceRatio returns the Levenshtein distance between a and b, counted
+// in runes and divided by the length of the longer text: 0 for identical texts
+// and 1 for completely different ones
+func EditDistanceRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	// Two rows of the dynamic programming table are enough
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return float64(prev[len(rb)]) / float64(longest)
+}
+
+// LineDiff renders the line differences between want and got, prefixing
+// removed lines with "-", added lines with "+" and common lines with " "
+func LineDiff(want, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return diff.String()
+}
+
+// checkGolden compares content with the golden file at path, or replaces the
+// golden file with it when update is set
+func (c *CLI) checkGolden(path, content string, update bool, tolerance float64) int {
+	content = strings.TrimSpace(content)
+	if update {
+		if err := c.fileStorage.WriteToFile(path, content+"\n"); err != nil {
+			log.Printf("Error writing golden file: %v", err)
+			return ExitError
+		}
+		fmt.Fprintf(os.Stderr, "Golden file %s updated\n", path)
+		return ExitOK
+	}
+
+	golden, err := c.fileStorage.ReadFromFile(path)
+	if err != nil {
+		log.Printf("Error reading golden file (create it with -golden-update): %v", err)
+		return ExitError
+	}
+	golden = strings.TrimSpace(golden)
+
+	distance := EditDistanceRatio(golden, content)
+	if distance > tolerance {
+		log.Printf("Output differs from golden file %s (distance %.3f > tolerance %.3f)", path, distance, tolerance)
+		fmt.Fprint(os.Stderr, LineDiff(golden, content))
+		return ExitGoldenMismatch
+	}
+	return ExitOK
+}
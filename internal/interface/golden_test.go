@@ -0,0 +1,37 @@
+package interfacelayer_test
+
+import (
+	"math"
+	"testing"
+
+	interfacelayer "claude-think-tool/internal/interface"
+)
+
+func TestEditDistanceRatio(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected float64
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "same", b: "same", expected: 0},
+		{a: "kitten", b: "sitting", expected: 3.0 / 7},
+		{a: "abc", b: "", expected: 1},
+		{a: "café", b: "cafe", expected: 0.25},
+	}
+
+	for _, tt := range tests {
+		if got := interfacelayer.EditDistanceRatio(tt.a, tt.b); math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("EditDistanceRatio(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	want := "Strengths\n- clear goal\nRisk level: LOW"
+	got := "Strengths\n- clear goal\n- data\nRisk level: MEDIUM"
+
+	expected := " Strengths\n - clear goal\n-Risk level: LOW\n+- data\n+Risk level: MEDIUM\n"
+	if diff := interfacelayer.LineDiff(want, got); diff != expected {
+		t.Errorf("LineDiff() =\n%s\nwant\n%s", diff, expected)
+	}
+}
@@ -2,8 +2,12 @@ package interfacelayer_test
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -175,4 +179,165 @@ func TestInteractiveModeWithScannerInput(t *testing.T) {
 	
 	// Close stdout to allow output reader to complete
 	stdoutWriter.Close()
-}
\ No newline at end of file
+}
+
+// runInteractiveSession feeds lines to interactive mode, waits for it to
+// finish and returns what it printed
+func runInteractiveSession(t *testing.T, cli *interfacelayer.CLI, lines string) string {
+	t.Helper()
+
+	oldStdin := os.Stdin
+	oldStdout := os.Stdout
+	defer func() {
+		os.Stdin = oldStdin
+		os.Stdout = oldStdout
+	}()
+
+	stdinReader, stdinWriter, _ := os.Pipe()
+	stdoutReader, stdoutWriter, _ := os.Pipe()
+	os.Stdin = stdinReader
+	os.Stdout = stdoutWriter
+	var output bytes.Buffer
+	copied := make(chan bool)
+	go func() {
+		io.Copy(&output, stdoutReader)
+		copied <- true
+	}()
+
+	// All lines arrive at once, so the session must not lose buffered input
+	stdinWriter.Write([]byte(lines))
+	stdinWriter.Close()
+
+	done := make(chan bool)
+	go func() {
+		cli.RunInteractiveMode(context.Background(), domain.Config{OutputFormat: "text"})
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test timed out")
+	}
+	stdoutWriter.Close()
+	<-copied
+	return output.String()
+}
+
+func TestInteractiveModeTranscript(t *testing.T) {
+	var transcript strings.Builder
+	var sizes []int
+	storage := &unit.MockFileStorage{
+		AppendToFileFunc: func(filePath string, content string) error {
+			if filePath != "session.md" {
+				t.Errorf("Expected transcript path session.md, got %q", filePath)
+			}
+			transcript.WriteString(content)
+			sizes = append(sizes, transcript.Len())
+			return nil
+		},
+	}
+
+	calls := 0
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			// Each earlier turn must already be on disk before the next one starts
+			if len(sizes) != calls {
+				t.Errorf("Expected %d saved turns before analyzing %q, got %d", calls, thought, len(sizes))
+			}
+			calls++
+			return &domain.ThinkResponse{Content: "Response for: " + thought}, nil
+		},
+	}
+
+	cli := interfacelayer.NewCLI(service, storage, interfacelayer.NewFormatter())
+	cli.SetTranscript("session.md")
+	runInteractiveSession(t, cli, "thought 1\nthought 2\nexit\n")
+
+	if calls != 2 {
+		t.Fatalf("Expected 2 calls to AnalyzeThought, got %d", calls)
+	}
+	if len(sizes) != 2 || sizes[1] <= sizes[0] {
+		t.Fatalf("Expected the transcript to grow after each turn, got sizes %v", sizes)
+	}
+	for _, want := range []string{"> thought 1", "Response for: thought 1", "> thought 2", "Response for: thought 2"} {
+		if !strings.Contains(transcript.String(), want) {
+			t.Errorf("Expected transcript to contain %q, got:\n%s", want, transcript.String())
+		}
+	}
+}
+
+func TestInteractiveModeTranscriptWriteFailure(t *testing.T) {
+	storage := &unit.MockFileStorage{
+		AppendToFileFunc: func(filePath string, content string) error {
+			return errors.New("disk full")
+		},
+	}
+
+	calls := 0
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			calls++
+			return &domain.ThinkResponse{Content: "ok"}, nil
+		},
+	}
+
+	cli := interfacelayer.NewCLI(service, storage, interfacelayer.NewFormatter())
+	cli.SetTranscript("session.md")
+	runInteractiveSession(t, cli, "thought 1\nthought 2\nexit\n")
+
+	// A failed save is only a warning; the session keeps going
+	if calls != 2 {
+		t.Errorf("Expected 2 calls to AnalyzeThought despite write failures, got %d", calls)
+	}
+}
+
+func TestInteractiveModeSave(t *testing.T) {
+	written := map[string]string{}
+	storage := &unit.MockFileStorage{
+		WriteToFileFunc: func(filePath string, content string) error {
+			written[filePath] = content
+			return nil
+		},
+	}
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			return &domain.ThinkResponse{Content: "Response for: " + thought}, nil
+		},
+	}
+
+	cli := interfacelayer.NewCLI(service, storage, interfacelayer.NewFormatter())
+	runInteractiveSession(t, cli, "/save early.txt\nthought 1\nthought 2\n/save\n/save result.txt\nexit\n")
+
+	// Nothing is written before the first analysis or without a path
+	if len(written) != 1 {
+		t.Fatalf("Expected exactly one file to be written, got %v", written)
+	}
+	if got := written["result.txt"]; !strings.Contains(got, "Response for: thought 2") || strings.Contains(got, "thought 1") {
+		t.Errorf("Expected result.txt to hold the last result, got %q", got)
+	}
+}
+
+func TestInteractiveModeEOF(t *testing.T) {
+	calls := 0
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			calls++
+			return &domain.ThinkResponse{Content: "Response for: " + thought}, nil
+		},
+	}
+
+	cli := interfacelayer.NewCLI(service, &unit.MockFileStorage{}, interfacelayer.NewFormatter())
+	// No "exit": stdin is closed after the thought, as with Ctrl-D
+	output := runInteractiveSession(t, cli, "thought 1\n")
+
+	if calls != 1 {
+		t.Errorf("Expected 1 call to AnalyzeThought, got %d", calls)
+	}
+	if strings.Count(output, "> ") != 2 {
+		t.Errorf("Expected the prompt twice before EOF, got:\n%s", output)
+	}
+	if !strings.HasSuffix(output, "> \nGoodbye!\n") {
+		t.Errorf("Expected the session to end with Goodbye! on its own line, got:\n%s", output)
+	}
+}
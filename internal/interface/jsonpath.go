@@ -0,0 +1,65 @@
+package interfacelayer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ExtractJSONPath navigates data by a dot-separated path of map keys and
+// array indices (e.g. "content.0.text") and returns the value found there
+func ExtractJSONPath(data interface{}, path string) (interface{}, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("empty JSON path")
+	}
+
+	segments := strings.Split(path, ".")
+	current := data
+	for i, segment := range segments {
+		traversed := strings.Join(segments[:i], ".")
+		if traversed == "" {
+			traversed = "<root>"
+		}
+
+		value := reflect.ValueOf(current)
+		switch value.Kind() {
+		case reflect.Map:
+			if value.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("path %q: %s is not an object", path, traversed)
+			}
+			entry := value.MapIndex(reflect.ValueOf(segment).Convert(value.Type().Key()))
+			if !entry.IsValid() {
+				return nil, fmt.Errorf("path %q: key %q not found in %s", path, segment, traversed)
+			}
+			current = entry.Interface()
+		case reflect.Slice, reflect.Array:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %s is an array, %q is not an index", path, traversed, segment)
+			}
+			if index < 0 || index >= value.Len() {
+				return nil, fmt.Errorf("path %q: index %d out of range for %s (length %d)", path, index, traversed, value.Len())
+			}
+			current = value.Index(index).Interface()
+		default:
+			return nil, fmt.Errorf("path %q: cannot descend into %s with %q", path, traversed, segment)
+		}
+	}
+
+	return current, nil
+}
+
+// FormatJSONPathValue renders an extracted value for printing: strings are
+// printed as-is, everything else as indented JSON
+func FormatJSONPathValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	jsonBytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format value: %w", err)
+	}
+	return string(jsonBytes), nil
+}
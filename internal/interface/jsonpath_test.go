@@ -0,0 +1,81 @@
+package interfacelayer_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	interfacelayer "claude-think-tool/internal/interface"
+)
+
+func TestExtractJSONPath(t *testing.T) {
+	var raw map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"id": "msg_123",
+		"content": [
+			{"type": "text", "text": "First block"},
+			{"type": "tool_use", "input": {"thought": "Restated"}}
+		],
+		"usage": {"input_tokens": 120, "output_tokens": 45}
+	}`), &raw)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		want        string
+		expectError string
+	}{
+		{name: "top-level key", path: "id", want: "msg_123"},
+		{name: "array index then key", path: "content.0.text", want: "First block"},
+		{name: "nested object", path: "content.1.input.thought", want: "Restated"},
+		{name: "non-string value", path: "usage.input_tokens", want: "120"},
+		{name: "object value", path: "usage", want: `"output_tokens": 45`},
+		{name: "missing key", path: "content.0.missing", expectError: `key "missing" not found in content.0`},
+		{name: "index out of range", path: "content.5", expectError: "index 5 out of range"},
+		{name: "non-numeric index", path: "content.first", expectError: `"first" is not an index`},
+		{name: "descend into scalar", path: "id.more", expectError: "cannot descend into id"},
+		{name: "empty path", path: "", expectError: "empty JSON path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := interfacelayer.ExtractJSONPath(raw, tt.path)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			output, err := interfacelayer.FormatJSONPathValue(value)
+			if err != nil {
+				t.Fatalf("Unexpected format error: %v", err)
+			}
+			if !strings.Contains(output, tt.want) {
+				t.Errorf("Expected output to contain %q, got %q", tt.want, output)
+			}
+		})
+	}
+}
+
+func TestExtractJSONPath_TypedSlices(t *testing.T) {
+	raw := map[string]interface{}{
+		"content": []map[string]interface{}{
+			{"type": "text", "text": "Typed slice"},
+		},
+	}
+
+	value, err := interfacelayer.ExtractJSONPath(raw, "content.0.text")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != "Typed slice" {
+		t.Errorf("Expected %q, got %v", "Typed slice", value)
+	}
+}
@@ -0,0 +1,105 @@
+package interfacelayer
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// genericJSON round-trips v through JSON so structs, typed slices and maps
+// all become generic values with json.Number leaves
+func genericJSON(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// jsonScalar encodes a string, boolean or null without HTML escaping
+func jsonScalar(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonTree writes generic JSON values as trees indented by two spaces, with
+// object keys sorted
+type jsonTree struct {
+	key  func(encoded string) string         // styles an encoded key; nil leaves it as is
+	leaf func(v interface{}) (string, error) // renders values other than objects and arrays
+}
+
+// write writes v at the given nesting depth
+func (t jsonTree) write(buf *bytes.Buffer, v interface{}, depth int) error {
+	indent := strings.Repeat("  ", depth+1)
+	closing := strings.Repeat("  ", depth)
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("{\n")
+		for i, k := range keys {
+			key, err := jsonScalar(k)
+			if err != nil {
+				return err
+			}
+			if t.key != nil {
+				key = t.key(key)
+			}
+			buf.WriteString(indent + key + ": ")
+			if err := t.write(buf, value[k], depth+1); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(closing + "}")
+	case []interface{}:
+		if len(value) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range value {
+			buf.WriteString(indent)
+			if err := t.write(buf, item, depth+1); err != nil {
+				return err
+			}
+			if i < len(value)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(closing + "]")
+	default:
+		leaf, err := t.leaf(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(leaf)
+	}
+	return nil
+}
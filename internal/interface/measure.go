@@ -0,0 +1,109 @@
+package interfacelayer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"claude-think-tool/internal/domain"
+)
+
+// MeasureReport aggregates the latency and token usage of -measure iterations
+type MeasureReport struct {
+	Iterations int
+	Failed     int
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+
+	AverageInputTokens  float64
+	AverageOutputTokens float64
+}
+
+// LatencyPercentile returns the p-th percentile (0-100) of latencies by the
+// nearest-rank method, or 0 when there are none. latencies must be sorted.
+func LatencyPercentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(latencies) {
+		rank = len(latencies)
+	}
+	return latencies[rank-1]
+}
+
+// runMeasure analyzes the thoughts in turn, iterations times in all, and
+// prints latency percentiles and average token usage instead of the content.
+// Failed iterations are counted but don't stop the others.
+func (c *CLI) runMeasure(config domain.Config, thoughts []string, iterations int) int {
+	clock := c.clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	var latencies []time.Duration
+	var usage domain.Usage
+	failed := 0
+	for i := 0; i < iterations; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+		started := clock()
+		response, err := c.thinkService.AnalyzeThought(ctx, thoughts[i%len(thoughts)], config)
+		elapsed := clock().Sub(started)
+		cancel()
+		if err != nil {
+			log.Printf("Iteration %d failed: %v", i+1, err)
+			failed++
+			continue
+		}
+		latencies = append(latencies, elapsed)
+		usage = usage.Add(response.Usage)
+	}
+
+	report := MeasureReport{Iterations: iterations, Failed: failed}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.P50 = LatencyPercentile(latencies, 50)
+	report.P90 = LatencyPercentile(latencies, 90)
+	report.P99 = LatencyPercentile(latencies, 99)
+	if succeeded := len(latencies); succeeded > 0 {
+		report.AverageInputTokens = float64(usage.InputTokens) / float64(succeeded)
+		report.AverageOutputTokens = float64(usage.OutputTokens) / float64(succeeded)
+	}
+
+	if config.OutputFormat == "json" {
+		jsonBytes, err := c.formatter.marshalJSON(map[string]interface{}{
+			"iterations": report.Iterations,
+			"failed":     report.Failed,
+			"latency_ms": map[string]interface{}{
+				"p50": report.P50.Milliseconds(),
+				"p90": report.P90.Milliseconds(),
+				"p99": report.P99.Milliseconds(),
+			},
+			"average_usage": map[string]interface{}{
+				"input_tokens":  report.AverageInputTokens,
+				"output_tokens": report.AverageOutputTokens,
+			},
+		})
+		if err != nil {
+			log.Printf("Error formatting measurements: %v", err)
+			return ExitError
+		}
+		fmt.Println(string(jsonBytes))
+	} else {
+		fmt.Printf("Iterations: %d (%d failed)\n", report.Iterations, report.Failed)
+		fmt.Printf("Latency: p50 %s, p90 %s, p99 %s\n", report.P50, report.P90, report.P99)
+		fmt.Printf("Average tokens: %.1f input, %.1f output\n", report.AverageInputTokens, report.AverageOutputTokens)
+	}
+
+	if failed > 0 {
+		log.Printf("Error: %d of %d iterations failed", failed, iterations)
+		return ExitError
+	}
+	return ExitOK
+}
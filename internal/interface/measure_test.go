@@ -0,0 +1,156 @@
+package interfacelayer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+	interfacelayer "claude-think-tool/internal/interface"
+	"claude-think-tool/test/unit"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 0, 10)
+	for i := 1; i <= 10; i++ {
+		latencies = append(latencies, time.Duration(i*10)*time.Millisecond)
+	}
+
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		p         float64
+		want      time.Duration
+	}{
+		{name: "p50", latencies: latencies, p: 50, want: 50 * time.Millisecond},
+		{name: "p90", latencies: latencies, p: 90, want: 90 * time.Millisecond},
+		{name: "p99", latencies: latencies, p: 99, want: 100 * time.Millisecond},
+		{name: "p0 is the minimum", latencies: latencies, p: 0, want: 10 * time.Millisecond},
+		{name: "single value", latencies: latencies[:1], p: 99, want: 10 * time.Millisecond},
+		{name: "no values", latencies: nil, p: 50, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interfacelayer.LatencyPercentile(tt.latencies, tt.p); got != tt.want {
+				t.Errorf("LatencyPercentile(p%.0f) = %s, want %s", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeClock is a time source that only moves when advanced
+type fakeClock struct {
+	now time.Time
+}
+
+// Now returns the current fake time
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// variedLatencyService takes the given latencies in turn, by advancing clock,
+// and responds with 100 input and 200 output tokens per analysis. Its stage
+// timings cover only part of each latency, as a real service's do.
+func variedLatencyService(latencies []time.Duration, thoughts *[]string, clock *fakeClock) *unit.MockThinkService {
+	calls := 0
+	return &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			latency := latencies[calls%len(latencies)]
+			calls++
+			if latency > 0 {
+				clock.now = clock.now.Add(latency)
+			}
+			if thoughts != nil {
+				*thoughts = append(*thoughts, thought)
+			}
+			if latency < 0 {
+				return nil, errors.New("request failed")
+			}
+			return &domain.ThinkResponse{
+				Raw:     map[string]interface{}{},
+				Content: "Analysis that must not be printed",
+				Usage:   domain.Usage{InputTokens: 100, OutputTokens: 200},
+				Timings: domain.Timings{{Stage: "initial_request", Duration: latency / 2}},
+			}, nil
+		},
+	}
+}
+
+func TestCLI_Measure(t *testing.T) {
+	// Shuffled 10ms..100ms, so the percentiles depend on sorting
+	latencies := []time.Duration{70, 20, 100, 40, 10, 90, 30, 60, 80, 50}
+	for i := range latencies {
+		latencies[i] *= time.Millisecond
+	}
+
+	clock := &fakeClock{now: time.Now()}
+	code, output := runCLIWithSetup(t, []string{"program", "-apikey=test-key", "-measure=10", "Some thought"}, variedLatencyService(latencies, nil, clock), nil, func(cli *interfacelayer.CLI) {
+		cli.SetClock(clock.Now)
+	})
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+	want := "Iterations: 10 (0 failed)\nLatency: p50 50ms, p90 90ms, p99 100ms\nAverage tokens: 100.0 input, 200.0 output\n"
+	if output != want {
+		t.Errorf("Output = %q, want %q", output, want)
+	}
+}
+
+func TestCLI_MeasureJSON(t *testing.T) {
+	latencies := []time.Duration{30 * time.Millisecond, -1, 10 * time.Millisecond, 20 * time.Millisecond}
+
+	clock := &fakeClock{now: time.Now()}
+	code, output := runCLIWithSetup(t, []string{"program", "-apikey=test-key", "-measure=4", "-format=json", "Some thought"}, variedLatencyService(latencies, nil, clock), nil, func(cli *interfacelayer.CLI) {
+		cli.SetClock(clock.Now)
+	})
+	if code != interfacelayer.ExitError {
+		t.Errorf("Exit code = %d, want %d for a failed iteration", code, interfacelayer.ExitError)
+	}
+
+	var report struct {
+		Iterations int              `json:"iterations"`
+		Failed     int              `json:"failed"`
+		LatencyMS  map[string]int64 `json:"latency_ms"`
+		Usage      struct {
+			InputTokens  float64 `json:"input_tokens"`
+			OutputTokens float64 `json:"output_tokens"`
+		} `json:"average_usage"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Output is not JSON: %v\n%s", err, output)
+	}
+	if report.Iterations != 4 || report.Failed != 1 {
+		t.Errorf("iterations, failed = %d, %d, want 4, 1", report.Iterations, report.Failed)
+	}
+	if report.LatencyMS["p50"] != 20 || report.LatencyMS["p90"] != 30 || report.LatencyMS["p99"] != 30 {
+		t.Errorf("latency_ms = %v, want p50 20, p90 30, p99 30", report.LatencyMS)
+	}
+	if report.Usage.InputTokens != 100 || report.Usage.OutputTokens != 200 {
+		t.Errorf("average_usage = %+v, want 100 input and 200 output tokens", report.Usage)
+	}
+}
+
+func TestCLI_MeasureBatch(t *testing.T) {
+	tempDir := t.TempDir()
+	first := filepath.Join(tempDir, "first.txt")
+	os.WriteFile(first, []byte("First thought"), 0644)
+	second := filepath.Join(tempDir, "second.txt")
+	os.WriteFile(second, []byte("Second thought"), 0644)
+
+	var thoughts []string
+	service := variedLatencyService([]time.Duration{time.Millisecond}, &thoughts, &fakeClock{})
+	code, _ := runCLI(t, []string{"program", "-apikey=test-key", "-measure=3", "-input=" + first, "-input=" + second}, service, infra.NewFileStorage())
+	if code != interfacelayer.ExitOK {
+		t.Fatalf("Exit code = %d, want %d", code, interfacelayer.ExitOK)
+	}
+	if got := strings.Join(thoughts, ","); got != "First thought,Second thought,First thought" {
+		t.Errorf("Analyzed thoughts = %s, want the inputs in turn", got)
+	}
+}
@@ -0,0 +1,69 @@
+package interfacelayer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+)
+
+// ANSI escape sequences used by -format pretty
+const (
+	ansiDim    = "\x1b[2m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// ColorEnabled reports whether output written to file may use ANSI colors:
+// it must be a terminal and NO_COLOR (https://no-color.org) must not be set
+func ColorEnabled(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatPretty renders a decoded JSON value as an indented tree with sorted
+// keys. With Color set, keys are dim, strings green and numbers yellow;
+// without it the result is plain indented JSON.
+func (o FormatOptions) formatPretty(v interface{}) (string, error) {
+	generic, err := genericJSON(v)
+	if err != nil {
+		return "", err
+	}
+
+	tree := jsonTree{
+		key:  func(key string) string { return o.colorize(ansiDim, key) },
+		leaf: o.prettyLeaf,
+	}
+	var buf bytes.Buffer
+	if err := tree.write(&buf, generic, 0); err != nil {
+		return "", err
+	}
+	if o.EscapeUnicode {
+		return string(escapeNonASCII(buf.Bytes())), nil
+	}
+	return buf.String(), nil
+}
+
+// prettyLeaf renders a scalar, colored by its type
+func (o FormatOptions) prettyLeaf(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		text, err := jsonScalar(v)
+		return o.colorize(ansiGreen, text), err
+	case json.Number:
+		return o.colorize(ansiYellow, v.String()), nil
+	default:
+		return jsonScalar(v) // true, false or null
+	}
+}
+
+// colorize wraps text in an ANSI color when colors are enabled
+func (o FormatOptions) colorize(color, text string) string {
+	if !o.Color {
+		return text
+	}
+	return color + text + ansiReset
+}
@@ -0,0 +1,72 @@
+package interfacelayer_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	interfacelayer "claude-think-tool/internal/interface"
+)
+
+func TestFormatter_Pretty(t *testing.T) {
+	response := &domain.ThinkResponse{
+		Raw: map[string]interface{}{
+			"id":      "msg_123",
+			"content": []interface{}{map[string]interface{}{"type": "tool_use", "input": map[string]interface{}{"thought": "<b>"}}},
+			"usage":   map[string]interface{}{"input_tokens": float64(12)},
+			"empty":   []interface{}{},
+			"done":    true,
+		},
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		formatter := interfacelayer.NewFormatter()
+		expected := `{
+  "content": [
+    {
+      "input": {
+        "thought": "<b>"
+      },
+      "type": "tool_use"
+    }
+  ],
+  "done": true,
+  "empty": [],
+  "id": "msg_123",
+  "usage": {
+    "input_tokens": 12
+  }
+}`
+		if output := formatter.FormatOutput(response, "pretty"); output != expected {
+			t.Errorf("Unexpected pretty output:\n%s", output)
+		}
+	})
+
+	t.Run("colored", func(t *testing.T) {
+		formatter := interfacelayer.NewFormatter()
+		formatter.Color = true
+		output := formatter.FormatOutput(response, "pretty")
+		for _, want := range []string{
+			"\x1b[2m\"id\"\x1b[0m: \x1b[32m\"msg_123\"\x1b[0m",
+			"\x1b[2m\"input_tokens\"\x1b[0m: \x1b[33m12\x1b[0m",
+			"\x1b[2m\"done\"\x1b[0m: true",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in colored output:\n%q", want, output)
+			}
+		}
+	})
+}
+
+func TestColorEnabled(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if interfacelayer.ColorEnabled(w) {
+		t.Errorf("Expected no colors for a pipe")
+	}
+}
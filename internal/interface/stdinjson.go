@@ -0,0 +1,125 @@
+package interfacelayer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// stdinJSONRequest is one input line of the -stdin-json protocol. A line that
+// is a plain JSON string is a request with only a thought.
+type stdinJSONRequest struct {
+	ID      interface{} `json:"id,omitempty"`
+	Thought string      `json:"thought"`
+	Format  string      `json:"format,omitempty"`
+
+	// Per-request overrides of -model, -prompt and -max-tokens
+	Model     string `json:"model,omitempty"`
+	Prompt    string `json:"prompt,omitempty"`
+	MaxTokens *int   `json:"max_tokens,omitempty"`
+}
+
+// stdinJSONResponse is one output line of the -stdin-json protocol
+type stdinJSONResponse struct {
+	ID        interface{}      `json:"id,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	Output    string           `json:"output,omitempty"`
+	Usage     *domain.Usage    `json:"usage,omitempty"`
+	Model     string           `json:"model,omitempty"`
+	RiskLevel domain.RiskLevel `json:"risk_level,omitempty"`
+	Refused   bool             `json:"refused,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// RunStdinJSON serves the -stdin-json line protocol: each input line is a JSON
+// request and produces exactly one JSON response line. Malformed lines yield
+// an error response rather than ending the session.
+func (c *CLI) RunStdinJSON(ctx context.Context, config domain.Config, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := encoder.Encode(c.handleStdinJSONLine(ctx, config, line)); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// handleStdinJSONLine analyzes a single protocol request
+func (c *CLI) handleStdinJSONLine(ctx context.Context, config domain.Config, line string) stdinJSONResponse {
+	var req stdinJSONRequest
+	var err error
+	if strings.HasPrefix(line, `"`) {
+		err = json.Unmarshal([]byte(line), &req.Thought)
+	} else {
+		err = json.Unmarshal([]byte(line), &req)
+	}
+	if err != nil {
+		return stdinJSONResponse{Error: fmt.Sprintf("malformed request: %v", err)}
+	}
+	if strings.TrimSpace(req.Thought) == "" {
+		return stdinJSONResponse{ID: req.ID, Error: "missing \"thought\""}
+	}
+	if config, err = c.requestConfig(config, req); err != nil {
+		return stdinJSONResponse{ID: req.ID, Error: err.Error()}
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	response, err := c.thinkService.AnalyzeThought(requestCtx, req.Thought, config)
+	c.recordItem(response, err)
+	if err != nil {
+		return stdinJSONResponse{ID: req.ID, Error: err.Error()}
+	}
+
+	result := stdinJSONResponse{
+		ID:        req.ID,
+		Content:   response.Content,
+		Usage:     &response.Usage,
+		Model:     response.Model,
+		RiskLevel: response.RiskLevel,
+		Refused:   response.Refused,
+	}
+	if req.Format != "" && req.Format != "text" {
+		result.Output = c.formatter.FormatOutput(response, req.Format)
+	}
+	return result
+}
+
+// requestConfig applies the overrides of a protocol request to the session config
+func (c *CLI) requestConfig(config domain.Config, req stdinJSONRequest) (domain.Config, error) {
+	if req.Model != "" {
+		aliases := c.modelAliases
+		if aliases == nil {
+			aliases = domain.DefaultModelAliases
+		}
+		model, err := domain.ResolveModel(req.Model, aliases, c.strictAliases)
+		if err != nil {
+			return config, err
+		}
+		config.Model = model
+	}
+	if req.Prompt != "" {
+		// A per-request prompt replaces the session's prompt template too
+		config.ThoughtPrompt, config.PromptTemplate = req.Prompt, ""
+	}
+	if req.MaxTokens != nil {
+		if *req.MaxTokens <= 0 {
+			return config, fmt.Errorf("\"max_tokens\" must be positive")
+		}
+		config.MaxTokens = *req.MaxTokens
+	}
+	return config, nil
+}
@@ -0,0 +1,141 @@
+package interfacelayer_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-think-tool/internal/domain"
+	interfacelayer "claude-think-tool/internal/interface"
+	"claude-think-tool/test/unit"
+)
+
+func TestCLI_RunStdinJSON(t *testing.T) {
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			if thought == "fail" {
+				return nil, errors.New("API error")
+			}
+			return &domain.ThinkResponse{
+				Raw:       map[string]interface{}{"id": "msg_123"},
+				Content:   "Analysis of " + thought,
+				Usage:     domain.Usage{InputTokens: 120, OutputTokens: 45},
+				RiskLevel: domain.RiskLow,
+			}, nil
+		},
+	}
+	cli := interfacelayer.NewCLI(service, &unit.MockFileStorage{}, interfacelayer.NewFormatter())
+
+	input := strings.Join([]string{
+		`{"id": 1, "thought": "first"}`,
+		`this is not json`,
+		``,
+		`{"id": 2, "thought": "second", "format": "json"}`,
+		`{"id": 3, "thought": ""}`,
+		`{"id": 4, "thought": "fail"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	config := domain.Config{APIKey: "test-key", Timeout: 5 * time.Second}
+	if err := cli.RunStdinJSON(context.Background(), config, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var responses []map[string]interface{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var response map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			t.Fatalf("Response line is not valid JSON: %q", scanner.Text())
+		}
+		responses = append(responses, response)
+	}
+
+	if len(responses) != 5 {
+		t.Fatalf("Expected 5 response lines (blank line skipped), got %d: %v", len(responses), responses)
+	}
+
+	first := responses[0]
+	if first["id"] != float64(1) || first["content"] != "Analysis of first" {
+		t.Errorf("Unexpected first response: %v", first)
+	}
+	if usage, _ := first["usage"].(map[string]interface{}); usage["input_tokens"] != float64(120) {
+		t.Errorf("Expected usage in response, got %v", first["usage"])
+	}
+	if _, ok := first["output"]; ok {
+		t.Errorf("Expected no formatted output without a format, got %v", first)
+	}
+
+	if msg, _ := responses[1]["error"].(string); !strings.Contains(msg, "malformed request") {
+		t.Errorf("Expected malformed request error, got %v", responses[1])
+	}
+
+	if output, _ := responses[2]["output"].(string); !strings.Contains(output, `"id": "msg_123"`) {
+		t.Errorf("Expected JSON-formatted output for format json, got %v", responses[2])
+	}
+
+	if msg, _ := responses[3]["error"].(string); !strings.Contains(msg, "missing") || responses[3]["id"] != float64(3) {
+		t.Errorf("Expected missing thought error echoing the id, got %v", responses[3])
+	}
+
+	if msg, _ := responses[4]["error"].(string); msg != "API error" {
+		t.Errorf("Expected analysis error response, got %v", responses[4])
+	}
+}
+
+func TestCLI_RunStdinJSONOverrides(t *testing.T) {
+	configs := map[string]domain.Config{}
+	service := &unit.MockThinkService{
+		AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+			configs[thought] = config
+			return &domain.ThinkResponse{Content: "Analysis of " + thought}, nil
+		},
+	}
+	cli := interfacelayer.NewCLI(service, &unit.MockFileStorage{}, interfacelayer.NewFormatter())
+
+	input := strings.Join([]string{
+		`"plain"`,
+		`{"id": 2, "thought": "custom", "model": "haiku", "prompt": "Critique:", "max_tokens": 2048}`,
+		`{"id": 3, "thought": "defaults"}`,
+		`{"id": 4, "thought": "bad", "max_tokens": 0}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	config := domain.Config{APIKey: "test-key", Model: "global-model", MaxTokens: 1024, PromptTemplate: "Review {{.Thought}}", Timeout: 5 * time.Second}
+	if err := cli.RunStdinJSON(context.Background(), config, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 response lines, got %d:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], `"content":"Analysis of plain"`) {
+		t.Errorf("Expected a plain string record to be analyzed, got %s", lines[0])
+	}
+	if !strings.Contains(lines[3], `"error":"\"max_tokens\" must be positive"`) {
+		t.Errorf("Expected an invalid max_tokens error, got %s", lines[3])
+	}
+
+	for _, thought := range []string{"plain", "defaults"} {
+		got := configs[thought]
+		if got.Model != "global-model" || got.MaxTokens != 1024 || got.PromptTemplate != "Review {{.Thought}}" {
+			t.Errorf("Expected the session config for %q, got model %q, max tokens %d, template %q", thought, got.Model, got.MaxTokens, got.PromptTemplate)
+		}
+	}
+	custom := configs["custom"]
+	if custom.Model != domain.DefaultModelAliases["haiku"] {
+		t.Errorf("Model = %q, want the resolved haiku alias %q", custom.Model, domain.DefaultModelAliases["haiku"])
+	}
+	if custom.MaxTokens != 2048 || custom.ThoughtPrompt != "Critique:" || custom.PromptTemplate != "" {
+		t.Errorf("Expected the record's max tokens and prompt, got %d, %q, template %q", custom.MaxTokens, custom.ThoughtPrompt, custom.PromptTemplate)
+	}
+	if _, ok := configs["bad"]; ok {
+		t.Errorf("Expected the invalid record not to be analyzed")
+	}
+}
@@ -0,0 +1,61 @@
+package interfacelayer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"claude-think-tool/internal/domain"
+)
+
+// runSummary is the machine-readable summary of a run written by -summary-json
+type runSummary struct {
+	Status     string       `json:"status"` // "ok", or "failed" for a non-zero exit code
+	ExitCode   int          `json:"exit_code"`
+	Items      int          `json:"items"`
+	Failures   int          `json:"failures"`
+	Tokens     domain.Usage `json:"tokens"`
+	DurationMS int64        `json:"duration_ms"`
+
+	path    string
+	started time.Time
+}
+
+// recordItem counts one analysis of the run in the summary, if one is kept
+func (c *CLI) recordItem(response *domain.ThinkResponse, err error) {
+	if c.summary == nil {
+		return
+	}
+	c.summary.Items++
+	if err != nil {
+		c.summary.Failures++
+		return
+	}
+	c.summary.Tokens = c.summary.Tokens.Add(response.Usage)
+}
+
+// writeSummary writes the run summary as a JSON line to its path, or to
+// stdout for "-"
+func (c *CLI) writeSummary(code int) {
+	summary := c.summary
+	summary.ExitCode = code
+	summary.Status = "ok"
+	if code != ExitOK {
+		summary.Status = "failed"
+	}
+	summary.DurationMS = time.Since(summary.started).Milliseconds()
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Warning: failed to encode run summary: %v", err)
+		return
+	}
+	if summary.path == "-" {
+		fmt.Println(string(data))
+		return
+	}
+	if err := c.fileStorage.WriteToFile(summary.path, string(data)+"\n"); err != nil {
+		log.Printf("Warning: failed to write run summary: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package interfacelayer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Named time formats accepted by -time-format in addition to Go layouts
+const (
+	TimeFormatRFC3339   = "rfc3339"
+	TimeFormatUnix      = "unix"
+	TimeFormatUnixMilli = "unixmilli"
+)
+
+// ResolveTimeFormat validates a -time-format value, returning the named
+// format or Go layout to use (RFC 3339 when empty)
+func ResolveTimeFormat(value string) (string, error) {
+	switch name := strings.ToLower(strings.TrimSpace(value)); name {
+	case "", TimeFormatRFC3339:
+		return time.RFC3339, nil
+	case TimeFormatUnix, TimeFormatUnixMilli:
+		return name, nil
+	}
+
+	// A Go layout must contain at least one reference-time element, so it
+	// formats two times that differ in every field differently
+	reference := time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC)
+	other := time.Date(2007, time.February, 3, 4, 5, 6, 123456789, time.FixedZone("XYZ", 3600))
+	if other.Format(value) == reference.Format(value) {
+		return "", fmt.Errorf("invalid time format %q (use rfc3339, unix, unixmilli or a Go layout such as 2006-01-02 15:04:05)", value)
+	}
+	return value, nil
+}
+
+// FormatTime formats t according to a format resolved by ResolveTimeFormat
+func FormatTime(t time.Time, format string) string {
+	switch format {
+	case TimeFormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeFormatUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case "":
+		return t.Format(time.RFC3339)
+	default:
+		return t.Format(format)
+	}
+}
@@ -0,0 +1,48 @@
+package interfacelayer_test
+
+import (
+	"testing"
+	"time"
+
+	interfacelayer "claude-think-tool/internal/interface"
+)
+
+func TestTimeFormat(t *testing.T) {
+	moment := time.Date(2025, time.March, 4, 5, 6, 7, 8000000, time.UTC)
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		expectError bool
+	}{
+		{name: "default", value: "", want: "2025-03-04T05:06:07Z"},
+		{name: "rfc3339", value: "RFC3339", want: "2025-03-04T05:06:07Z"},
+		{name: "unix", value: "unix", want: "1741064767"},
+		{name: "unixmilli", value: "unixmilli", want: "1741064767008"},
+		{name: "go layout", value: "2006-01-02 15:04", want: "2025-03-04 05:06"},
+		{name: "date-only layout", value: "2006-01-02", want: "2025-03-04"},
+		{name: "written date layout", value: "Jan 2, 2006", want: "Mar 4, 2025"},
+		{name: "weekday layout", value: "Monday", want: "Tuesday"},
+		{name: "not a layout", value: "timestamp", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := interfacelayer.ResolveTimeFormat(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got nil", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := interfacelayer.FormatTime(moment, format); got != tt.want {
+				t.Errorf("FormatTime(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package interfacelayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// Results of a -validate check
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// validationCheck is the result of one -validate check
+type validationCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// validationReport is the -format json output of -validate
+type validationReport struct {
+	OK     bool              `json:"ok"`
+	Checks []validationCheck `json:"checks"`
+}
+
+// validatePipeline runs every check short of an analysis: the configuration,
+// the API key, the prompt template and connectivity to the API. Each result is
+// reported, and the exit code is ExitError if any check failed.
+func (c *CLI) validatePipeline(ctx context.Context, config domain.Config) int {
+	report := validationReport{OK: true}
+	add := func(name, status, detail string) {
+		report.OK = report.OK && status != checkFail
+		report.Checks = append(report.Checks, validationCheck{Name: name, Status: status, Detail: detail})
+	}
+
+	// Invalid flags, environment variables or config file values fail earlier
+	// with a usage error, so reaching this point means they passed
+	add("config", checkPass, "flags, environment and config file are valid")
+
+	keyFound := true
+	switch {
+	case !config.RequiresAPIKey():
+		add("api_key", checkSkip, "not required by this provider")
+	case config.APIKey != "":
+		add("api_key", checkPass, "set with -apikey")
+	case config.NoEnv:
+		keyFound = false
+		add("api_key", checkFail, "not set; use -apikey (-no-env ignores ANTHROPIC_API_KEY)")
+	case os.Getenv("ANTHROPIC_API_KEY") != "":
+		add("api_key", checkPass, "set with ANTHROPIC_API_KEY")
+	default:
+		keyFound = false
+		add("api_key", checkFail, "not set; use -apikey or ANTHROPIC_API_KEY")
+	}
+
+	if config.PromptTemplate == "" {
+		add("prompt_template", checkSkip, "no -prompt-template")
+	} else if _, err := domain.RenderPromptTemplate(config.PromptTemplate, domain.PromptData{Thought: "sample"}); err != nil {
+		add("prompt_template", checkFail, err.Error())
+	} else {
+		add("prompt_template", checkPass, "renders a sample thought")
+	}
+
+	preflighter, ok := c.thinkService.(domain.Preflighter)
+	switch {
+	case !keyFound:
+		add("connectivity", checkSkip, "requires an API key")
+	case !ok:
+		add("connectivity", checkSkip, domain.ErrPreflightUnsupported.Error())
+	default:
+		err := preflighter.Preflight(ctx)
+		switch {
+		case errors.Is(err, domain.ErrPreflightUnsupported):
+			add("connectivity", checkSkip, err.Error())
+		case err != nil:
+			add("connectivity", checkFail, err.Error())
+		default:
+			add("connectivity", checkPass, "the API accepted the credentials")
+		}
+	}
+
+	if config.OutputFormat == "json" {
+		jsonBytes, err := c.formatter.marshalJSON(report)
+		if err != nil {
+			log.Printf("Error formatting validation report: %v", err)
+			return ExitError
+		}
+		fmt.Println(string(jsonBytes))
+	} else {
+		for _, check := range report.Checks {
+			fmt.Printf("%-4s %s: %s\n", strings.ToUpper(check.Status), check.Name, check.Detail)
+		}
+		if report.OK {
+			fmt.Println("PASS")
+		} else {
+			fmt.Println("FAIL")
+		}
+	}
+
+	if !report.OK {
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,128 @@
+package interfacelayer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/infra"
+	interfacelayer "claude-think-tool/internal/interface"
+	"claude-think-tool/test/unit"
+)
+
+func TestCLI_Validate(t *testing.T) {
+	tempDir := t.TempDir()
+	goodTemplate := filepath.Join(tempDir, "good.tmpl")
+	os.WriteFile(goodTemplate, []byte("Review: {{.Thought}}"), 0644)
+	badTemplate := filepath.Join(tempDir, "bad.tmpl")
+	os.WriteFile(badTemplate, []byte("Review: {{.Thought"), 0644)
+
+	tests := []struct {
+		name         string
+		args         []string
+		preflightErr error
+		wantCode     int
+		wantOutput   []string
+	}{
+		{
+			name:       "all checks pass",
+			args:       []string{"-apikey=test-key", "-prompt-template=" + goodTemplate},
+			wantCode:   interfacelayer.ExitOK,
+			wantOutput: []string{"PASS config:", "PASS api_key: set with -apikey", "PASS prompt_template:", "PASS connectivity:", "\nPASS\n"},
+		},
+		{
+			name:       "missing API key skips connectivity",
+			args:       []string{"-no-env"},
+			wantCode:   interfacelayer.ExitError,
+			wantOutput: []string{"FAIL api_key:", "SKIP prompt_template:", "SKIP connectivity: requires an API key", "\nFAIL\n"},
+		},
+		{
+			name:       "invalid template",
+			args:       []string{"-apikey=test-key", "-prompt-template=" + badTemplate},
+			wantCode:   interfacelayer.ExitError,
+			wantOutput: []string{"FAIL prompt_template:", "PASS connectivity:"},
+		},
+		{
+			name:         "API unreachable",
+			args:         []string{"-apikey=test-key"},
+			preflightErr: errors.New("HTTP request failed: connection refused"),
+			wantCode:     interfacelayer.ExitError,
+			wantOutput:   []string{"FAIL connectivity: HTTP request failed: connection refused"},
+		},
+		{
+			name:         "provider without a preflight",
+			args:         []string{"-provider=ollama"},
+			preflightErr: domain.ErrPreflightUnsupported,
+			wantCode:     interfacelayer.ExitOK,
+			wantOutput:   []string{"SKIP api_key:", "SKIP connectivity:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &unit.MockThinkService{
+				AnalyzeThoughtFunc: func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+					t.Fatal("-validate must not analyze the thought")
+					return nil, nil
+				},
+				PreflightFunc: func(ctx context.Context) error {
+					return tt.preflightErr
+				},
+			}
+
+			args := append(append([]string{"program", "-validate"}, tt.args...), "Some thought")
+			code, output := runCLI(t, args, service, infra.NewFileStorage())
+			if code != tt.wantCode {
+				t.Errorf("Exit code = %d, want %d (output: %q)", code, tt.wantCode, output)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got %q", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_ValidateJSON(t *testing.T) {
+	service := &unit.MockThinkService{
+		PreflightFunc: func(ctx context.Context) error {
+			return &domain.APIError{StatusCode: 401, Body: "invalid x-api-key"}
+		},
+	}
+
+	code, output := runCLI(t, []string{"program", "-validate", "-format=json", "-apikey=bad-key"}, service, nil)
+	if code != interfacelayer.ExitError {
+		t.Errorf("Exit code = %d, want %d", code, interfacelayer.ExitError)
+	}
+
+	var report struct {
+		OK     bool `json:"ok"`
+		Checks []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Detail string `json:"detail"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Output is not JSON: %v\n%s", err, output)
+	}
+	if report.OK {
+		t.Error("ok = true, want false")
+	}
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	want := map[string]string{"config": "pass", "api_key": "pass", "prompt_template": "skip", "connectivity": "fail"}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s status = %q, want %q", name, statuses[name], status)
+		}
+	}
+}
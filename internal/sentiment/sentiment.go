@@ -0,0 +1,108 @@
+// Package sentiment estimates the tone of a text locally from a small word
+// lexicon, as a cheap signal next to Claude's analysis
+package sentiment
+
+import (
+	"strings"
+	"unicode"
+
+	"claude-think-tool/internal/domain"
+)
+
+// Sentiment labels
+const (
+	Positive = "positive"
+	Neutral  = "neutral"
+	Negative = "negative"
+)
+
+// neutralBand is the score range around 0 labeled neutral
+const neutralBand = 0.1
+
+// positiveWords and negativeWords are the lexicon; matching is by lowercase word
+var positiveWords = wordSet(`
+	good great excellent improve improves improved improvement benefit benefits
+	beneficial success successful succeed win wins gain gains growth opportunity
+	confident clear effective efficient reliable robust safe secure stable
+	strong better best positive progress happy glad excited love like easy
+	fast faster solid valuable useful promising agree support achieve achieved
+	`)
+
+var negativeWords = wordSet(`
+	bad poor terrible awful worse worst fail fails failed failure problem
+	problems risk risks risky danger dangerous unsafe insecure broken bug bugs
+	concern concerns worried worry doubt unclear confusing slow slower loss
+	lose losses weak negative hard difficult costly expensive delay delayed
+	late mistake mistakes error errors hate unhappy angry disagree crisis threat
+	`)
+
+// negators flip the polarity of the next sentiment word within negationWindow words
+var negators = wordSet(`not no never none nothing neither nor without cannot`)
+
+const negationWindow = 3
+
+// Score returns the sentiment of text: the balance of positive and negative
+// lexicon words from -1 (only negative) to 1 (only positive), with words
+// shortly after a negation ("not good") counted with the opposite polarity
+func Score(text string) domain.Sentiment {
+	positive, negative := 0, 0
+	sinceNegation := negationWindow + 1
+	for _, word := range words(text) {
+		sinceNegation++
+		if negators[word] || strings.HasSuffix(word, "n't") {
+			sinceNegation = 0
+			continue
+		}
+
+		polarity := 0
+		if positiveWords[word] {
+			polarity = 1
+		} else if negativeWords[word] {
+			polarity = -1
+		}
+		if polarity != 0 && sinceNegation <= negationWindow {
+			polarity = -polarity
+		}
+		switch polarity {
+		case 1:
+			positive++
+		case -1:
+			negative++
+		}
+	}
+
+	score := 0.0
+	if total := positive + negative; total > 0 {
+		score = float64(positive-negative) / float64(total)
+	}
+	return domain.Sentiment{Label: label(score), Score: score}
+}
+
+// label names the sentiment of a score
+func label(score float64) string {
+	switch {
+	case score > neutralBand:
+		return Positive
+	case score < -neutralBand:
+		return Negative
+	default:
+		return Neutral
+	}
+}
+
+// words splits text into lowercase words, keeping apostrophes ("don't")
+func words(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "’", "'")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+}
+
+// wordSet builds a lookup set from whitespace-separated words
+func wordSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+	return set
+}
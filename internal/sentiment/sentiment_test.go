@@ -0,0 +1,33 @@
+package sentiment_test
+
+import (
+	"testing"
+
+	"claude-think-tool/internal/sentiment"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantLabel string
+		wantScore float64
+	}{
+		{name: "clearly positive", text: "This is a great plan with clear benefits and strong, reliable results.", wantLabel: sentiment.Positive, wantScore: 1},
+		{name: "clearly negative", text: "The rollout is risky: security testing failed and the bugs are a serious concern.", wantLabel: sentiment.Negative, wantScore: -1},
+		{name: "mixed", text: "A good idea, but the timeline is risky.", wantLabel: sentiment.Neutral, wantScore: 0},
+		{name: "no sentiment words", text: "We meet on Tuesday at the office.", wantLabel: sentiment.Neutral, wantScore: 0},
+		{name: "negation flips polarity", text: "This is not good and won't be safe.", wantLabel: sentiment.Negative, wantScore: -1},
+		{name: "curly apostrophe negation", text: "It doesn’t look bad.", wantLabel: sentiment.Positive, wantScore: 1},
+		{name: "mostly positive", text: "Great progress and happy users, one small problem.", wantLabel: sentiment.Positive, wantScore: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sentiment.Score(tt.text)
+			if got.Label != tt.wantLabel || got.Score != tt.wantScore {
+				t.Errorf("Score(%q) = %+v, want %s %v", tt.text, got, tt.wantLabel, tt.wantScore)
+			}
+		})
+	}
+}
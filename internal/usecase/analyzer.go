@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// Analyzer names accepted by NewAnalyzer
+const (
+	AnalyzerDefault = "default"
+	AnalyzerFallacy = "fallacy"
+)
+
+// NewAnalyzer returns the analyzer registered under name ("default" when empty)
+func NewAnalyzer(name string) (domain.Analyzer, error) {
+	switch strings.ToLower(name) {
+	case "", AnalyzerDefault:
+		return &DefaultAnalyzer{}, nil
+	case AnalyzerFallacy:
+		return &FallacyAnalyzer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown analyzer %q (available: %s, %s)", name, AnalyzerDefault, AnalyzerFallacy)
+	}
+}
+
+// DefaultAnalyzer returns general strengths/concerns/recommendation feedback
+type DefaultAnalyzer struct{}
+
+// Analyze implements domain.Analyzer
+func (a *DefaultAnalyzer) Analyze(thought string) (*domain.AnalysisResult, error) {
+	// Create a dynamic response based on the thought
+	if thought == "Japan is cool" {
+		return &domain.AnalysisResult{Text: `I've analyzed the thought "Japan is cool":
+
+Strengths:
+- Simple and clear statement of opinion
+- Easy to understand sentiment 
+- Broadly relatable to many audiences
+
+Concerns:
+- Very general statement lacking specific details
+- No supporting evidence or reasoning provided
+- Could be perceived as overly simplistic
+
+Recommendation:
+- Consider adding specific aspects of Japan that are "cool"
+- Provide personal experiences or facts that support this opinion
+- Consider cultural context and avoid generalizations`}, nil
+	}
+
+	// Default response for other thoughts
+	return &domain.AnalysisResult{Text: `I've analyzed the thought. Here are my observations:
+
+Strengths:
+- Clear statement of opinion
+- Easy to understand the main point
+
+Concerns:
+- Limited supporting details or evidence
+- Could benefit from more specific examples
+
+Recommendation:
+- Add specific supporting details
+- Consider different perspectives
+- Clarify reasoning behind the thought`}, nil
+}
+
+// fallacyPattern is a keyword/structure heuristic for one fallacy
+type fallacyPattern struct {
+	name    string
+	advice  string
+	pattern *regexp.Regexp
+}
+
+// fallacyPatterns are checked in order; each fallacy is reported at most once
+var fallacyPatterns = []fallacyPattern{
+	{
+		name:    "Hasty generalization",
+		advice:  "Qualify sweeping claims or back them with representative evidence",
+		pattern: regexp.MustCompile(`(?i)\b(always|never|everyone|everybody|no ?one|nobody|all (?:of )?(?:the )?(?:users|customers|people|developers|teams))\b`),
+	},
+	{
+		name:    "False dichotomy",
+		advice:  "Consider options beyond the two presented",
+		pattern: regexp.MustCompile(`(?i)\b(either\b.{1,80}\bor\b|only two (?:options|choices|ways)|no other (?:choice|option|way)|the only (?:option|choice|way))`),
+	},
+	{
+		name:    "Appeal to authority",
+		advice:  "Support the claim with evidence rather than who endorses it",
+		pattern: regexp.MustCompile(`(?i)\b((?:experts?|the ceo|my boss|management|scientists|doctors|everyone important) (?:says?|said|agrees?|agreed|believes?|thinks?)|according to (?:the )?experts?|studies show)\b`),
+	},
+}
+
+// FallacyAnalyzer scans the thought for common logical fallacy patterns
+type FallacyAnalyzer struct{}
+
+// Analyze implements domain.Analyzer
+func (a *FallacyAnalyzer) Analyze(thought string) (*domain.AnalysisResult, error) {
+	var fallacies []domain.Fallacy
+	var advice []string
+	for _, fp := range fallacyPatterns {
+		if match := fp.pattern.FindString(thought); match != "" {
+			fallacies = append(fallacies, domain.Fallacy{Name: fp.name, Evidence: match})
+			advice = append(advice, fp.advice)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("I've analyzed the thought for common logical fallacies.\n\n")
+	if len(fallacies) == 0 {
+		b.WriteString("Detected fallacies:\n- None of the common patterns (hasty generalization, false dichotomy, appeal to authority) were detected\n\n")
+		b.WriteString("Recommendation:\n- Still verify that the reasoning is supported by evidence")
+		return &domain.AnalysisResult{Text: b.String()}, nil
+	}
+
+	b.WriteString("Detected fallacies:\n")
+	for _, f := range fallacies {
+		fmt.Fprintf(&b, "- %s (\"%s\")\n", f.Name, f.Evidence)
+	}
+	b.WriteString("\nRecommendation:\n")
+	for _, a := range advice {
+		fmt.Fprintf(&b, "- %s\n", a)
+	}
+
+	return &domain.AnalysisResult{
+		Text:      strings.TrimRight(b.String(), "\n"),
+		Data:      map[string]interface{}{"fallacies": fallacies},
+		Fallacies: fallacies,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/usecase"
+	"claude-think-tool/test/unit"
+)
+
+func TestNewAnalyzer(t *testing.T) {
+	for _, name := range []string{"", "default", "fallacy", "FALLACY"} {
+		if _, err := usecase.NewAnalyzer(name); err != nil {
+			t.Errorf("NewAnalyzer(%q) unexpected error: %v", name, err)
+		}
+	}
+	if _, err := usecase.NewAnalyzer("astrology"); err == nil {
+		t.Errorf("Expected error for unknown analyzer")
+	}
+}
+
+func TestFallacyAnalyzer(t *testing.T) {
+	tests := []struct {
+		name          string
+		thought       string
+		wantFallacies []string
+	}{
+		{
+			name:          "hasty generalization",
+			thought:       "One customer complained, so users always hate redesigns.",
+			wantFallacies: []string{"Hasty generalization"},
+		},
+		{
+			name:          "false dichotomy",
+			thought:       "Either we ship on Friday or the company fails.",
+			wantFallacies: []string{"False dichotomy"},
+		},
+		{
+			name:          "appeal to authority",
+			thought:       "The CEO said microservices are better, so we must migrate.",
+			wantFallacies: []string{"Appeal to authority"},
+		},
+		{
+			name:          "several fallacies",
+			thought:       "Experts say it works and everyone agrees, so it's the only option.",
+			wantFallacies: []string{"Hasty generalization", "False dichotomy", "Appeal to authority"},
+		},
+		{
+			name:          "sound reasoning",
+			thought:       "Our A/B test of 10,000 sessions showed a 4% lift, so we should roll it out gradually.",
+			wantFallacies: nil,
+		},
+	}
+
+	analyzer := &usecase.FallacyAnalyzer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.Analyze(tt.thought)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, f := range result.Fallacies {
+				got = append(got, f.Name)
+				if f.Evidence == "" || !strings.Contains(result.Text, f.Name) {
+					t.Errorf("Expected %q with evidence listed in the tool result, got %q", f.Name, result.Text)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantFallacies, ",") {
+				t.Errorf("Fallacies = %v, want %v", got, tt.wantFallacies)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_FallacyAnalyzer(t *testing.T) {
+	var toolResult []map[string]interface{}
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		defer func() { callCount++ }()
+		if callCount == 0 {
+			return createMockResponse("tool_use", true), nil
+		}
+		messages := requestMap["messages"].([]map[string]interface{})
+		blocks := messages[2]["content"].([]map[string]interface{})
+		toolResult, _ = blocks[0]["content"].([]map[string]interface{})
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	config := domain.Config{APIKey: "test-key", Analyzer: "fallacy"}
+	response, err := service.AnalyzeThought(context.Background(), "Either we rewrite it in Rust or we die.", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(response.Fallacies) != 1 || response.Fallacies[0].Name != "False dichotomy" {
+		t.Errorf("Expected a false dichotomy in the response, got %+v", response.Fallacies)
+	}
+	if len(toolResult) != 2 {
+		t.Fatalf("Expected text and data blocks in the tool result, got %v", toolResult)
+	}
+	if text, _ := toolResult[0]["text"].(string); !strings.Contains(text, "False dichotomy") {
+		t.Errorf("Expected tool result text to list the fallacy, got %q", text)
+	}
+	if data, _ := toolResult[1]["text"].(string); !strings.Contains(data, `"name":"False dichotomy"`) {
+		t.Errorf("Expected tool result data block with the fallacy, got %q", data)
+	}
+
+	if _, err := service.AnalyzeThought(context.Background(), "thought", domain.Config{APIKey: "test-key", Analyzer: "unknown"}); err == nil {
+		t.Errorf("Expected error for unknown analyzer")
+	}
+}
+
+func TestAnalyzeThought_ToolResultContentShape(t *testing.T) {
+	tests := []struct {
+		name       string
+		analyzer   string
+		thought    string
+		wantString bool
+	}{
+		{name: "text-only result stays a string", analyzer: "default", thought: "Japan is cool", wantString: true},
+		{name: "result with data becomes blocks", analyzer: "fallacy", thought: "Nobody ever reads the docs.", wantString: false},
+		{name: "result without data stays a string", analyzer: "fallacy", thought: "We measured a 4% lift.", wantString: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				defer func() { callCount++ }()
+				if callCount == 0 {
+					return createMockResponse("tool_use", true), nil
+				}
+				messages := requestMap["messages"].([]map[string]interface{})
+				content = messages[2]["content"].([]map[string]interface{})[0]["content"]
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			if _, err := service.AnalyzeThought(context.Background(), tt.thought, domain.Config{APIKey: "test-key", Analyzer: tt.analyzer}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			_, isString := content.(string)
+			if isString != tt.wantString {
+				t.Errorf("tool_result content = %#v, want string: %v", content, tt.wantString)
+			}
+		})
+	}
+}
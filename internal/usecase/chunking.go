@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"claude-think-tool/internal/domain"
+)
+
+// synthesisPrompt asks Claude to merge per-chunk analyses into one
+const synthesisPrompt = `The following are analyses of %d consecutive, slightly overlapping parts of one long document.
+Synthesize them into a single combined analysis with Strengths, Concerns and Recommendation sections, merging duplicates and resolving contradictions.
+
+%s`
+
+// ChunkThought splits text into chunks of at most size characters (runes, not
+// bytes), preferring paragraph boundaries, and prefixes each chunk after the
+// first with the last overlap characters of the previous one
+func ChunkThought(text string, size int, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if size <= 0 || utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	// Break paragraphs that are too long on their own at word boundaries
+	var pieces []string
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		for utf8.RuneCountInString(paragraph) > size {
+			prefix := runePrefix(paragraph, size)
+			cut := strings.LastIndexAny(prefix, " \n\t")
+			if cut <= 0 {
+				cut = len(prefix)
+			}
+			pieces = append(pieces, strings.TrimSpace(paragraph[:cut]))
+			paragraph = strings.TrimSpace(paragraph[cut:])
+		}
+		if paragraph != "" {
+			pieces = append(pieces, paragraph)
+		}
+	}
+
+	// Greedily pack pieces into chunks
+	var chunks []string
+	var current string
+	var currentLen int
+	for _, piece := range pieces {
+		pieceLen := utf8.RuneCountInString(piece)
+		if current != "" && currentLen+2+pieceLen > size {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		if current == "" {
+			current, currentLen = piece, pieceLen
+		} else {
+			current += "\n\n" + piece
+			currentLen += 2 + pieceLen
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	if overlap == 0 {
+		return chunks
+	}
+	overlapped := make([]string, len(chunks))
+	overlapped[0] = chunks[0]
+	for i := 1; i < len(chunks); i++ {
+		overlapped[i] = overlapTail(chunks[i-1], overlap) + "\n\n" + chunks[i]
+	}
+	return overlapped
+}
+
+// overlapTail returns roughly the last n characters of s, starting at a word boundary
+func overlapTail(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	tail := runeSuffix(s, n)
+	if i := strings.IndexAny(tail, " \n\t"); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return "..." + strings.TrimSpace(tail)
+}
+
+// runePrefix returns the first n runes of s
+func runePrefix(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// runeSuffix returns the last n runes of s
+func runeSuffix(s string, n int) string {
+	end := len(s)
+	for ; n > 0 && end > 0; n-- {
+		_, width := utf8.DecodeLastRuneInString(s[:end])
+		end -= width
+	}
+	return s[end:]
+}
+
+// analyzeChunked analyzes each chunk separately (map) and asks Claude to
+// synthesize the per-chunk analyses into a combined one (reduce)
+func (s *ThinkService) analyzeChunked(ctx context.Context, chunks []string, config domain.Config) (*domain.ThinkResponse, error) {
+	chunkConfig := config
+	chunkConfig.ChunkSize = 0
+
+	var results []domain.ChunkResult
+	var usage domain.Usage
+	var timings domain.Timings
+	var sections []string
+	var warnings []string
+	var toolTrace []domain.ToolTraceEntry
+	for i, chunk := range chunks {
+		response, err := s.AnalyzeThought(ctx, chunk, chunkConfig)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		results = append(results, domain.ChunkResult{Index: i, Thought: chunk, Content: response.Content})
+		usage = usage.Add(response.Usage)
+		timings.Merge(response.Timings)
+		toolTrace = append(toolTrace, response.ToolTrace...)
+		for _, warning := range response.Warnings {
+			warnings = append(warnings, fmt.Sprintf("chunk %d/%d: %s", i+1, len(chunks), warning))
+		}
+		sections = append(sections, fmt.Sprintf("## Part %d\n%s", i+1, strings.TrimSpace(response.Content)))
+	}
+
+	// The synthesis falls back to the other models like a single analysis
+	prompt := fmt.Sprintf(synthesisPrompt, len(chunks), strings.Join(sections, "\n\n"))
+	models := append([]string{config.Model}, config.ModelFallback...)
+	stopSynthesisRequest := timings.Track("synthesis_request")
+	var synthesisResponseMap map[string]interface{}
+	var lastErr error
+	for i, model := range models {
+		if i > 0 {
+			log.Printf("Model %s unavailable (%v), falling back to %s", models[i-1], lastErr, model)
+		}
+		modelConfig := config
+		modelConfig.Model = model
+		var err error
+		if modelConfig.MaxTokens, err = fitMaxTokens(modelConfig); err != nil {
+			return nil, err
+		}
+		synthesisRequestMap := map[string]interface{}{
+			"model":      modelConfig.Model,
+			"max_tokens": modelConfig.MaxTokens,
+			"messages": []map[string]interface{}{
+				{
+					"role":    "user",
+					"content": fmt.Sprintf("%s\n\n%s", prompt, closingInstructions(config)),
+				},
+			},
+		}
+		addRequestFields(synthesisRequestMap, config)
+
+		synthesisResponseMap, err = s.sendRequest(ctx, synthesisRequestMap, modelConfig, "synthesis")
+		if err == nil {
+			config.Model = model
+			break
+		}
+		if !isModelUnavailable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	if synthesisResponseMap == nil {
+		return nil, lastErr
+	}
+	stopSynthesisRequest()
+
+	response, err := formatThinkResponse(synthesisResponseMap, config.ContentBlockTypes)
+	if err != nil {
+		return nil, err
+	}
+	response.Model = config.Model
+	response.Usage = response.Usage.Add(usage)
+	response.Chunks = results
+	response.Timings = timings
+	response.RequestID = s.lastRequestID(ctx)
+	response.Warnings = append(warnings, response.Warnings...)
+	response.ToolTrace = toolTrace
+	return response, nil
+}
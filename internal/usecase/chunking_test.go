@@ -0,0 +1,143 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/usecase"
+	"claude-think-tool/test/unit"
+)
+
+func TestChunkThought(t *testing.T) {
+	paragraphs := []string{
+		strings.Repeat("alpha ", 10),
+		strings.Repeat("beta ", 10),
+		strings.Repeat("gamma ", 10),
+	}
+	document := strings.Join(paragraphs, "\n\n")
+
+	tests := []struct {
+		name       string
+		text       string
+		size       int
+		overlap    int
+		wantChunks int
+	}{
+		{name: "short text is a single chunk", text: "short", size: 100, wantChunks: 1},
+		{name: "disabled", text: document, size: 0, wantChunks: 1},
+		{name: "one paragraph per chunk", text: document, size: 70, wantChunks: 3},
+		{name: "two paragraphs fit", text: document, size: 130, wantChunks: 2},
+		{name: "long paragraph is split on words", text: strings.Repeat("word ", 100), size: 50, wantChunks: 10},
+		{name: "overlap keeps chunk count", text: document, size: 70, overlap: 20, wantChunks: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := usecase.ChunkThought(tt.text, tt.size, tt.overlap)
+			if len(chunks) != tt.wantChunks {
+				t.Fatalf("Got %d chunks, want %d: %q", len(chunks), tt.wantChunks, chunks)
+			}
+			for i, chunk := range chunks {
+				if tt.size > 0 && tt.overlap == 0 && len(chunk) > tt.size {
+					t.Errorf("Chunk %d has %d characters, exceeding %d", i, len(chunk), tt.size)
+				}
+			}
+		})
+	}
+
+	// Sizes count characters, and multi-byte ones are never cut in half
+	cjk := strings.Repeat("日本語の文章を分割する。", 10) + "\n\n" + strings.Repeat("これは二番目の段落です。", 10)
+	for _, overlap := range []int{0, 15} {
+		chunks := usecase.ChunkThought(cjk, 50, overlap)
+		if len(chunks) < 5 {
+			t.Errorf("Overlap %d: expected the CJK text to be split on character counts, got %d chunks", overlap, len(chunks))
+		}
+		for i, chunk := range chunks {
+			if !utf8.ValidString(chunk) {
+				t.Errorf("Overlap %d: chunk %d is not valid UTF-8: %q", overlap, i, chunk)
+			}
+			if n := utf8.RuneCountInString(chunk); overlap == 0 && n > 50 {
+				t.Errorf("Chunk %d has %d characters, exceeding 50", i, n)
+			}
+		}
+	}
+
+	overlapped := usecase.ChunkThought(document, 70, 20)
+	if !strings.Contains(overlapped[1], "alpha") || !strings.Contains(overlapped[1], "beta") {
+		t.Errorf("Expected second chunk to start with the tail of the first, got %q", overlapped[1])
+	}
+}
+
+func TestAnalyzeThought_Chunked(t *testing.T) {
+	document := strings.Repeat("first part ", 10) + "\n\n" + strings.Repeat("second part ", 10)
+
+	var prompts []string
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		messages := requestMap["messages"].([]map[string]interface{})
+		prompt, _ := messages[0]["content"].(string)
+		prompts = append(prompts, prompt)
+		if _, hasTools := requestMap["tools"]; !hasTools {
+			return unit.CreateMockTextResponse("end_turn", "Combined analysis\nRisk level: MEDIUM")
+		}
+		return unit.CreateMockTextResponse("end_turn", "Chunk analysis")
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	config := domain.Config{APIKey: "test-key", Model: "test-model", ChunkSize: 150}
+	response, err := service.AnalyzeThought(context.Background(), document, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(prompts) != 3 {
+		t.Fatalf("Expected 2 chunk analyses and 1 synthesis call, got %d", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "first part") || strings.Contains(prompts[0], "second part") {
+		t.Errorf("Expected first call to analyze only the first chunk, got %q", prompts[0])
+	}
+	if !strings.Contains(prompts[2], "## Part 1\nChunk analysis") || !strings.Contains(prompts[2], "## Part 2") {
+		t.Errorf("Expected synthesis prompt to contain both chunk analyses, got %q", prompts[2])
+	}
+
+	if len(response.Chunks) != 2 {
+		t.Errorf("Expected 2 chunk results, got %d", len(response.Chunks))
+	}
+	if !strings.Contains(response.Content, "Combined analysis") || response.RiskLevel != domain.RiskMedium {
+		t.Errorf("Expected synthesized content and risk level, got %q (%q)", response.Content, response.RiskLevel)
+	}
+}
+
+func TestAnalyzeThought_ChunkedSynthesisFallback(t *testing.T) {
+	document := strings.Repeat("first part ", 10) + "\n\n" + strings.Repeat("second part ", 10)
+
+	var synthesisModels []string
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		if _, hasTools := requestMap["tools"]; hasTools {
+			return unit.CreateMockTextResponse("end_turn", "Chunk analysis")
+		}
+		// Only the synthesis finds the primary model overloaded
+		synthesisModels = append(synthesisModels, requestMap["model"].(string))
+		if requestMap["model"] == "primary" {
+			return nil, &domain.APIError{StatusCode: 529, Body: "overloaded"}
+		}
+		return unit.CreateMockTextResponse("end_turn", "Combined analysis")
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	config := domain.Config{APIKey: "test-key", Model: "primary", ModelFallback: []string{"backup"}, ChunkSize: 150}
+	response, err := service.AnalyzeThought(context.Background(), document, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(synthesisModels, ",") != "primary,backup" {
+		t.Errorf("Synthesis models = %v, want primary then backup", synthesisModels)
+	}
+	if response.Model != "backup" || !strings.Contains(response.Content, "Combined analysis") {
+		t.Errorf("Model, Content = %q, %q, want the backup model's synthesis", response.Model, response.Content)
+	}
+}
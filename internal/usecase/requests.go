@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"claude-think-tool/internal/domain"
+)
+
+// BuildUserPrompt renders the user prompt for a thought: the prompt template,
+// the -prompt prefix or the default request to analyze the thought
+func BuildUserPrompt(thought string, config domain.Config) (string, error) {
+	switch {
+	case config.PromptTemplate != "":
+		return domain.RenderPromptTemplate(config.PromptTemplate, domain.PromptData{Thought: thought})
+	case config.ThoughtPrompt != "":
+		return fmt.Sprintf("%s %s", config.ThoughtPrompt, thought), nil
+	default:
+		return fmt.Sprintf("Please analyze the following thought: %s", thought), nil
+	}
+}
+
+// BuildInitialRequest builds the first Messages API request of an analysis:
+// the user prompt, asking for a final risk level line, with the tools on offer
+// and, if config.ForceTool names one of them, a tool_choice forcing it
+func BuildInitialRequest(thought string, config domain.Config, tools []domain.Tool) (map[string]interface{}, error) {
+	userPrompt, err := BuildUserPrompt(thought, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tools are sent as plain maps, like the rest of the request
+	toolMaps := make([]interface{}, 0, len(tools))
+	forcedToolFound := false
+	for _, tool := range tools {
+		forcedToolFound = forcedToolFound || tool.Name == config.ForceTool
+		var toolMap map[string]interface{}
+		toolBytes, err := json.Marshal(tool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tool: %w", err)
+		}
+		if err := json.Unmarshal(toolBytes, &toolMap); err != nil {
+			return nil, fmt.Errorf("failed to convert tool to map: %w", err)
+		}
+		toolMaps = append(toolMaps, toolMap)
+	}
+
+	requestMap := map[string]interface{}{
+		"model":      config.Model,
+		"max_tokens": config.MaxTokens,
+		"messages": []map[string]interface{}{
+			{
+				"role":    "user",
+				"content": fmt.Sprintf("%s\n\n%s", userPrompt, closingInstructions(config)),
+			},
+		},
+		"tools": toolMaps,
+	}
+	if config.ForceTool != "" {
+		if !forcedToolFound {
+			return nil, fmt.Errorf("cannot force tool %q: it is not one of the request's tools", config.ForceTool)
+		}
+		requestMap["tool_choice"] = map[string]interface{}{"type": "tool", "name": config.ForceTool}
+	}
+	addRequestFields(requestMap, config)
+	return requestMap, nil
+}
+
+// BuildFollowUpRequest builds the request that answers Claude's tool call: the
+// messages of the initial request, Claude's content with the tool_use and a
+// user turn with the tool result, followed by the rendered -followup-prompt
+func BuildFollowUpRequest(initialRequest map[string]interface{}, assistantContent []interface{}, toolResultBlock map[string]interface{}, thought string, config domain.Config) (map[string]interface{}, error) {
+	// The tool result comes first in the follow-up turn, then any guidance for the answer
+	followUpContent := []map[string]interface{}{toolResultBlock}
+	if config.FollowupPrompt != "" {
+		followupPrompt, err := domain.RenderPromptTemplate(config.FollowupPrompt, domain.PromptData{Thought: thought})
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(followupPrompt) != "" {
+			followUpContent = append(followUpContent, map[string]interface{}{"type": "text", "text": followupPrompt})
+		}
+	}
+
+	initialMessages, _ := initialRequest["messages"].([]map[string]interface{})
+	messages := append([]map[string]interface{}{}, initialMessages...)
+	messages = append(messages,
+		// Assistant's response with tool use
+		map[string]interface{}{
+			"role":    "assistant",
+			"content": assistantContent,
+		},
+		// Our tool result
+		map[string]interface{}{
+			"role":    "user",
+			"content": followUpContent,
+		},
+	)
+
+	requestMap := map[string]interface{}{
+		"model":      config.Model,
+		"max_tokens": config.MaxTokens,
+		"messages":   messages,
+	}
+	addRequestFields(requestMap, config)
+	return requestMap, nil
+}
@@ -0,0 +1,206 @@
+package usecase_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/usecase"
+)
+
+// testTool is a minimal tool offered in built requests
+var testTool = domain.Tool{
+	Type:        "custom",
+	Name:        "think",
+	Description: "Analyze a thought",
+	InputSchema: map[string]interface{}{"type": "object"},
+}
+
+func TestBuildInitialRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     domain.Config
+		wantPrompt string
+		wantFields map[string]interface{}
+		wantAbsent []string
+	}{
+		{
+			name:       "default prompt",
+			config:     domain.Config{Model: "claude-test", MaxTokens: 1024},
+			wantPrompt: "Please analyze the following thought: Ship it",
+			wantAbsent: []string{"metadata", "container"},
+		},
+		{
+			name:       "prompt prefix",
+			config:     domain.Config{Model: "claude-test", MaxTokens: 1024, ThoughtPrompt: "Critique:"},
+			wantPrompt: "Critique: Ship it",
+		},
+		{
+			name:       "prompt template",
+			config:     domain.Config{Model: "claude-test", MaxTokens: 1024, PromptTemplate: "Review <{{.Thought}}>"},
+			wantPrompt: "Review <Ship it>",
+		},
+		{
+			name: "request fields",
+			config: domain.Config{
+				Model:        "claude-test",
+				MaxTokens:    1024,
+				UserID:       "user-1",
+				SessionID:    "container-1",
+				RequestExtra: map[string]interface{}{"temperature": 0.2, "model": "ignored"},
+			},
+			wantPrompt: "Please analyze the following thought: Ship it",
+			wantFields: map[string]interface{}{
+				"metadata":    map[string]interface{}{"user_id": "user-1"},
+				"container":   "container-1",
+				"temperature": 0.2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := usecase.BuildInitialRequest("Ship it", tt.config, []domain.Tool{testTool})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if request["model"] != "claude-test" || request["max_tokens"] != 1024 {
+				t.Errorf("model, max_tokens = %v, %v, want claude-test, 1024", request["model"], request["max_tokens"])
+			}
+			messages := request["messages"].([]map[string]interface{})
+			if len(messages) != 1 || messages[0]["role"] != "user" {
+				t.Fatalf("messages = %v, want a single user message", messages)
+			}
+			prompt := messages[0]["content"].(string)
+			if !strings.HasPrefix(prompt, tt.wantPrompt+"\n\n") || !strings.Contains(prompt, "Risk level: LOW") {
+				t.Errorf("prompt = %q, want %q followed by the risk level instruction", prompt, tt.wantPrompt)
+			}
+			tools := request["tools"].([]interface{})
+			if len(tools) != 1 || tools[0].(map[string]interface{})["name"] != "think" {
+				t.Errorf("tools = %v, want the think tool as a map", tools)
+			}
+			for field, want := range tt.wantFields {
+				if !reflect.DeepEqual(request[field], want) {
+					t.Errorf("%s = %v, want %v", field, request[field], want)
+				}
+			}
+			for _, field := range tt.wantAbsent {
+				if _, ok := request[field]; ok {
+					t.Errorf("Unexpected field %s in the request", field)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildInitialRequest_InvalidTemplate(t *testing.T) {
+	_, err := usecase.BuildInitialRequest("Ship it", domain.Config{PromptTemplate: "{{.Thought"}, []domain.Tool{testTool})
+	if err == nil {
+		t.Fatal("Expected an error for an invalid prompt template")
+	}
+}
+
+func TestBuildInitialRequest_Focus(t *testing.T) {
+	request, err := usecase.BuildInitialRequest("Ship it", domain.Config{Focus: []string{"security", "clarity"}}, []domain.Tool{testTool})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	prompt := request["messages"].([]map[string]interface{})[0]["content"].(string)
+	for _, want := range []string{"Restrict your observations to security, clarity", "Risk level: LOW"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt = %q, want it to contain %q", prompt, want)
+		}
+	}
+
+	request, err = usecase.BuildInitialRequest("Ship it", domain.Config{}, []domain.Tool{testTool})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompt := request["messages"].([]map[string]interface{})[0]["content"].(string); strings.Contains(prompt, "Restrict your observations") {
+		t.Errorf("prompt = %q, want no focus instruction without -focus", prompt)
+	}
+}
+
+func TestBuildInitialRequest_ForceTool(t *testing.T) {
+	request, err := usecase.BuildInitialRequest("Ship it", domain.Config{ForceTool: "think", RequestExtra: map[string]interface{}{"tool_choice": "ignored"}}, []domain.Tool{testTool})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]interface{}{"type": "tool", "name": "think"}
+	if !reflect.DeepEqual(request["tool_choice"], want) {
+		t.Errorf("tool_choice = %v, want %v", request["tool_choice"], want)
+	}
+
+	if _, err := usecase.BuildInitialRequest("Ship it", domain.Config{ForceTool: "search"}, []domain.Tool{testTool}); err == nil || !strings.Contains(err.Error(), `cannot force tool "search"`) {
+		t.Errorf("Expected an error forcing an unregistered tool, got %v", err)
+	}
+}
+
+func TestBuildFollowUpRequest(t *testing.T) {
+	assistantContent := []interface{}{
+		map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "think", "input": map[string]interface{}{"thought": "Ship it"}},
+	}
+	toolResult := map[string]interface{}{"type": "tool_result", "tool_use_id": "toolu_1", "content": "Analysis"}
+
+	tests := []struct {
+		name         string
+		config       domain.Config
+		wantFollowup string
+	}{
+		{name: "tool result only", config: domain.Config{Model: "claude-test", MaxTokens: 1024, UserID: "user-1"}},
+		{name: "with follow-up prompt", config: domain.Config{Model: "claude-test", MaxTokens: 1024, UserID: "user-1", FollowupPrompt: "Be brief about {{.Thought}}"}, wantFollowup: "Be brief about Ship it"},
+		{name: "blank follow-up prompt", config: domain.Config{Model: "claude-test", MaxTokens: 1024, UserID: "user-1", FollowupPrompt: "  "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initial, err := usecase.BuildInitialRequest("Ship it", tt.config, []domain.Tool{testTool})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			request, err := usecase.BuildFollowUpRequest(initial, assistantContent, toolResult, "Ship it", tt.config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if request["model"] != "claude-test" || request["max_tokens"] != 1024 {
+				t.Errorf("model, max_tokens = %v, %v, want claude-test, 1024", request["model"], request["max_tokens"])
+			}
+			if !reflect.DeepEqual(request["metadata"], map[string]interface{}{"user_id": "user-1"}) {
+				t.Errorf("metadata = %v, want the user id", request["metadata"])
+			}
+			if _, ok := request["tools"]; ok {
+				t.Error("Unexpected tools in the follow-up request")
+			}
+
+			messages := request["messages"].([]map[string]interface{})
+			if len(messages) != 3 {
+				t.Fatalf("got %d messages, want 3", len(messages))
+			}
+			initialMessages := initial["messages"].([]map[string]interface{})
+			if !reflect.DeepEqual(messages[0], initialMessages[0]) {
+				t.Errorf("first message = %v, want the initial user message", messages[0])
+			}
+			if messages[1]["role"] != "assistant" || !reflect.DeepEqual(messages[1]["content"], assistantContent) {
+				t.Errorf("second message = %v, want the assistant's tool_use", messages[1])
+			}
+
+			content := messages[2]["content"].([]map[string]interface{})
+			if messages[2]["role"] != "user" || !reflect.DeepEqual(content[0], toolResult) {
+				t.Errorf("third message = %v, want a user turn starting with the tool result", messages[2])
+			}
+			if tt.wantFollowup == "" {
+				if len(content) != 1 {
+					t.Errorf("follow-up content = %v, want only the tool result", content)
+				}
+			} else if len(content) != 2 || content[1]["text"] != tt.wantFollowup {
+				t.Errorf("follow-up content = %v, want the tool result and %q", content, tt.wantFollowup)
+			}
+			if len(initialMessages) != 1 {
+				t.Errorf("initial request messages were modified: %v", initialMessages)
+			}
+		})
+	}
+}
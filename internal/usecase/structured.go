@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"claude-think-tool/internal/domain"
+)
+
+// respondToolName is the synthetic tool whose input carries a structured output
+const respondToolName = "respond"
+
+// analyzeStructured asks for the analysis as an object matching
+// config.StructuredOutput: the schema becomes the input schema of a "respond"
+// tool that Claude is forced to call, and the validated tool input is the result
+func (s *ThinkService) analyzeStructured(ctx context.Context, userPrompt string, config domain.Config, timings domain.Timings) (*domain.ThinkResponse, error) {
+	respondTool := domain.Tool{
+		Name:        respondToolName,
+		Description: "Respond with your analysis of the thought. The input is the complete answer, so fill in every field the schema describes.",
+		InputSchema: config.StructuredOutput,
+	}
+	requestMap := map[string]interface{}{
+		"model":      config.Model,
+		"max_tokens": config.MaxTokens,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": userPrompt},
+		},
+		"tools":       []domain.Tool{respondTool},
+		"tool_choice": map[string]interface{}{"type": "tool", "name": respondToolName},
+	}
+	addRequestFields(requestMap, config)
+
+	stopRequest := timings.Track("structured_request")
+	responseMap, err := s.sendRequest(ctx, requestMap, config, "structured output")
+	if err != nil {
+		return nil, err
+	}
+	stopRequest()
+
+	// The respond tool is forced, so running out of tokens always cuts it off
+	if responseMap["stop_reason"] == "max_tokens" {
+		return nil, truncatedToolUseError(config)
+	}
+	response, err := formatThinkResponse(responseMap, config.ContentBlockTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var structured map[string]interface{}
+	content, _ := responseMap["content"].([]interface{})
+	for _, item := range content {
+		block, _ := item.(map[string]interface{})
+		if block["type"] == "tool_use" && block["name"] == respondToolName {
+			structured, _ = block["input"].(map[string]interface{})
+			break
+		}
+	}
+	if structured == nil {
+		return nil, fmt.Errorf("structured output: Claude did not call the %s tool (stop_reason: %s)", respondToolName, response.StopReason)
+	}
+	if err := domain.ValidateSchema(structured, config.StructuredOutput); err != nil {
+		return nil, fmt.Errorf("structured output does not match the schema: %w", err)
+	}
+
+	text, err := json.MarshalIndent(structured, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format structured output: %w", err)
+	}
+	response.Content = string(text)
+	response.Structured = structured
+	response.Timings = timings
+	response.RequestID = s.lastRequestID(ctx)
+	return response, nil
+}
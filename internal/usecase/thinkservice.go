@@ -3,15 +3,45 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"net/http"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 
 	"claude-think-tool/internal/domain"
+	"claude-think-tool/internal/sentiment"
 )
 
+// riskInstruction asks Claude to end its analysis with a parseable risk label
+const riskInstruction = "Finish your answer with a final line of the form \"Risk level: LOW\", \"Risk level: MEDIUM\" or \"Risk level: HIGH\"."
+
+// closingInstructions are appended to the prompt of a request asking for an
+// analysis: the focus categories, if any, and the risk level line
+func closingInstructions(config domain.Config) string {
+	if len(config.Focus) == 0 {
+		return riskInstruction
+	}
+	return fmt.Sprintf("%s\n\n%s", domain.FocusInstruction(config.Focus), riskInstruction)
+}
+
+// invalidToolInputMessage is the is_error tool result sent for an empty or invalid tool_use input
+const invalidToolInputMessage = "The think tool input must contain a non-empty \"thought\" string. Please call the tool again with the thought to analyze."
+
+// noToolQuestion asks Claude why it answered without calling the think tool
+const noToolQuestion = "You answered without using the think tool. In two or three sentences, explain why you decided not to use it."
+
+// riskLevelPattern matches the risk label line, tolerating markdown emphasis
+var riskLevelPattern = regexp.MustCompile(`(?i)risk\s*level\W*(low|medium|high)\b`)
+
 // ThinkService implements the domain.ThinkService interface
 type ThinkService struct {
 	apiClient domain.APIClient
+	analyzer  domain.Analyzer // overrides config.Analyzer when set
 }
 
 // NewThinkService creates a new instance of ThinkService
@@ -21,83 +51,580 @@ func NewThinkService(apiClient domain.APIClient) *ThinkService {
 	}
 }
 
-// AnalyzeThought runs a complete tool use cycle with Claude to analyze a thought
+// SetAnalyzer makes the service use analyzer instead of the one named by config.Analyzer
+func (s *ThinkService) SetAnalyzer(analyzer domain.Analyzer) {
+	s.analyzer = analyzer
+}
+
+// Configure forwards the runtime configuration to the API client when it supports it
+func (s *ThinkService) Configure(config domain.Config) error {
+	if configurable, ok := s.apiClient.(domain.Configurable); ok {
+		return configurable.Configure(config)
+	}
+	return nil
+}
+
+// Preflight checks the API client's connectivity without running an analysis
+func (s *ThinkService) Preflight(ctx context.Context) error {
+	if preflighter, ok := s.apiClient.(domain.Preflighter); ok {
+		return preflighter.Preflight(ctx)
+	}
+	return domain.ErrPreflightUnsupported
+}
+
+// lastRequestID returns the request ID of the latest response to a request
+// made with ctx, or else of the API client's latest response when the client
+// reports one
+func (s *ThinkService) lastRequestID(ctx context.Context) string {
+	if recorder := domain.RequestIDFrom(ctx); recorder != nil && recorder.Value() != "" {
+		return recorder.Value()
+	}
+	if reporter, ok := s.apiClient.(domain.RequestIDReporter); ok {
+		return reporter.LastRequestID()
+	}
+	return ""
+}
+
+// modelUnavailableStatuses are the API statuses that make it worth trying a fallback model
+var modelUnavailableStatuses = map[int]bool{
+	http.StatusNotFound:            true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusServiceUnavailable:  true,
+	529:                            true, // Anthropic "overloaded"
+}
+
+// AnalyzeThought runs a complete tool use cycle with Claude to analyze a thought,
+// falling back to the models in config.ModelFallback when a model is unavailable.
+// Thoughts longer than config.ChunkSize are split and analyzed per chunk.
 func (s *ThinkService) AnalyzeThought(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
+	// Count the retries of every request made for this analysis, including
+	// those of its chunks, which share the collector
+	stats := domain.RetryStatsFrom(ctx)
+	if stats == nil {
+		ctx, stats = domain.WithRetryStats(ctx)
+	}
+	// Each analysis tracks the request ID of its own latest response
+	ctx, _ = domain.WithRequestID(ctx)
+
+	if config.NormalizeNewlines {
+		thought = domain.NormalizeNewlines(thought)
+	}
+
+	// Score the tone of the thought as given; chunks don't need a score of their own
+	var tone *domain.Sentiment
+	if config.Sentiment {
+		score := sentiment.Score(thought)
+		tone = &score
+		config.Sentiment = false
+	}
+
+	// Strip PII before anything derived from the thought leaves the machine
+	if config.RedactPII || len(config.RedactPatterns) > 0 {
+		redactor, err := domain.NewRedactor(config.RedactPII, config.RedactPatterns)
+		if err != nil {
+			return nil, err
+		}
+		var count int
+		thought, count = redactor.Redact(thought)
+		if count > 0 || config.Verbose {
+			log.Printf("Redacted %d sensitive match(es) from the thought", count)
+		}
+
+		// Chunks of the already redacted thought don't need another pass
+		config.RedactPII, config.RedactPatterns = false, nil
+	}
+
+	// The thought as sent, for provenance; chunks don't carry it
+	var input string
+	if config.IncludeInput {
+		input = thought
+		config.IncludeInput = false
+	}
+
+	// Long thoughts are analyzed chunk by chunk and synthesized
+	if config.ChunkSize > 0 {
+		if chunks := ChunkThought(thought, config.ChunkSize, config.ChunkOverlap); len(chunks) > 1 {
+			// Fit the limit once here rather than warning again for every chunk
+			var err error
+			if config.MaxTokens, err = fitMaxTokens(config); err != nil {
+				return nil, err
+			}
+			response, err := s.analyzeChunked(ctx, chunks, config)
+			if err != nil {
+				return nil, err
+			}
+			response.RetryCount, response.TotalRetryWait = stats.Totals()
+			response.Sentiment = tone
+			response.Input = input
+			return response, nil
+		}
+	}
+
+	models := append([]string{config.Model}, config.ModelFallback...)
+
+	var lastErr error
+	for i, model := range models {
+		if i > 0 {
+			log.Printf("Model %s unavailable (%v), falling back to %s", models[i-1], lastErr, model)
+		}
+
+		modelConfig := config
+		modelConfig.Model = model
+		var err error
+		if modelConfig.MaxTokens, err = fitMaxTokens(modelConfig); err != nil {
+			return nil, err
+		}
+		response, err := s.analyzeWithModel(ctx, thought, modelConfig)
+		if err == nil {
+			// A raced analysis reports the model that won instead
+			if response.Model == "" {
+				response.Model = model
+			}
+			response.RetryCount, response.TotalRetryWait = stats.Totals()
+			response.Sentiment = tone
+			response.Input = input
+			return response, nil
+		}
+		if !isModelUnavailable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// fitMaxTokens checks config.MaxTokens against the output limit of
+// config.Model before any request is sent, returning the value to use
+func fitMaxTokens(config domain.Config) (int, error) {
+	limits := config.ModelMaxTokens
+	if limits == nil {
+		limits = domain.DefaultModelMaxOutputTokens
+	}
+	limit, ok := limits[config.Model]
+	if !ok || config.MaxTokens <= limit {
+		return config.MaxTokens, nil
+	}
+	if !config.ClampMaxTokens {
+		return 0, fmt.Errorf("max tokens %d exceeds the output limit of %d tokens of model %s; lower -max-tokens or use -clamp-max-tokens", config.MaxTokens, limit, config.Model)
+	}
+	log.Printf("Warning: lowering max tokens from %d to %d, the output limit of model %s", config.MaxTokens, limit, config.Model)
+	return limit, nil
+}
+
+// isModelUnavailable reports whether err indicates the model itself could not serve the request
+func isModelUnavailable(err error) bool {
+	var apiErr *domain.APIError
+	return errors.As(err, &apiErr) && modelUnavailableStatuses[apiErr.StatusCode]
+}
+
+// analyzeWithModel runs a single tool use cycle against config.Model
+func (s *ThinkService) analyzeWithModel(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error) {
 	// Get API key from config or environment variable if not set
 	apiKey := config.APIKey
-	if apiKey == "" {
+	if apiKey == "" && config.RequiresAPIKey() {
+		if config.NoEnv {
+			return nil, fmt.Errorf("API key not found. Set it using the -apikey flag (-no-env ignores ANTHROPIC_API_KEY)")
+		}
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
 		if apiKey == "" {
 			return nil, fmt.Errorf("API key not found. Set it using the -apikey flag or ANTHROPIC_API_KEY environment variable")
 		}
 	}
 
-	// Create the think tool
-	thinkTool := createThinkTool()
-	
-	// Convert to map for API request
-	var toolMap map[string]interface{}
-	toolBytes, err := json.Marshal(thinkTool)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal tool: %w", err)
-	}
-	if err := json.Unmarshal(toolBytes, &toolMap); err != nil {
-		return nil, fmt.Errorf("failed to convert tool to map: %w", err)
+	// Resolve the analyzer that will produce the tool result
+	analyzer := s.analyzer
+	if analyzer == nil {
+		var err error
+		if analyzer, err = NewAnalyzer(config.Analyzer); err != nil {
+			return nil, err
+		}
 	}
 
-	// Prepare the user prompt
-	userPrompt := thought
-	if config.ThoughtPrompt != "" {
-		userPrompt = fmt.Sprintf("%s %s", config.ThoughtPrompt, thought)
-	} else {
-		userPrompt = fmt.Sprintf("Please analyze the following thought: %s", thought)
-	}
+	var timings domain.Timings
 
-	// Build initial request
-	initialRequestMap := map[string]interface{}{
-		"model":      config.Model,
-		"max_tokens": config.MaxTokens,
-		"messages": []map[string]interface{}{
-			{
-				"role":    "user",
-				"content": userPrompt,
-			},
-		},
-		"tools": []interface{}{toolMap},
+	// Build the initial request, offering Claude the think tool
+	stopPromptBuild := timings.Track("prompt_build")
+	if config.StructuredOutput != nil {
+		userPrompt, err := BuildUserPrompt(thought, config)
+		if err != nil {
+			return nil, err
+		}
+		stopPromptBuild()
+		return s.analyzeStructured(ctx, userPrompt, config, timings)
 	}
-
-	// Print request for debugging
-	reqJSON, _ := json.MarshalIndent(initialRequestMap, "", "  ")
-	fmt.Printf("API Request: %s\n", reqJSON)
-
-	// Send initial request
-	initialResp, err := s.apiClient.SendRequest(ctx, initialRequestMap)
+	initialRequestMap, err := BuildInitialRequest(thought, config, []domain.Tool{createThinkTool()})
 	if err != nil {
-		return nil, fmt.Errorf("initial request failed: %w", err)
+		return nil, err
+	}
+	stopPromptBuild()
+
+	// Print request for debugging; stderr keeps stdout clean for machine-readable output
+	if config.Verbose {
+		reqJSON, _ := json.MarshalIndent(initialRequestMap, "", "  ")
+		fmt.Fprintf(os.Stderr, "API Request: %s\n", reqJSON)
 	}
 
-	// Parse the response
+	// Send initial request, to several models at once if racing them
+	stopInitialRequest := timings.Track("initial_request")
 	var initialResponseMap map[string]interface{}
-	if err := json.Unmarshal(initialResp, &initialResponseMap); err != nil {
-		return nil, fmt.Errorf("failed to parse initial response: %v", err)
+	if len(config.RaceModels) > 0 {
+		var winner raceResult
+		if winner, err = s.raceInitialRequest(ctx, initialRequestMap, config); err != nil {
+			return nil, err
+		}
+		// The rest of the analysis continues with the model that answered
+		initialResponseMap = winner.response
+		if winner.requestID != "" {
+			domain.RequestIDFrom(ctx).Record(winner.requestID)
+		}
+		config.Model, config.MaxTokens = winner.model, winner.maxTokens
+		initialRequestMap["model"], initialRequestMap["max_tokens"] = winner.model, winner.maxTokens
+	} else if initialResponseMap, err = s.sendRequest(ctx, initialRequestMap, config, "initial"); err != nil {
+		return nil, err
 	}
+	stopInitialRequest()
 
 	// Check if Claude wants to use our tool
 	stopReason, ok := initialResponseMap["stop_reason"].(string)
+	if stopReason == "max_tokens" && hasToolUse(initialResponseMap) {
+		return nil, truncatedToolUseError(config)
+	}
 	if !ok || stopReason != "tool_use" {
 		// Format the response and return it
-		return formatThinkResponse(initialResponseMap)
+		response, err := formatThinkResponse(initialResponseMap, config.ContentBlockTypes)
+		if err != nil {
+			return nil, err
+		}
+		response.Model = config.Model
+		response.Timings = timings
+		response.RequestID = s.lastRequestID(ctx)
+		if config.ExplainNoTool {
+			// The rationale is a debugging aid, so failing to get one doesn't fail the analysis
+			stopExplain := timings.Track("explain_no_tool")
+			rationale, usage, err := s.explainNoTool(ctx, initialRequestMap, initialResponseMap, config)
+			stopExplain()
+			if err != nil {
+				log.Printf("Warning: failed to ask why the think tool wasn't used: %v", err)
+			} else {
+				response.ToolDecisionRationale = rationale
+				response.Usage = response.Usage.Add(usage)
+			}
+			response.Timings = timings
+		}
+		return response, nil
 	}
 
 	// Extract tool use information
-	content, ok := initialResponseMap["content"].([]interface{})
+	content, toolUse, err := findToolUse(initialResponseMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop before running the analyzer if only the tool invocation was requested
+	if config.NoFollowup {
+		response, err := formatThinkResponse(initialResponseMap, config.ContentBlockTypes)
+		if err != nil {
+			return nil, err
+		}
+		response.ToolUse = toolUse
+		response.ToolInput, _ = toolUse.Input["thought"].(string)
+		response.Model = config.Model
+		response.Timings = timings
+		response.RequestID = s.lastRequestID(ctx)
+		if config.IncludeToolTrace {
+			response.ToolTrace = []domain.ToolTraceEntry{{ToolUse: *toolUse}}
+		}
+		return response, nil
+	}
+
+	// Don't answer an empty invocation with a canned analysis; Claude gets one
+	// more turn, with the tools, to call the tool properly
+	var retryUsage domain.Usage
+	var toolTrace []domain.ToolTraceEntry
+	if !validToolInput(toolUse.Input) {
+		if config.AbortOnInvalidToolUse {
+			return nil, fmt.Errorf("tool_use %s has an empty or invalid input: %v", toolUse.ID, toolUse.Input)
+		}
+		retryRequestMap, err := buildToolRetryRequest(initialRequestMap, content, toolUse.ID, thought, config)
+		if err != nil {
+			return nil, err
+		}
+		if config.IncludeToolTrace {
+			toolTrace = append(toolTrace, domain.ToolTraceEntry{ToolUse: *toolUse, Result: invalidToolInputMessage, IsError: true})
+		}
+		stopRetry := timings.Track("tool_retry")
+		retryResponseMap, err := s.sendRequest(ctx, retryRequestMap, config, "retry")
+		if err != nil {
+			return nil, err
+		}
+		stopRetry()
+		retryUsage = parseUsage(initialResponseMap)
+
+		// Claude may answer without calling the tool again
+		if retryResponseMap["stop_reason"] != "tool_use" {
+			response, err := formatThinkResponse(retryResponseMap, config.ContentBlockTypes)
+			if err != nil {
+				return nil, err
+			}
+			response.Usage = response.Usage.Add(retryUsage)
+			response.Model = config.Model
+			response.Timings = timings
+			response.RequestID = s.lastRequestID(ctx)
+			response.ToolTrace = toolTrace
+			return response, nil
+		}
+		if content, toolUse, err = findToolUse(retryResponseMap); err != nil {
+			return nil, err
+		}
+		if !validToolInput(toolUse.Input) {
+			return nil, fmt.Errorf("tool_use %s has an empty or invalid input after a retry: %v", toolUse.ID, toolUse.Input)
+		}
+		// The retried call is answered as if it were the first
+		initialRequestMap, initialResponseMap = retryRequestMap, retryResponseMap
+	}
+
+	toolResultBlock := map[string]interface{}{
+		"type":        "tool_result",
+		"tool_use_id": toolUse.ID,
+	}
+	var fallacies []domain.Fallacy
+
+	if config.ToolResult != "" {
+		// A precomputed tool result is sent as given, without the analyzer
+		toolResultBlock["content"] = config.ToolResult
+	} else {
+		// Process the tool request - in this case, providing an analysis of the thought
+		stopAnalyzer := timings.Track("analyzer")
+		analysis, err := analyzer.Analyze(thought)
+		if err != nil {
+			return nil, fmt.Errorf("analyzer failed: %w", err)
+		}
+		stopAnalyzer()
+		if config.StripToolResultPrefix != "" {
+			if analysis.Text, err = stripPrefix(analysis.Text, config.StripToolResultPrefix); err != nil {
+				return nil, err
+			}
+		}
+		toolResult, err := toolResultContent(analysis)
+		if err != nil {
+			return nil, err
+		}
+		// An empty content string or array is rejected by some API versions, so
+		// leave it out (an empty tool result) unless that should be an error
+		if toolResult != nil {
+			toolResultBlock["content"] = toolResult
+		} else if config.ErrorOnEmptyToolResult {
+			return nil, fmt.Errorf("analyzer produced an empty tool result for tool_use %s", toolUse.ID)
+		}
+		fallacies = analysis.Fallacies
+	}
+
+	// Prepare follow-up request with tool result
+	followUpRequestMap, err := BuildFollowUpRequest(initialRequestMap, content, toolResultBlock, thought, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send follow-up request
+	stopFollowUpRequest := timings.Track("followup_request")
+	finalResponseMap, err := s.sendRequest(ctx, followUpRequestMap, config, "follow-up")
+	if err != nil {
+		return nil, err
+	}
+	stopFollowUpRequest()
+
+	// Format the response and return it, accounting for both calls' tokens
+	response, err := formatThinkResponse(finalResponseMap, config.ContentBlockTypes)
+	if err != nil {
+		return nil, err
+	}
+	response.Usage = response.Usage.Add(parseUsage(initialResponseMap)).Add(retryUsage)
+	response.Fallacies = fallacies
+	response.ToolUse = toolUse
+	response.ToolInput, _ = toolUse.Input["thought"].(string)
+	response.Model = config.Model
+	response.Timings = timings
+	response.RequestID = s.lastRequestID(ctx)
+	if config.IncludeToolTrace {
+		response.ToolTrace = append(toolTrace, domain.ToolTraceEntry{ToolUse: *toolUse, Result: toolResultBlock["content"]})
+	}
+	return response, nil
+}
+
+// raceResult is the outcome of one model's initial request in a race
+type raceResult struct {
+	model     string
+	maxTokens int
+	response  map[string]interface{}
+	requestID string
+	err       error
+}
+
+// raceInitialRequest sends the initial request to config.Model and each of
+// config.RaceModels at once and returns the first successful response. The
+// others are cancelled as soon as it arrives, which stops their generation but
+// not necessarily their billing. Only when every model fails is it an error.
+func (s *ThinkService) raceInitialRequest(ctx context.Context, requestMap map[string]interface{}, config domain.Config) (raceResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	models := append([]string{config.Model}, config.RaceModels...)
+	results := make(chan raceResult, len(models))
+	for i, model := range models {
+		// config.MaxTokens already fits the first model
+		maxTokens := config.MaxTokens
+		if i > 0 {
+			modelConfig := config
+			modelConfig.Model = model
+			var err error
+			if maxTokens, err = fitMaxTokens(modelConfig); err != nil {
+				return raceResult{}, err
+			}
+		}
+		modelRequest := make(map[string]interface{}, len(requestMap))
+		for k, v := range requestMap {
+			modelRequest[k] = v
+		}
+		modelRequest["model"], modelRequest["max_tokens"] = model, maxTokens
+
+		go func(result raceResult, request map[string]interface{}) {
+			// A request ID of its own, so a late answer can't pass for the winner's
+			requestCtx, requestID := domain.WithRequestID(ctx)
+			result.response, result.err = s.sendRequest(requestCtx, request, config, "initial")
+			result.requestID = requestID.Value()
+			results <- result
+		}(raceResult{model: model, maxTokens: maxTokens}, modelRequest)
+	}
+
+	errs := make([]error, 0, len(models))
+	for range models {
+		result := <-results
+		if result.err == nil {
+			if config.Verbose {
+				fmt.Fprintf(os.Stderr, "Model %s answered first; cancelling the other raced requests\n", result.model)
+			}
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", result.model, result.err))
+	}
+	return raceResult{}, fmt.Errorf("every raced model failed: %w", errors.Join(errs...))
+}
+
+// explainNoTool asks Claude, in a follow-up to a response that didn't call the
+// think tool, why it answered directly. Tool use is disabled for the follow-up
+// so the answer comes back as text.
+func (s *ThinkService) explainNoTool(ctx context.Context, initialRequestMap, initialResponseMap map[string]interface{}, config domain.Config) (string, domain.Usage, error) {
+	messages := append([]map[string]interface{}{}, initialRequestMap["messages"].([]map[string]interface{})...)
+	if content, _ := initialResponseMap["content"].([]interface{}); len(content) > 0 {
+		messages = append(messages, map[string]interface{}{"role": "assistant", "content": content})
+	}
+	messages = append(messages, map[string]interface{}{"role": "user", "content": noToolQuestion})
+
+	explainRequestMap := map[string]interface{}{
+		"model":       config.Model,
+		"max_tokens":  config.MaxTokens,
+		"messages":    messages,
+		"tools":       initialRequestMap["tools"],
+		"tool_choice": map[string]interface{}{"type": "none"},
+	}
+	addRequestFields(explainRequestMap, config)
+
+	explainResponseMap, err := s.sendRequest(ctx, explainRequestMap, config, "explain-no-tool")
+	if err != nil {
+		return "", domain.Usage{}, err
+	}
+	explanation, err := formatThinkResponse(explainResponseMap, nil)
+	if err != nil {
+		return "", domain.Usage{}, err
+	}
+	return strings.TrimSpace(explanation.Content), explanation.Usage, nil
+}
+
+// sendRequest sends one request of the analysis and parses the response. While
+// Claude pauses a long-running turn (stop_reason pause_turn), the request is
+// re-sent with the paused content as an assistant turn so Claude continues
+// where it stopped, up to config.MaxPauseTurns times. The returned response
+// combines the content and token usage of every turn.
+func (s *ThinkService) sendRequest(ctx context.Context, requestMap map[string]interface{}, config domain.Config, stage string) (map[string]interface{}, error) {
+	messages, _ := requestMap["messages"].([]map[string]interface{})
+	var pausedContent []interface{}
+	var pausedUsage domain.Usage
+
+	for pauses := 0; ; pauses++ {
+		responseData, err := s.apiClient.SendRequest(ctx, requestMap)
+		if err != nil {
+			return nil, fmt.Errorf("%s request failed: %w", stage, err)
+		}
+		var responseMap map[string]interface{}
+		if err := json.Unmarshal(responseData, &responseMap); err != nil {
+			return nil, fmt.Errorf("failed to parse %s response: %v", stage, err)
+		}
+
+		content, _ := responseMap["content"].([]interface{})
+		if responseMap["stop_reason"] != "pause_turn" || pauses >= config.MaxPauseTurns || messages == nil {
+			if pauses > 0 {
+				responseMap["content"] = append(pausedContent, content...)
+				usage, ok := responseMap["usage"].(map[string]interface{})
+				if !ok {
+					usage = map[string]interface{}{}
+					responseMap["usage"] = usage
+				}
+				total := parseUsage(responseMap).Add(pausedUsage)
+				usage["input_tokens"] = total.InputTokens
+				usage["output_tokens"] = total.OutputTokens
+			}
+			return responseMap, nil
+		}
+
+		// Resume from everything Claude produced so far
+		pausedContent = append(pausedContent, content...)
+		pausedUsage = pausedUsage.Add(parseUsage(responseMap))
+		resumed := make(map[string]interface{}, len(requestMap))
+		for k, v := range requestMap {
+			resumed[k] = v
+		}
+		resumed["messages"] = append(append([]map[string]interface{}{}, messages...), map[string]interface{}{
+			"role":    "assistant",
+			"content": pausedContent,
+		})
+		requestMap = resumed
+	}
+}
+
+// hasToolUse reports whether a response contains a tool_use block
+func hasToolUse(responseMap map[string]interface{}) bool {
+	content, _ := responseMap["content"].([]interface{})
+	for _, item := range content {
+		if block, ok := item.(map[string]interface{}); ok && block["type"] == "tool_use" {
+			return true
+		}
+	}
+	return false
+}
+
+// truncatedToolUseError explains a tool call cut off by the max_tokens limit,
+// whose input can't be trusted to be complete
+func truncatedToolUseError(config domain.Config) error {
+	return fmt.Errorf("Claude's tool call was cut off by the max_tokens limit of %d (stop_reason: max_tokens); raise -max-tokens and try again", config.MaxTokens)
+}
+
+// findToolUse returns the content of a tool_use response and its first
+// tool_use block
+func findToolUse(responseMap map[string]interface{}) ([]interface{}, *domain.ToolUse, error) {
+	content, ok := responseMap["content"].([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("content field missing or invalid")
+		return nil, nil, fmt.Errorf("content field missing or invalid")
 	}
 
 	var toolUseID string
 	var toolName string
+	var toolInput map[string]interface{}
 
+	// The first tool_use is answered; a reused id would make its tool_result ambiguous
+	seenIDs := make(map[string]bool)
+	found := false
 	for _, item := range content {
 		block, ok := item.(map[string]interface{})
 		if !ok {
@@ -109,96 +636,118 @@ func (s *ThinkService) AnalyzeThought(ctx context.Context, thought string, confi
 			continue
 		}
 
-		toolUseID, _ = block["id"].(string)
+		id, _ := block["id"].(string)
+		if id != "" && seenIDs[id] {
+			return nil, nil, fmt.Errorf("malformed response: tool_use id %q is used by more than one content block", id)
+		}
+		seenIDs[id] = true
+		if found {
+			continue
+		}
+
+		found = true
+		toolUseID = id
 		toolName, _ = block["name"].(string)
-		break
+		toolInput, _ = block["input"].(map[string]interface{})
 	}
 
 	if toolUseID == "" || toolName == "" {
-		return nil, fmt.Errorf("couldn't find valid tool use block")
+		return nil, nil, fmt.Errorf("couldn't find valid tool use block")
 	}
+	return content, &domain.ToolUse{ID: toolUseID, Name: toolName, Input: toolInput}, nil
+}
 
-	// Process the tool request - in this case, providing an analysis of the thought
-	// Create a dynamic response based on the thought
-	var toolResult string
-	if thought == "Japan is cool" {
-		toolResult = `I've analyzed the thought "Japan is cool":
-
-Strengths:
-- Simple and clear statement of opinion
-- Easy to understand sentiment 
-- Broadly relatable to many audiences
-
-Concerns:
-- Very general statement lacking specific details
-- No supporting evidence or reasoning provided
-- Could be perceived as overly simplistic
-
-Recommendation:
-- Consider adding specific aspects of Japan that are "cool"
-- Provide personal experiences or facts that support this opinion
-- Consider cultural context and avoid generalizations`
-	} else {
-		// Default response for other thoughts
-		toolResult = `I've analyzed the thought. Here are my observations:
-
-Strengths:
-- Clear statement of opinion
-- Easy to understand the main point
+// buildToolRetryRequest answers a tool call with an empty or invalid input
+// with an is_error result and offers the tools again, so Claude can retry it
+func buildToolRetryRequest(initialRequest map[string]interface{}, assistantContent []interface{}, toolUseID, thought string, config domain.Config) (map[string]interface{}, error) {
+	errorBlock := map[string]interface{}{
+		"type":        "tool_result",
+		"tool_use_id": toolUseID,
+		"content":     invalidToolInputMessage,
+		"is_error":    true,
+	}
+	// The -followup-prompt guides the final answer, which isn't due yet
+	config.FollowupPrompt = ""
+	requestMap, err := BuildFollowUpRequest(initialRequest, assistantContent, errorBlock, thought, config)
+	if err != nil {
+		return nil, err
+	}
+	requestMap["tools"] = initialRequest["tools"]
+	if toolChoice, ok := initialRequest["tool_choice"]; ok {
+		requestMap["tool_choice"] = toolChoice
+	}
+	return requestMap, nil
+}
 
-Concerns:
-- Limited supporting details or evidence
-- Could benefit from more specific examples
+// validToolInput reports whether a think tool input carries a non-empty thought
+func validToolInput(input map[string]interface{}) bool {
+	thought, ok := input["thought"].(string)
+	return ok && strings.TrimSpace(thought) != ""
+}
 
-Recommendation:
-- Add specific supporting details
-- Consider different perspectives
-- Clarify reasoning behind the thought`
+// addRequestFields attaches the optional per-run request fields: the end-user
+// identifier, the container that keeps server-side tool state across turns
+// and any extra fields not already set
+func addRequestFields(requestMap map[string]interface{}, config domain.Config) {
+	if config.UserID != "" {
+		requestMap["metadata"] = map[string]interface{}{"user_id": config.UserID}
 	}
-
-	// Prepare follow-up request with tool result
-	followUpRequestMap := map[string]interface{}{
-		"model":      config.Model,
-		"max_tokens": config.MaxTokens,
-		"messages": []map[string]interface{}{
-			// Original user message
-			{
-				"role":    "user",
-				"content": userPrompt,
-			},
-			// Assistant's response with tool use
-			{
-				"role":    "assistant",
-				"content": content,
-			},
-			// Our tool result
-			{
-				"role": "user",
-				"content": []map[string]interface{}{
-					{
-						"type":        "tool_result",
-						"tool_use_id": toolUseID,
-						"content":     toolResult,
-					},
-				},
-			},
-		},
+	if config.SessionID != "" {
+		requestMap["container"] = config.SessionID
 	}
+	for field, value := range config.RequestExtra {
+		if _, exists := requestMap[field]; !exists {
+			requestMap[field] = value
+		}
+	}
+}
 
-	// Send follow-up request
-	finalResp, err := s.apiClient.SendRequest(ctx, followUpRequestMap)
+// stripPrefix removes the match of pattern at the start of text, along with
+// the whitespace following it
+func stripPrefix(text, pattern string) (string, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")")
 	if err != nil {
-		return nil, fmt.Errorf("follow-up request failed: %w", err)
+		return "", fmt.Errorf("invalid tool result prefix pattern: %w", err)
 	}
+	if loc := re.FindStringIndex(text); loc != nil {
+		text = strings.TrimLeft(text[loc[1]:], " \t\r\n")
+	}
+	return text, nil
+}
 
-	// Parse final response
-	var finalResponseMap map[string]interface{}
-	if err := json.Unmarshal(finalResp, &finalResponseMap); err != nil {
-		return nil, fmt.Errorf("failed to parse final response: %v", err)
+// toolResultContent serializes an analysis into a tool_result content value:
+// a plain string for text-only results, otherwise an array of content blocks,
+// or nil when the analysis is empty
+func toolResultContent(analysis *domain.AnalysisResult) (interface{}, error) {
+	if len(analysis.Blocks) == 0 && analysis.Data == nil {
+		if strings.TrimSpace(analysis.Text) == "" {
+			return nil, nil
+		}
+		return analysis.Text, nil
 	}
 
-	// Format the response and return it
-	return formatThinkResponse(finalResponseMap)
+	var blocks []map[string]interface{}
+	if strings.TrimSpace(analysis.Text) != "" {
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": analysis.Text})
+	}
+	for _, block := range analysis.Blocks {
+		// Empty text blocks are invalid content
+		if text, isText := block["text"].(string); block["type"] == "text" && (!isText || strings.TrimSpace(text) == "") {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	if analysis.Data != nil {
+		dataJSON, err := json.Marshal(analysis.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal analyzer data: %w", err)
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": string(dataJSON)})
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return blocks, nil
 }
 
 // createThinkTool creates a new instance of the think tool
@@ -220,34 +769,140 @@ func createThinkTool() domain.Tool {
 	}
 }
 
-// formatThinkResponse converts API response to a ThinkResponse
-func formatThinkResponse(responseMap map[string]interface{}) (*domain.ThinkResponse, error) {
+// formatThinkResponse converts API response to a ThinkResponse, building the
+// content from the blocks whose type is in blockTypes (text blocks when empty)
+func formatThinkResponse(responseMap map[string]interface{}, blockTypes []string) (*domain.ThinkResponse, error) {
 	// Extract just the text content from Claude's response
 	content, ok := responseMap["content"].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("couldn't extract content from response")
 	}
+	if len(blockTypes) == 0 {
+		blockTypes = []string{"text"}
+	}
 	
 	var textContent string
-	for _, item := range content {
+	var warnings []string
+	for i, item := range content {
 		block, ok := item.(map[string]interface{})
 		if !ok {
+			warnings = append(warnings, fmt.Sprintf("content block %d is not an object", i))
 			continue
 		}
 		
 		blockType, ok := block["type"].(string)
-		if !ok || blockType != "text" {
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("content block %d has no type", i))
+			continue
+		}
+		if !slices.Contains(blockTypes, blockType) {
+			if !knownBlockTypes[blockType] {
+				warnings = append(warnings, fmt.Sprintf("unknown content block type %q", blockType))
+			}
 			continue
 		}
 		
-		text, ok := block["text"].(string)
+		text, ok := blockText(block, blockType)
 		if ok {
 			textContent += text + "\n"
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s block %d has no text", blockType, i))
 		}
 	}
 
+	// Claude may decline to answer; flag it so callers don't mistake it for an analysis
+	stopReason, _ := responseMap["stop_reason"].(string)
+
+	riskLevel := parseRiskLevel(textContent)
+	if stopReason != "tool_use" && stopReason != "refusal" {
+		if strings.TrimSpace(textContent) == "" {
+			warnings = append(warnings, "response contains no text")
+		} else {
+			warnings = append(warnings, domain.ParseSections(textContent).Warnings...)
+			if riskLevel == domain.RiskUnknown {
+				warnings = append(warnings, "no risk level found")
+			}
+		}
+	}
+	if _, ok := responseMap["usage"].(map[string]interface{}); !ok {
+		warnings = append(warnings, "response has no usage information")
+	}
+
 	return &domain.ThinkResponse{
-		Raw:     responseMap,
-		Content: textContent,
+		Raw:        responseMap,
+		Content:    textContent,
+		RiskLevel:  riskLevel,
+		Refused:    stopReason == "refusal",
+		Usage:      parseUsage(responseMap),
+		StopReason: stopReason,
+		Warnings:   warnings,
 	}, nil
-}
\ No newline at end of file
+}
+
+// knownBlockTypes are the content block types that carry no analysis text but
+// are expected in a response
+var knownBlockTypes = map[string]bool{
+	"text":              true,
+	"tool_use":          true,
+	"thinking":          true,
+	"redacted_thinking": true,
+	"server_tool_use":   true,
+}
+
+// blockText renders a content block included in the analysis text
+func blockText(block map[string]interface{}, blockType string) (string, bool) {
+	switch blockType {
+	case "thinking":
+		text, ok := block["thinking"].(string)
+		return text, ok
+	case "redacted_thinking":
+		return "[redacted thinking]", true
+	case "tool_use", "server_tool_use":
+		name, _ := block["name"].(string)
+		input, err := json.Marshal(block["input"])
+		if name == "" || err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("[%s %s %s]", blockType, name, input), true
+	default:
+		text, ok := block["text"].(string)
+		return text, ok
+	}
+}
+
+// parseUsage extracts the token counts from an API response
+func parseUsage(responseMap map[string]interface{}) domain.Usage {
+	usage, ok := responseMap["usage"].(map[string]interface{})
+	if !ok {
+		return domain.Usage{}
+	}
+	return domain.Usage{
+		InputTokens:  intValue(usage["input_tokens"]),
+		OutputTokens: intValue(usage["output_tokens"]),
+	}
+}
+
+// intValue converts a decoded JSON number (float64 from encoding/json, or
+// json.Number) to an int, returning 0 for anything else
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case float64:
+		return int(math.Round(n))
+	case json.Number:
+		f, _ := n.Float64()
+		return int(math.Round(f))
+	case int:
+		return n
+	default:
+		return 0
+	}
+}
+
+// parseRiskLevel extracts the last risk label from the response text
+func parseRiskLevel(content string) domain.RiskLevel {
+	matches := riskLevelPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return domain.RiskUnknown
+	}
+	return domain.RiskLevel(strings.ToUpper(matches[len(matches)-1][1]))
+}
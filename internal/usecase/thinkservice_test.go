@@ -1,8 +1,15 @@
 package usecase_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -144,4 +151,1677 @@ func TestAnalyzeThought(t *testing.T) {
 func createMockResponse(stopReason string, includeToolUse bool) []byte {
 	response, _ := unit.CreateMockAPIResponse(stopReason, includeToolUse)
 	return response
-}
\ No newline at end of file
+}
+
+func TestAnalyzeThought_RiskLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantLevel domain.RiskLevel
+	}{
+		{name: "low", text: "Looks fine.\nRisk level: LOW", wantLevel: domain.RiskLow},
+		{name: "medium", text: "Some gaps.\nRisk level: medium", wantLevel: domain.RiskMedium},
+		{name: "high with markdown", text: "Security untested.\n**Risk level:** HIGH", wantLevel: domain.RiskHigh},
+		{name: "last label wins", text: "Risk level: LOW at first glance, but...\nRisk level: HIGH", wantLevel: domain.RiskHigh},
+		{name: "missing label", text: "No classification given.", wantLevel: domain.RiskUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentPrompt string
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				messages := requestMap["messages"].([]map[string]interface{})
+				sentPrompt, _ = messages[0]["content"].(string)
+				return unit.CreateMockTextResponse("end_turn", tt.text)
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if response.RiskLevel != tt.wantLevel {
+				t.Errorf("RiskLevel = %q, want %q", response.RiskLevel, tt.wantLevel)
+			}
+			if !strings.Contains(sentPrompt, "Risk level: HIGH") {
+				t.Errorf("Expected prompt to request a risk level, got %q", sentPrompt)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ModelFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      map[string]error
+		fallback      []string
+		expectError   bool
+		wantModel     string
+		wantRequested []string
+	}{
+		{
+			name:          "primary succeeds",
+			failures:      map[string]error{},
+			fallback:      []string{"backup"},
+			wantModel:     "primary",
+			wantRequested: []string{"primary"},
+		},
+		{
+			name:          "overloaded primary falls back",
+			failures:      map[string]error{"primary": &domain.APIError{StatusCode: 529, Body: "overloaded"}},
+			fallback:      []string{"backup"},
+			wantModel:     "backup",
+			wantRequested: []string{"primary", "backup"},
+		},
+		{
+			name: "missing models fall through the list",
+			failures: map[string]error{
+				"primary": &domain.APIError{StatusCode: 404, Body: "not found"},
+				"backup":  &domain.APIError{StatusCode: 404, Body: "not found"},
+			},
+			fallback:      []string{"backup", "last-resort"},
+			wantModel:     "last-resort",
+			wantRequested: []string{"primary", "backup", "last-resort"},
+		},
+		{
+			name:          "bad request does not fall back",
+			failures:      map[string]error{"primary": &domain.APIError{StatusCode: 400, Body: "invalid"}},
+			fallback:      []string{"backup"},
+			expectError:   true,
+			wantRequested: []string{"primary"},
+		},
+		{
+			name:          "exhausted fallbacks return last error",
+			failures:      map[string]error{"primary": &domain.APIError{StatusCode: 529}, "backup": &domain.APIError{StatusCode: 529}},
+			fallback:      []string{"backup"},
+			expectError:   true,
+			wantRequested: []string{"primary", "backup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				model := requestMap["model"].(string)
+				requested = append(requested, model)
+				if err, ok := tt.failures[model]; ok {
+					return nil, err
+				}
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", Model: "primary", ModelFallback: tt.fallback}
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+
+			if tt.expectError != (err != nil) {
+				t.Fatalf("AnalyzeThought error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && response.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", response.Model, tt.wantModel)
+			}
+			if strings.Join(requested, ",") != strings.Join(tt.wantRequested, ",") {
+				t.Errorf("Requested models = %v, want %v", requested, tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_Refusal(t *testing.T) {
+	tests := []struct {
+		name          string
+		responses     [][]byte
+		expectRefused bool
+	}{
+		{
+			name:          "refusal on initial response",
+			responses:     [][]byte{mustMockText("refusal", "I can't help with that.")},
+			expectRefused: true,
+		},
+		{
+			name:          "refusal after tool use",
+			responses:     [][]byte{createMockResponse("tool_use", true), mustMockText("refusal", "")},
+			expectRefused: true,
+		},
+		{
+			name:          "normal answer",
+			responses:     [][]byte{mustMockText("end_turn", "Analysis")},
+			expectRefused: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				defer func() { callCount++ }()
+				return tt.responses[callCount], nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if response.Refused != tt.expectRefused {
+				t.Errorf("Refused = %v, want %v", response.Refused, tt.expectRefused)
+			}
+		})
+	}
+}
+
+// mustMockText creates a final text response for tests
+func mustMockText(stopReason string, text string) []byte {
+	response, _ := unit.CreateMockTextResponse(stopReason, text)
+	return response
+}
+
+func TestAnalyzeThought_UsageIsSummed(t *testing.T) {
+	responses := []map[string]interface{}{
+		{
+			"stop_reason": "tool_use",
+			"content":     []interface{}{map[string]interface{}{"type": "tool_use", "id": "tu_123", "name": "think"}},
+			"usage":       map[string]interface{}{"input_tokens": 100, "output_tokens": 20},
+		},
+		{
+			"stop_reason": "end_turn",
+			"content":     []interface{}{map[string]interface{}{"type": "text", "text": "Analysis"}},
+			"usage":       map[string]interface{}{"input_tokens": 150, "output_tokens": 60},
+		},
+	}
+
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		defer func() { callCount++ }()
+		return json.Marshal(responses[callCount])
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := domain.Usage{InputTokens: 250, OutputTokens: 80}
+	if response.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", response.Usage, want)
+	}
+}
+
+func TestAnalyzeThought_PromptTemplate(t *testing.T) {
+	var sentPrompt string
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		messages := requestMap["messages"].([]map[string]interface{})
+		sentPrompt, _ = messages[0]["content"].(string)
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	config := domain.Config{
+		APIKey:         "test-key",
+		ThoughtPrompt:  "ignored prefix",
+		PromptTemplate: "Play devil's advocate against: {{.Thought}}",
+	}
+	if _, err := service.AnalyzeThought(context.Background(), "We should rewrite everything", config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(sentPrompt, "Play devil's advocate against: We should rewrite everything") {
+		t.Errorf("Expected rendered template prompt, got %q", sentPrompt)
+	}
+	if strings.Contains(sentPrompt, "ignored prefix") {
+		t.Errorf("Expected template to take precedence over -prompt, got %q", sentPrompt)
+	}
+}
+
+func TestAnalyzeThought_NoFollowup(t *testing.T) {
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		callCount++
+		return unit.CreateMockToolUseResponse(map[string]interface{}{"thought": "Restated thought"})
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", NoFollowup: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if callCount != 1 {
+		t.Errorf("Expected exactly 1 API call, got %d", callCount)
+	}
+	if response.ToolUse == nil {
+		t.Fatalf("Expected tool_use details in the response")
+	}
+	if response.ToolUse.Name != "think" || response.ToolUse.ID != "tu_123" {
+		t.Errorf("Unexpected tool use %+v", response.ToolUse)
+	}
+	if response.ToolUse.Input["thought"] != "Restated thought" {
+		t.Errorf("Expected tool_use input to be surfaced, got %v", response.ToolUse.Input)
+	}
+	if response.StopReason != "tool_use" {
+		t.Errorf("StopReason = %q, want tool_use", response.StopReason)
+	}
+}
+
+func TestAnalyzeThought_UserIDMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+	}{
+		{name: "set", userID: "user-42"},
+		{name: "unset", userID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				requests = append(requests, requestMap)
+				callCount++
+				if callCount == 1 {
+					return createMockResponse("tool_use", true), nil
+				}
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", UserID: tt.userID}
+			if _, err := service.AnalyzeThought(context.Background(), "Test thought", config); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(requests) != 2 {
+				t.Fatalf("Expected 2 requests, got %d", len(requests))
+			}
+			for i, request := range requests {
+				metadata, ok := request["metadata"]
+				if tt.userID == "" {
+					if ok {
+						t.Errorf("Request %d: expected no metadata key, got %v", i, metadata)
+					}
+					continue
+				}
+				want := map[string]interface{}{"user_id": tt.userID}
+				if !reflect.DeepEqual(metadata, want) {
+					t.Errorf("Request %d: metadata = %v, want %v", i, metadata, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_Timings(t *testing.T) {
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		callCount++
+		if callCount == 1 {
+			return createMockResponse("tool_use", true), nil
+		}
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stages []string
+	for _, timing := range response.Timings {
+		stages = append(stages, timing.Stage)
+	}
+	want := []string{"prompt_build", "initial_request", "analyzer", "followup_request"}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("Timing stages = %v, want %v", stages, want)
+	}
+}
+
+func TestAnalyzeThought_SessionID(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionID string
+	}{
+		{name: "set", sessionID: "container_123"},
+		{name: "unset", sessionID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var containers []interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				container, ok := requestMap["container"]
+				if !ok {
+					container = nil
+				}
+				containers = append(containers, container)
+				callCount++
+				if callCount%2 == 1 {
+					return createMockResponse("tool_use", true), nil
+				}
+				return createMockResponse("end_turn", false), nil
+			}
+
+			// Two chunks make five turns: a tool cycle per chunk plus the synthesis
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", SessionID: tt.sessionID, ChunkSize: 12}
+			if _, err := service.AnalyzeThought(context.Background(), "First part\n\nSecond part", config); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(containers) != 5 {
+				t.Fatalf("Expected 5 requests, got %d", len(containers))
+			}
+			for i, container := range containers {
+				if tt.sessionID == "" && container != nil {
+					t.Errorf("Request %d: expected no container key, got %v", i, container)
+				}
+				if tt.sessionID != "" && container != tt.sessionID {
+					t.Errorf("Request %d: container = %v, want %s", i, container, tt.sessionID)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_RequestExtra(t *testing.T) {
+	var requests []map[string]interface{}
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		requests = append(requests, requestMap)
+		if len(requests) == 1 {
+			return createMockResponse("tool_use", true), nil
+		}
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	config := domain.Config{
+		APIKey: "test-key",
+		Model:  "test-model",
+		UserID: "user-1",
+		RequestExtra: map[string]interface{}{
+			"temperature": 0.2,
+			"metadata":    map[string]interface{}{"user_id": "from-extra"},
+		},
+	}
+	if _, err := service.AnalyzeThought(context.Background(), "Test thought", config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	for i, request := range requests {
+		if request["temperature"] != 0.2 {
+			t.Errorf("Request %d: temperature = %v, want the extra field 0.2", i, request["temperature"])
+		}
+		if request["model"] != "test-model" {
+			t.Errorf("Request %d: model = %v, want test-model", i, request["model"])
+		}
+		// Fields the tool sets itself win over extra fields
+		if metadata := request["metadata"].(map[string]interface{}); metadata["user_id"] != "user-1" {
+			t.Errorf("Request %d: metadata = %v, want the -user-id value", i, metadata)
+		}
+	}
+}
+
+func TestAnalyzeThought_InvalidToolInput(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          map[string]interface{}
+		abort          bool
+		expectError    bool
+		expectRetryMsg bool
+	}{
+		{name: "missing input asks for retry", input: nil, expectRetryMsg: true},
+		{name: "empty input asks for retry", input: map[string]interface{}{}, expectRetryMsg: true},
+		{name: "blank thought asks for retry", input: map[string]interface{}{"thought": "  "}, expectRetryMsg: true},
+		{name: "empty input aborts when configured", input: map[string]interface{}{}, abort: true, expectError: true},
+		{name: "valid input runs the analyzer", input: map[string]interface{}{"thought": "Restated"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var toolResult map[string]interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				if callCount == 1 {
+					return unit.CreateMockToolUseResponse(tt.input)
+				}
+				messages := requestMap["messages"].([]map[string]interface{})
+				toolResult = messages[2]["content"].([]map[string]interface{})[0]
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", AbortOnInvalidToolUse: tt.abort}
+			_, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "empty or invalid input") {
+					t.Fatalf("Expected invalid input error, got %v", err)
+				}
+				if callCount != 1 {
+					t.Errorf("Expected no follow-up request, got %d calls", callCount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			isError, _ := toolResult["is_error"].(bool)
+			if isError != tt.expectRetryMsg {
+				t.Errorf("is_error = %v, want %v", isError, tt.expectRetryMsg)
+			}
+			content, _ := toolResult["content"].(string)
+			if tt.expectRetryMsg && !strings.Contains(content, "call the tool again") {
+				t.Errorf("Expected a retry request in the tool result, got %q", content)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_InvalidToolInputRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryInput  map[string]interface{}
+		expectError bool
+	}{
+		{name: "valid second call is analyzed", retryInput: map[string]interface{}{"thought": "Restated"}},
+		{name: "invalid second call fails", retryInput: map[string]interface{}{}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]interface{}
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				requests = append(requests, requestMap)
+				switch len(requests) {
+				case 1:
+					return unit.CreateMockToolUseResponse(map[string]interface{}{})
+				case 2:
+					return unit.CreateMockToolUseResponse(tt.retryInput)
+				default:
+					return unit.CreateMockTextResponse("end_turn", "Final analysis")
+				}
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			service.SetAnalyzer(&stubAnalyzer{result: &domain.AnalysisResult{Text: "Sound"}})
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+
+			// The retry turn offers the tools again after the is_error result
+			if len(requests) < 2 {
+				t.Fatalf("Expected a retry request, got %d requests", len(requests))
+			}
+			if tools, _ := requests[1]["tools"].([]interface{}); len(tools) != 1 {
+				t.Errorf("Retry request tools = %v, want the think tool", requests[1]["tools"])
+			}
+			retryMessages := requests[1]["messages"].([]map[string]interface{})
+			if errorResult := retryMessages[2]["content"].([]map[string]interface{})[0]; errorResult["is_error"] != true {
+				t.Errorf("Retry request tool result = %v, want an is_error result", errorResult)
+			}
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "after a retry") {
+					t.Fatalf("Expected an error after the retry, got %v", err)
+				}
+				if len(requests) != 2 {
+					t.Errorf("Expected no follow-up request, got %d requests", len(requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(requests) != 3 {
+				t.Fatalf("Expected initial, retry and follow-up requests, got %d", len(requests))
+			}
+			followUpMessages := requests[2]["messages"].([]map[string]interface{})
+			if len(followUpMessages) != 5 {
+				t.Fatalf("Follow-up messages = %d, want the whole exchange (5)", len(followUpMessages))
+			}
+			if toolResult := followUpMessages[4]["content"].([]map[string]interface{})[0]; toolResult["content"] != "Sound" || toolResult["is_error"] != nil {
+				t.Errorf("Follow-up tool result = %v, want the analysis", toolResult)
+			}
+			if response.ToolInput != "Restated" || strings.TrimSpace(response.Content) != "Final analysis" {
+				t.Errorf("ToolInput, Content = %q, %q, want the retried call's input and the final analysis", response.ToolInput, response.Content)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ToolInput(t *testing.T) {
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		callCount++
+		if callCount == 1 {
+			return unit.CreateMockToolUseResponse(map[string]interface{}{"thought": "Restated by Claude"})
+		}
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if response.ToolInput != "Restated by Claude" {
+		t.Errorf("ToolInput = %q, want %q", response.ToolInput, "Restated by Claude")
+	}
+}
+
+func TestAnalyzeThought_RedactPII(t *testing.T) {
+	var sentPrompt string
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		messages := requestMap["messages"].([]map[string]interface{})
+		sentPrompt, _ = messages[0]["content"].(string)
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	config := domain.Config{APIKey: "test-key", RedactPII: true, RedactPatterns: []string{`ACME-\d+`}}
+	thought := "Email jane@example.com or call 415-555-0132 about ACME-42"
+	if _, err := service.AnalyzeThought(context.Background(), thought, config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, secret := range []string{"jane@example.com", "415-555-0132", "ACME-42"} {
+		if strings.Contains(sentPrompt, secret) {
+			t.Errorf("Expected %q to be redacted, got %q", secret, sentPrompt)
+		}
+	}
+	if !strings.Contains(sentPrompt, "Email [EMAIL] or call [PHONE] about [REDACTED]") {
+		t.Errorf("Expected placeholders in the prompt, got %q", sentPrompt)
+	}
+}
+
+func TestAnalyzeThought_RedactionLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		thought string
+		verbose bool
+		wantLog string
+	}{
+		{name: "matches are reported", thought: "Mail jane@example.com", wantLog: "Redacted 1 sensitive match(es)"},
+		{name: "no matches are quiet", thought: "Nothing to hide"},
+		{name: "no matches in verbose mode", thought: "Nothing to hide", verbose: true, wantLog: "Redacted 0 sensitive match(es)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			mockAPIClient := &unit.MockAPIClient{
+				SendRequestFunc: func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+					return createMockResponse("end_turn", false), nil
+				},
+			}
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", RedactPII: true, Verbose: tt.verbose}
+			if _, err := service.AnalyzeThought(context.Background(), tt.thought, config); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if tt.wantLog == "" && strings.Contains(logs.String(), "Redacted") {
+				t.Errorf("Expected no redaction log, got %q", logs.String())
+			}
+			if tt.wantLog != "" && !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("Expected log containing %q, got %q", tt.wantLog, logs.String())
+			}
+		})
+	}
+}
+
+// requestIDClient is a mock API client that also reports request IDs
+type requestIDClient struct {
+	unit.MockAPIClient
+	requestIDs []string
+	calls      int
+}
+
+// LastRequestID returns the request ID of the latest mocked response
+func (c *requestIDClient) LastRequestID() string {
+	return c.requestIDs[c.calls-1]
+}
+
+func TestAnalyzeThought_RequestID(t *testing.T) {
+	client := &requestIDClient{requestIDs: []string{"req_initial", "req_followup"}}
+	client.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		client.calls++
+		if client.calls == 1 {
+			return unit.CreateMockAPIResponse("tool_use", true)
+		}
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(client)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if response.RequestID != "req_followup" {
+		t.Errorf("RequestID = %q, want the final response's ID %q", response.RequestID, "req_followup")
+	}
+}
+
+func TestAnalyzeThought_RetryStats(t *testing.T) {
+	// A flaky client that needs one retry for the first request and two for the follow-up
+	calls := 0
+	mockClient := &unit.MockAPIClient{
+		SendRequestFunc: func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+			calls++
+			stats := domain.RetryStatsFrom(ctx)
+			if stats == nil {
+				t.Fatalf("Expected the request context to carry retry stats")
+			}
+			if calls == 1 {
+				stats.Record(time.Second)
+				return unit.CreateMockAPIResponse("tool_use", true)
+			}
+			stats.Record(time.Second)
+			stats.Record(2 * time.Second)
+			return createMockResponse("end_turn", false), nil
+		},
+	}
+
+	service := usecase.NewThinkService(mockClient)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if response.RetryCount != 3 {
+		t.Errorf("RetryCount = %d, want 3", response.RetryCount)
+	}
+	if response.TotalRetryWait != 4*time.Second {
+		t.Errorf("TotalRetryWait = %s, want 4s", response.TotalRetryWait)
+	}
+}
+
+func TestAnalyzeThought_Warnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected []string
+	}{
+		{
+			name:     "well-formed response",
+			response: `{"content":[{"type":"text","text":"Strengths:\n- Clear\nConcerns:\n- Cost\nRecommendation:\n- Ship it\nRisk level: LOW"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`,
+		},
+		{
+			name:     "missing recommendation section",
+			response: `{"content":[{"type":"text","text":"Strengths:\n- Clear\nConcerns:\n- Cost\nRisk level: LOW"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`,
+			expected: []string{"no recommendation section found"},
+		},
+		{
+			name:     "unknown block and missing risk level",
+			response: `{"content":[{"type":"citation_map"},{"type":"text","text":"Fine."}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`,
+			expected: []string{
+				`unknown content block type "citation_map"`,
+				"no strengths section found",
+				"no concerns section found",
+				"no recommendation section found",
+				"no risk level found",
+			},
+		},
+		{
+			name:     "malformed blocks and no usage",
+			response: `{"content":["text",{"text":"untyped"},{"type":"text"}],"stop_reason":"end_turn"}`,
+			expected: []string{"content block 0 is not an object", "content block 1 has no type", "text block 2 has no text", "response contains no text", "response has no usage information"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				return []byte(tt.response), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(response.Warnings, tt.expected) {
+				t.Errorf("Warnings = %q, want %q", response.Warnings, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ContentBlockTypes(t *testing.T) {
+	response := `{"content":[` +
+		`{"type":"thinking","thinking":"Weighing the rollout.","signature":"sig"},` +
+		`{"type":"redacted_thinking","data":"opaque"},` +
+		`{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"rollout risks"}},` +
+		`{"type":"future_block","payload":"?"},` +
+		`{"type":"text","text":"Risk level: LOW"}` +
+		`],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`
+
+	tests := []struct {
+		name       string
+		blockTypes []string
+		expected   string
+	}{
+		{
+			name:     "text only by default",
+			expected: "Risk level: LOW\n",
+		},
+		{
+			name:       "thinking and text",
+			blockTypes: []string{"thinking", "text"},
+			expected:   "Weighing the rollout.\nRisk level: LOW\n",
+		},
+		{
+			name:       "every known type",
+			blockTypes: domain.ContentBlockTypes,
+			expected:   "Weighing the rollout.\n[redacted thinking]\n[server_tool_use web_search {\"query\":\"rollout risks\"}]\nRisk level: LOW\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				return []byte(response), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			result, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", ContentBlockTypes: tt.blockTypes})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result.Content != tt.expected {
+				t.Errorf("Content = %q, want %q", result.Content, tt.expected)
+			}
+			// The text has no sections, which adds a warning for each
+			if want := `unknown content block type "future_block"`; len(result.Warnings) == 0 || result.Warnings[0] != want {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+			}
+		})
+	}
+}
+
+// stubAnalyzer returns a fixed analysis result
+type stubAnalyzer struct {
+	result *domain.AnalysisResult
+}
+
+// Analyze implements domain.Analyzer
+func (a *stubAnalyzer) Analyze(thought string) (*domain.AnalysisResult, error) {
+	return a.result, nil
+}
+
+func TestAnalyzeThought_EmptyToolResult(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        *domain.AnalysisResult
+		errorOnEmpty  bool
+		expectError   bool
+		expectContent interface{}
+	}{
+		{name: "empty text is omitted", result: &domain.AnalysisResult{}},
+		{name: "whitespace text is omitted", result: &domain.AnalysisResult{Text: " \n"}},
+		{name: "empty text blocks are omitted", result: &domain.AnalysisResult{Blocks: []map[string]interface{}{{"type": "text", "text": ""}}}},
+		{name: "empty result errors when configured", result: &domain.AnalysisResult{}, errorOnEmpty: true, expectError: true},
+		{name: "text is sent", result: &domain.AnalysisResult{Text: "Sound"}, errorOnEmpty: true, expectContent: "Sound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var toolResult map[string]interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				if callCount == 1 {
+					return unit.CreateMockAPIResponse("tool_use", true)
+				}
+				messages := requestMap["messages"].([]map[string]interface{})
+				toolResult = messages[2]["content"].([]map[string]interface{})[0]
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			service.SetAnalyzer(&stubAnalyzer{result: tt.result})
+			_, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", ErrorOnEmptyToolResult: tt.errorOnEmpty})
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "empty tool result") {
+					t.Fatalf("Expected an empty tool result error, got %v", err)
+				}
+				if callCount != 1 {
+					t.Errorf("Expected no follow-up request, got %d requests", callCount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			content, hasContent := toolResult["content"]
+			if tt.expectContent == nil {
+				if hasContent {
+					t.Errorf("Expected the tool_result content to be omitted, got %#v", content)
+				}
+			} else if content != tt.expectContent {
+				t.Errorf("tool_result content = %#v, want %#v", content, tt.expectContent)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_StripToolResultPrefix(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		pattern       string
+		expectError   bool
+		expectContent interface{}
+	}{
+		{name: "prefix is removed", text: "I've analyzed the thought. Here are my observations:\n\nSound plan", pattern: `I've analyzed the thought[^\n]*`, expectContent: "Sound plan"},
+		{name: "match must be at the start", text: "Sound plan. I've analyzed the thought.", pattern: `I've analyzed the thought\.`, expectContent: "Sound plan. I've analyzed the thought."},
+		{name: "no match keeps the text", text: "Sound plan", pattern: "Summary:", expectContent: "Sound plan"},
+		{name: "invalid pattern", text: "Sound plan", pattern: "(", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var toolResult map[string]interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				if callCount == 1 {
+					return unit.CreateMockAPIResponse("tool_use", true)
+				}
+				messages := requestMap["messages"].([]map[string]interface{})
+				toolResult = messages[2]["content"].([]map[string]interface{})[0]
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			service.SetAnalyzer(&stubAnalyzer{result: &domain.AnalysisResult{Text: tt.text}})
+			_, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", StripToolResultPrefix: tt.pattern})
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "invalid tool result prefix pattern") {
+					t.Fatalf("Expected an invalid pattern error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if toolResult["content"] != tt.expectContent {
+				t.Errorf("tool_result content = %#v, want %#v", toolResult["content"], tt.expectContent)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ToolResult(t *testing.T) {
+	var toolResult map[string]interface{}
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		callCount++
+		if callCount == 1 {
+			return unit.CreateMockAPIResponse("tool_use", true)
+		}
+		messages := requestMap["messages"].([]map[string]interface{})
+		toolResult = messages[2]["content"].([]map[string]interface{})[0]
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	service.SetAnalyzer(&stubAnalyzer{result: &domain.AnalysisResult{Text: "From the analyzer"}})
+	config := domain.Config{APIKey: "test-key", ToolResult: "Precomputed analysis\n"}
+	if _, err := service.AnalyzeThought(context.Background(), "Test thought", config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if toolResult["content"] != "Precomputed analysis\n" {
+		t.Errorf("tool_result content = %#v, want the precomputed result", toolResult["content"])
+	}
+	if toolResult["tool_use_id"] != "tu_123" {
+		t.Errorf("tool_result tool_use_id = %#v, want tu_123", toolResult["tool_use_id"])
+	}
+}
+
+func TestAnalyzeThought_PauseTurn(t *testing.T) {
+	paused := []byte(`{"stop_reason":"pause_turn","content":[{"type":"text","text":"Still searching"}],"usage":{"input_tokens":10,"output_tokens":5}}`)
+
+	t.Run("paused turns are resumed", func(t *testing.T) {
+		var requests []map[string]interface{}
+		mockAPIClient := &unit.MockAPIClient{}
+		mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+			requests = append(requests, requestMap)
+			switch len(requests) {
+			case 1, 2:
+				return paused, nil
+			case 3:
+				return unit.CreateMockAPIResponse("tool_use", true)
+			default:
+				return unit.CreateMockTextResponse("end_turn", "Done.\nRisk level: LOW")
+			}
+		}
+
+		service := usecase.NewThinkService(mockAPIClient)
+		response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", MaxPauseTurns: 3})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(requests) != 4 {
+			t.Fatalf("Expected 4 requests (two resumed pauses, the tool cycle), got %d", len(requests))
+		}
+		// Each resumed request carries everything Claude produced so far as one assistant turn
+		resumed := requests[2]["messages"].([]map[string]interface{})
+		if len(resumed) != 2 || resumed[1]["role"] != "assistant" || len(resumed[1]["content"].([]interface{})) != 2 {
+			t.Errorf("Expected the user turn and both paused turns as one assistant turn, got %v", resumed)
+		}
+		// The tool cycle continues from the combined content
+		followUp := requests[3]["messages"].([]map[string]interface{})
+		if blocks := followUp[1]["content"].([]interface{}); len(blocks) != 3 {
+			t.Errorf("Expected the paused text blocks and the tool_use in the assistant turn, got %v", blocks)
+		}
+		if strings.TrimSpace(response.Content) != "Done.\nRisk level: LOW" {
+			t.Errorf("Content = %q, want the final answer", response.Content)
+		}
+		if response.Usage.InputTokens != 20 || response.Usage.OutputTokens != 10 {
+			t.Errorf("Usage = %+v, want the paused turns' tokens included", response.Usage)
+		}
+	})
+
+	t.Run("resuming stops at the cap", func(t *testing.T) {
+		callCount := 0
+		mockAPIClient := &unit.MockAPIClient{}
+		mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+			callCount++
+			return paused, nil
+		}
+
+		service := usecase.NewThinkService(mockAPIClient)
+		response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", MaxPauseTurns: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if callCount != 3 {
+			t.Errorf("Expected 3 requests (the original and 2 resumptions), got %d", callCount)
+		}
+		if response.StopReason != "pause_turn" {
+			t.Errorf("StopReason = %q, want pause_turn", response.StopReason)
+		}
+	})
+}
+
+func TestAnalyzeThought_Sentiment(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    *domain.Sentiment
+	}{
+		{name: "enabled", enabled: true, want: &domain.Sentiment{Label: "negative", Score: -1}},
+		{name: "disabled", enabled: false, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", Sentiment: tt.enabled}
+			response, err := service.AnalyzeThought(context.Background(), "The launch is risky and the tests failed.", config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(response.Sentiment, tt.want) {
+				t.Errorf("Sentiment = %+v, want %+v", response.Sentiment, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_IncludeInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		config domain.Config
+		want   string
+	}{
+		{name: "enabled", config: domain.Config{IncludeInput: true}, want: "Email jane@example.com about the launch."},
+		{name: "redacted", config: domain.Config{IncludeInput: true, RedactPII: true}, want: "Email [EMAIL] about the launch."},
+		{name: "disabled", config: domain.Config{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			config := tt.config
+			config.APIKey = "test-key"
+			response, err := service.AnalyzeThought(context.Background(), "Email jane@example.com about the launch.", config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if response.Input != tt.want {
+				t.Errorf("Input = %q, want %q", response.Input, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_NormalizeNewlines(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalize  bool
+		wantPrompt string
+	}{
+		{name: "enabled", normalize: true, wantPrompt: "Plan:\n- ship\n- test\n\nDone"},
+		{name: "disabled", wantPrompt: "Plan:\r\n- ship\r- test\n\r\nDone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				prompt = requestMap["messages"].([]map[string]interface{})[0]["content"].(string)
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			config := domain.Config{APIKey: "test-key", NormalizeNewlines: tt.normalize}
+			if _, err := service.AnalyzeThought(context.Background(), "Plan:\r\n- ship\r- test\n\r\nDone", config); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(prompt, tt.wantPrompt) {
+				t.Errorf("prompt = %q, want it to contain %q", prompt, tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_RaceModels(t *testing.T) {
+	// Each model has its own client: the fast one answers the think tool cycle,
+	// the slow one would take far longer than the test and reports its cancellation
+	var fastModels []string
+	fast := &unit.MockAPIClient{}
+	fast.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		fastModels = append(fastModels, requestMap["model"].(string))
+		time.Sleep(10 * time.Millisecond)
+		if len(requestMap["messages"].([]map[string]interface{})) == 1 {
+			return createMockResponse("tool_use", true), nil
+		}
+		return unit.CreateMockTextResponse("end_turn", "Fast analysis")
+	}
+	cancelled := make(chan struct{})
+	slow := &unit.MockAPIClient{}
+	slow.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Second):
+			return unit.CreateMockTextResponse("end_turn", "Slow analysis")
+		}
+	}
+	router := &unit.MockAPIClient{}
+	router.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		if requestMap["model"] == "fast-model" {
+			return fast.SendRequest(ctx, requestMap)
+		}
+		return slow.SendRequest(ctx, requestMap)
+	}
+
+	service := usecase.NewThinkService(router)
+	config := domain.Config{APIKey: "test-key", Model: "slow-model", MaxTokens: 1024, RaceModels: []string{"fast-model"}}
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.TrimSpace(response.Content) != "Fast analysis" || response.Model != "fast-model" {
+		t.Errorf("Content, Model = %q, %q, want the fast model's analysis", response.Content, response.Model)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("The slow model's request was not cancelled")
+	}
+	// Only the initial request is raced; the follow-up goes to the winner
+	if !reflect.DeepEqual(fastModels, []string{"fast-model", "fast-model"}) {
+		t.Errorf("Fast client requests = %v, want the initial request and the follow-up", fastModels)
+	}
+
+	t.Run("every model fails", func(t *testing.T) {
+		failing := &unit.MockAPIClient{}
+		failing.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+			return nil, &domain.APIError{StatusCode: 529, Body: "overloaded"}
+		}
+		service := usecase.NewThinkService(failing)
+		_, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+		if err == nil || !strings.Contains(err.Error(), "every raced model failed") || !strings.Contains(err.Error(), "fast-model") {
+			t.Errorf("Expected an error naming each raced model, got %v", err)
+		}
+		var apiErr *domain.APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("Expected the API errors to be wrapped, got %v", err)
+		}
+	})
+
+	t.Run("request ID of the winner", func(t *testing.T) {
+		// The slow model's response arrives while the fast one is still being
+		// returned, so it is the client's latest but not the winner's
+		client := &sharedRequestIDClient{}
+		client.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+			if requestMap["model"] == "fast-model" {
+				client.record(ctx, "req_fast")
+				time.Sleep(20 * time.Millisecond)
+				return unit.CreateMockTextResponse("end_turn", "Fast analysis")
+			}
+			time.Sleep(10 * time.Millisecond)
+			client.record(ctx, "req_slow")
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		service := usecase.NewThinkService(client)
+		response, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if response.RequestID != "req_fast" {
+			t.Errorf("RequestID = %q, want the winner's %q", response.RequestID, "req_fast")
+		}
+	})
+}
+
+// sharedRequestIDClient is a mock API client whose concurrent requests share
+// one latest request ID, like the real client's
+type sharedRequestIDClient struct {
+	unit.MockAPIClient
+	mu   sync.Mutex
+	last string
+}
+
+// record stores the request ID of a response the way the real client does
+func (c *sharedRequestIDClient) record(ctx context.Context, requestID string) {
+	c.mu.Lock()
+	c.last = requestID
+	c.mu.Unlock()
+	if recorder := domain.RequestIDFrom(ctx); recorder != nil {
+		recorder.Record(requestID)
+	}
+}
+
+// LastRequestID returns the request ID of the latest mocked response
+func (c *sharedRequestIDClient) LastRequestID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+func TestAnalyzeThought_ToolTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		toolInput string
+		want      []domain.ToolTraceEntry
+	}{
+		{
+			name:      "records the tool_use and result",
+			enabled:   true,
+			toolInput: `{"thought":"Ship it"}`,
+			want: []domain.ToolTraceEntry{{
+				ToolUse: domain.ToolUse{ID: "toolu_1", Name: "think", Input: map[string]interface{}{"thought": "Ship it"}},
+				Result:  "Sound",
+			}},
+		},
+		{
+			name:      "records error results",
+			enabled:   true,
+			toolInput: `{}`,
+			want: []domain.ToolTraceEntry{{
+				ToolUse: domain.ToolUse{ID: "toolu_1", Name: "think", Input: map[string]interface{}{}},
+				Result:  "The think tool input must contain a non-empty \"thought\" string. Please call the tool again with the thought to analyze.",
+				IsError: true,
+			}},
+		},
+		{name: "disabled", toolInput: `{"thought":"Ship it"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				if callCount == 1 {
+					return []byte(`{"stop_reason":"tool_use","content":[{"type":"tool_use","id":"toolu_1","name":"think","input":` + tt.toolInput + `}]}`), nil
+				}
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			service.SetAnalyzer(&stubAnalyzer{result: &domain.AnalysisResult{Text: "Sound"}})
+			config := domain.Config{APIKey: "test-key", IncludeToolTrace: tt.enabled}
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(response.ToolTrace, tt.want) {
+				t.Errorf("ToolTrace = %+v, want %+v", response.ToolTrace, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_DuplicateToolUseIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name:        "two blocks sharing an id",
+			content:     `[{"type":"tool_use","id":"toolu_1","name":"think","input":{"thought":"a"}},{"type":"tool_use","id":"toolu_1","name":"think","input":{"thought":"b"}}]`,
+			expectError: true,
+		},
+		{
+			name:    "distinct ids",
+			content: `[{"type":"tool_use","id":"toolu_1","name":"think","input":{"thought":"a"}},{"type":"tool_use","id":"toolu_2","name":"think","input":{"thought":"b"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				if callCount == 1 {
+					return []byte(`{"stop_reason":"tool_use","content":` + tt.content + `}`), nil
+				}
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			_, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key"})
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), `tool_use id "toolu_1" is used by more than one content block`) {
+					t.Fatalf("Expected a duplicate id error, got %v", err)
+				}
+				if callCount != 1 {
+					t.Errorf("Expected no follow-up request, got %d requests", callCount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ExplainNoTool(t *testing.T) {
+	tests := []struct {
+		name          string
+		explain       bool
+		useTool       bool
+		wantRequests  int
+		wantRationale string
+	}{
+		{name: "asks why the tool was skipped", explain: true, wantRequests: 2, wantRationale: "The question was simple enough to answer directly."},
+		{name: "disabled", explain: false, wantRequests: 1},
+		{name: "tool used", explain: true, useTool: true, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]interface{}
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				requests = append(requests, requestMap)
+				switch {
+				case len(requests) == 1 && tt.useTool:
+					return unit.CreateMockAPIResponse("tool_use", true)
+				case len(requests) == 1:
+					return []byte(`{"stop_reason":"end_turn","content":[{"type":"text","text":"Hi!\nRisk level: LOW"}],"usage":{"input_tokens":10,"output_tokens":5}}`), nil
+				default:
+					return []byte(`{"stop_reason":"end_turn","content":[{"type":"text","text":"The question was simple enough to answer directly.\n"}],"usage":{"input_tokens":30,"output_tokens":12}}`), nil
+				}
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			response, err := service.AnalyzeThought(context.Background(), "Hello", domain.Config{APIKey: "test-key", ExplainNoTool: tt.explain})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(requests) != tt.wantRequests {
+				t.Fatalf("Expected %d requests, got %d", tt.wantRequests, len(requests))
+			}
+			if response.ToolDecisionRationale != tt.wantRationale {
+				t.Errorf("ToolDecisionRationale = %q, want %q", response.ToolDecisionRationale, tt.wantRationale)
+			}
+			if tt.wantRationale == "" {
+				return
+			}
+
+			// The question follows the original exchange, with tool use disabled
+			messages := requests[1]["messages"].([]map[string]interface{})
+			if len(messages) != 3 || messages[1]["role"] != "assistant" || messages[2]["role"] != "user" {
+				t.Errorf("Expected the user prompt, Claude's answer and the question, got %v", messages)
+			}
+			if choice, _ := requests[1]["tool_choice"].(map[string]interface{}); choice["type"] != "none" {
+				t.Errorf("Expected tool_choice none, got %v", requests[1]["tool_choice"])
+			}
+			// The analysis itself is unchanged, but the extra tokens are counted
+			if strings.TrimSpace(response.Content) != "Hi!\nRisk level: LOW" {
+				t.Errorf("Content = %q, want the original answer", response.Content)
+			}
+			if response.Usage.InputTokens != 40 || response.Usage.OutputTokens != 17 {
+				t.Errorf("Usage = %+v, want both requests' tokens", response.Usage)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ExplainNoToolFailure(t *testing.T) {
+	callCount := 0
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		callCount++
+		if callCount == 1 {
+			return unit.CreateMockTextResponse("end_turn", "Hi!\nRisk level: LOW")
+		}
+		return nil, errors.New("connection reset")
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	response, err := service.AnalyzeThought(context.Background(), "Hello", domain.Config{APIKey: "test-key", ExplainNoTool: true})
+	if err != nil {
+		t.Fatalf("Expected the analysis to succeed without a rationale, got error: %v", err)
+	}
+	if response.ToolDecisionRationale != "" {
+		t.Errorf("Expected no rationale, got %q", response.ToolDecisionRationale)
+	}
+}
+
+func TestAnalyzeThought_FollowupPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		prompt      string
+		expectError bool
+		expectText  string
+	}{
+		{name: "prompt follows the tool result", prompt: "Using the analysis above, give a final recommendation for: {{.Thought}}", expectText: "Using the analysis above, give a final recommendation for: Test thought"},
+		{name: "no prompt", prompt: ""},
+		{name: "invalid template", prompt: "{{.Missing}}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var followUp []map[string]interface{}
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				if callCount == 1 {
+					return unit.CreateMockAPIResponse("tool_use", true)
+				}
+				messages := requestMap["messages"].([]map[string]interface{})
+				followUp = messages[2]["content"].([]map[string]interface{})
+				return createMockResponse("end_turn", false), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			_, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", FollowupPrompt: tt.prompt})
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "failed to render prompt template") {
+					t.Fatalf("Expected a template error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(followUp) == 0 || followUp[0]["type"] != "tool_result" {
+				t.Fatalf("Expected the tool_result to come first, got %v", followUp)
+			}
+			if tt.expectText == "" {
+				if len(followUp) != 1 {
+					t.Errorf("Expected only the tool_result, got %v", followUp)
+				}
+				return
+			}
+			if len(followUp) != 2 || followUp[1]["type"] != "text" || followUp[1]["text"] != tt.expectText {
+				t.Errorf("Expected a text block %q after the tool_result, got %v", tt.expectText, followUp)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_MaxTokensLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        domain.Config
+		expectError   bool
+		wantMaxTokens int
+	}{
+		{name: "within the limit", config: domain.Config{Model: "claude-3-opus-20240229", MaxTokens: 4096}, wantMaxTokens: 4096},
+		{name: "over the limit fails before sending", config: domain.Config{Model: "claude-3-opus-20240229", MaxTokens: 8000}, expectError: true},
+		{name: "over the limit is clamped", config: domain.Config{Model: "claude-3-opus-20240229", MaxTokens: 8000, ClampMaxTokens: true}, wantMaxTokens: 4096},
+		{name: "overridden table", config: domain.Config{Model: "claude-custom", MaxTokens: 8000, ClampMaxTokens: true, ModelMaxTokens: map[string]int{"claude-custom": 2000}}, wantMaxTokens: 2000},
+		{name: "unknown model is not checked", config: domain.Config{Model: "claude-custom", MaxTokens: 100000}, wantMaxTokens: 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentMaxTokens []interface{}
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				sentMaxTokens = append(sentMaxTokens, requestMap["max_tokens"])
+				return unit.CreateMockTextResponse("end_turn", "Fine.\nRisk level: LOW")
+			}
+
+			config := tt.config
+			config.APIKey = "test-key"
+			service := usecase.NewThinkService(mockAPIClient)
+			_, err := service.AnalyzeThought(context.Background(), "Test thought", config)
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "exceeds the output limit of 4096 tokens") {
+					t.Fatalf("Expected an output limit error, got %v", err)
+				}
+				if len(sentMaxTokens) != 0 {
+					t.Errorf("Expected no request to be sent, got %d", len(sentMaxTokens))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(sentMaxTokens) != 1 || sentMaxTokens[0] != tt.wantMaxTokens {
+				t.Errorf("Sent max_tokens %v, want %d", sentMaxTokens, tt.wantMaxTokens)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_StructuredOutput(t *testing.T) {
+	schema, err := domain.ParseOutputSchema(`{"type": "object", "required": ["risk", "concerns"], "properties": {"risk": {"type": "string", "enum": ["low", "medium", "high"]}, "concerns": {"type": "array", "items": {"type": "string"}}}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		expectError string
+	}{
+		{name: "matching object", input: `{"risk": "high", "concerns": ["security testing is incomplete"]}`},
+		{name: "object not matching the schema", input: `{"risk": "severe", "concerns": []}`, expectError: "structured output does not match the schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]interface{}
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				requests = append(requests, requestMap)
+				return []byte(`{"stop_reason":"tool_use","content":[{"type":"tool_use","id":"toolu_1","name":"respond","input":` + tt.input + `}]}`), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", StructuredOutput: schema})
+
+			if len(requests) != 1 {
+				t.Fatalf("Expected a single request, got %d", len(requests))
+			}
+			tools := requests[0]["tools"].([]domain.Tool)
+			if len(tools) != 1 || tools[0].Name != "respond" || !reflect.DeepEqual(tools[0].InputSchema, schema) {
+				t.Errorf("Expected only the respond tool with the schema, got %+v", tools)
+			}
+			if choice := requests[0]["tool_choice"].(map[string]interface{}); choice["type"] != "tool" || choice["name"] != "respond" {
+				t.Errorf("Expected tool_choice forcing the respond tool, got %v", choice)
+			}
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := domain.ValidateSchema(response.Structured, schema); err != nil {
+				t.Errorf("Structured result doesn't match the schema: %v", err)
+			}
+			want := map[string]interface{}{"risk": "high", "concerns": []interface{}{"security testing is incomplete"}}
+			if !reflect.DeepEqual(response.Structured, want) {
+				t.Errorf("Structured = %v, want %v", response.Structured, want)
+			}
+			var content map[string]interface{}
+			if err := json.Unmarshal([]byte(response.Content), &content); err != nil || !reflect.DeepEqual(content, want) {
+				t.Errorf("Expected Content to hold the object as JSON, got %q", response.Content)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_TruncatedToolUse(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expectError bool
+	}{
+		{
+			name:        "tool call cut off",
+			response:    `{"stop_reason":"max_tokens","content":[{"type":"text","text":"Let me think."},{"type":"tool_use","id":"toolu_1","name":"think","input":{}}]}`,
+			expectError: true,
+		},
+		{
+			name:     "text answer cut off",
+			response: `{"stop_reason":"max_tokens","content":[{"type":"text","text":"The plan looks"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			mockAPIClient := &unit.MockAPIClient{}
+			mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+				callCount++
+				return []byte(tt.response), nil
+			}
+
+			service := usecase.NewThinkService(mockAPIClient)
+			response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", MaxTokens: 50})
+
+			if callCount != 1 {
+				t.Errorf("Expected no follow-up request, got %d requests", callCount)
+			}
+			if !tt.expectError {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if response.StopReason != "max_tokens" {
+					t.Errorf("StopReason = %q, want max_tokens", response.StopReason)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "cut off by the max_tokens limit of 50") || !strings.Contains(err.Error(), "raise -max-tokens") {
+				t.Fatalf("Expected a truncated tool call error suggesting a higher -max-tokens, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAnalyzeThought_ForceTool(t *testing.T) {
+	var requests []map[string]interface{}
+	mockAPIClient := &unit.MockAPIClient{}
+	mockAPIClient.SendRequestFunc = func(ctx context.Context, requestMap map[string]interface{}) ([]byte, error) {
+		requests = append(requests, requestMap)
+		if len(requests) == 1 {
+			return unit.CreateMockAPIResponse("tool_use", true)
+		}
+		return createMockResponse("end_turn", false), nil
+	}
+
+	service := usecase.NewThinkService(mockAPIClient)
+	response, err := service.AnalyzeThought(context.Background(), "Test thought", domain.Config{APIKey: "test-key", ForceTool: "think"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{"type": "tool", "name": "think"}
+	if !reflect.DeepEqual(requests[0]["tool_choice"], want) {
+		t.Errorf("tool_choice = %v, want %v", requests[0]["tool_choice"], want)
+	}
+	if len(requests) != 2 || response.ToolUse == nil {
+		t.Fatalf("Expected the tool branch with a follow-up request, got %d requests and tool use %v", len(requests), response.ToolUse)
+	}
+	if _, ok := requests[1]["tool_choice"]; ok {
+		t.Error("The follow-up request must not force a tool")
+	}
+}
+
@@ -1,19 +1,21 @@
 package main
 
 import (
-	"net/http"
+	"log"
 	"os"
 	"time"
 
+	"claude-think-tool/internal/domain"
 	"claude-think-tool/internal/infra"
 	interfacelayer "claude-think-tool/internal/interface"
 	"claude-think-tool/internal/usecase"
 )
 
 func main() {
-	// Create HTTP client with timeout
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
+	// Create HTTP client with timeout; the CLI reconfigures it once flags are parsed
+	httpClient, err := infra.NewHTTPClient(domain.Config{Timeout: 30 * time.Second})
+	if err != nil {
+		log.Fatalf("Error creating HTTP client: %v", err)
 	}
 
 	// Get API key from environment
@@ -23,12 +25,17 @@ func main() {
 	apiClient := infra.NewClaudeAPIClient(httpClient, apiKey)
 	fileStorage := infra.NewFileStorage()
 
-	// Initialize use cases
-	thinkService := usecase.NewThinkService(apiClient)
+	// Initialize use cases; CTT_FAKE_API=1 swaps the API for canned responses
+	// once the service is configured (unless -no-env is given)
+	simulated := os.Getenv(infra.FakeAPIEnv) == "1"
+	thinkService := usecase.NewThinkService(infra.NewProviderClient(apiClient))
 
 	// Initialize interface layer
 	formatter := interfacelayer.NewFormatter()
 	cli := interfacelayer.NewCLI(thinkService, fileStorage, formatter)
+	cli.SetClipboard(infra.NewSystemClipboard())
+	cli.SetDiffSource(infra.NewGitDiff())
+	cli.SetSimulated(simulated)
 
 	// Run the application
 	cli.Run()
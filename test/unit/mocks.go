@@ -22,6 +22,7 @@ func (m *MockAPIClient) SendRequest(ctx context.Context, requestMap map[string]i
 type MockFileStorage struct {
 	ReadFromFileFunc func(filePath string) (string, error)
 	WriteToFileFunc  func(filePath string, content string) error
+	AppendToFileFunc func(filePath string, content string) error
 }
 
 // ReadFromFile calls the mocked function
@@ -34,9 +35,15 @@ func (m *MockFileStorage) WriteToFile(filePath string, content string) error {
 	return m.WriteToFileFunc(filePath, content)
 }
 
+// AppendToFile calls the mocked function
+func (m *MockFileStorage) AppendToFile(filePath string, content string) error {
+	return m.AppendToFileFunc(filePath, content)
+}
+
 // MockThinkService implements domain.ThinkService for testing
 type MockThinkService struct {
 	AnalyzeThoughtFunc func(ctx context.Context, thought string, config domain.Config) (*domain.ThinkResponse, error)
+	PreflightFunc      func(ctx context.Context) error
 }
 
 // AnalyzeThought calls the mocked function
@@ -44,6 +51,40 @@ func (m *MockThinkService) AnalyzeThought(ctx context.Context, thought string, c
 	return m.AnalyzeThoughtFunc(ctx, thought, config)
 }
 
+// Preflight calls the mocked function, if any
+func (m *MockThinkService) Preflight(ctx context.Context) error {
+	if m.PreflightFunc == nil {
+		return domain.ErrPreflightUnsupported
+	}
+	return m.PreflightFunc(ctx)
+}
+
+// MockClipboard implements domain.Clipboard for testing
+type MockClipboard struct {
+	ReadClipboardFunc  func() (string, error)
+	WriteClipboardFunc func(text string) error
+}
+
+// ReadClipboard calls the mocked function
+func (m *MockClipboard) ReadClipboard() (string, error) {
+	return m.ReadClipboardFunc()
+}
+
+// WriteClipboard calls the mocked function
+func (m *MockClipboard) WriteClipboard(text string) error {
+	return m.WriteClipboardFunc(text)
+}
+
+// MockDiffSource implements domain.DiffSource for testing
+type MockDiffSource struct {
+	ReadDiffFunc func(args []string) (string, error)
+}
+
+// ReadDiff calls the mocked function
+func (m *MockDiffSource) ReadDiff(args []string) (string, error) {
+	return m.ReadDiffFunc(args)
+}
+
 // Helper function to create mock Claude API responses
 func CreateMockAPIResponse(stopReason string, includeToolUse bool) ([]byte, error) {
 	content := []map[string]interface{}{}
@@ -53,6 +94,9 @@ func CreateMockAPIResponse(stopReason string, includeToolUse bool) ([]byte, erro
 			"type": "tool_use",
 			"id":   "tu_123",
 			"name": "think",
+			"input": map[string]interface{}{
+				"thought": "This is a test thought",
+			},
 		})
 	} else {
 		content = append(content, map[string]interface{}{
@@ -77,4 +121,36 @@ func CreateMockAPIResponse(stopReason string, includeToolUse bool) ([]byte, erro
 var (
 	ErrNotFound = errors.New("not found")
 	ErrAPIError = errors.New("API error")
-)
\ No newline at end of file
+)
+// CreateMockTextResponse creates a final Claude API response carrying the given text
+func CreateMockTextResponse(stopReason string, text string) ([]byte, error) {
+	response := map[string]interface{}{
+		"id":   "msg_123",
+		"type": "message",
+		"role": "assistant",
+		"content": []map[string]interface{}{
+			{"type": "text", "text": text},
+		},
+		"stop_reason": stopReason,
+		"model":       "claude-3-opus-20240229",
+	}
+
+	return json.Marshal(response)
+}
+
+// CreateMockToolUseResponse creates an initial Claude API response invoking the think tool with input
+func CreateMockToolUseResponse(input map[string]interface{}) ([]byte, error) {
+	response := map[string]interface{}{
+		"id":   "msg_123",
+		"type": "message",
+		"role": "assistant",
+		"content": []map[string]interface{}{
+			{"type": "text", "text": "Let me think about this."},
+			{"type": "tool_use", "id": "tu_123", "name": "think", "input": input},
+		},
+		"stop_reason": "tool_use",
+		"model":       "claude-3-opus-20240229",
+	}
+
+	return json.Marshal(response)
+}